@@ -15,6 +15,18 @@ func (db *DB) InsertDrive(ctx context.Context, drive *storage.DriveInfo, created
 	return db.conn.WithContext(ctx).Create(model).Error
 }
 
+// DeleteDrive removes a drive record by UUID along with its SMART history.
+func (db *DB) DeleteDrive(ctx context.Context, driveUuid string) error {
+	result := db.conn.WithContext(ctx).Delete(&DriveModel{}, "uuid = ?", driveUuid)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return storage.ErrDriveNotFound
+	}
+	return nil
+}
+
 // QueryDriveByKey finds an adopted drive by its key.
 func (db *DB) QueryDriveByKey(ctx context.Context, key storage.DriveKey) (storage.AdoptedDrive, bool, error) {
 	var model DriveModel
@@ -33,6 +45,24 @@ func (db *DB) QueryDriveByKey(ctx context.Context, key storage.DriveKey) (storag
 	return adoptedDrive, true, nil
 }
 
+// QueryDriveByUuid finds an adopted drive by its UUID.
+func (db *DB) QueryDriveByUuid(ctx context.Context, driveUuid string) (storage.AdoptedDrive, bool, error) {
+	var model DriveModel
+	err := db.conn.WithContext(ctx).
+		Where("uuid = ?", driveUuid).
+		First(&model).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return storage.AdoptedDrive{}, false, nil
+	}
+	if err != nil {
+		return storage.AdoptedDrive{}, false, err
+	}
+
+	adoptedDrive := model.ToAdoptedDrive()
+	return adoptedDrive, true, nil
+}
+
 // QueryAllAdoptedDrives returns all adopted drives ordered by creation time.
 func (db *DB) QueryAllAdoptedDrives(ctx context.Context) ([]storage.AdoptedDrive, error) {
 	var models []DriveModel
@@ -50,7 +80,9 @@ func (db *DB) QueryAllAdoptedDrives(ctx context.Context) ([]storage.AdoptedDrive
 }
 
 type DrivePatch struct {
-	PoolID *string
+	PoolID   *string // empty string clears the pool association
+	Role     *storage.DriveRole
+	PartUuid *string // empty string clears the recorded partition
 }
 
 // PatchDrive updates a drive record using the provided patch.
@@ -58,7 +90,19 @@ func (db *DB) PatchDrive(ctx context.Context, driveUuid string, p DrivePatch) er
 	updates := make(map[string]interface{})
 
 	if p.PoolID != nil {
-		updates["poolID"] = *p.PoolID
+		if *p.PoolID == "" {
+			updates["poolID"] = nil
+		} else {
+			updates["poolID"] = *p.PoolID
+		}
+	}
+
+	if p.Role != nil {
+		updates["role"] = string(*p.Role)
+	}
+
+	if p.PartUuid != nil {
+		updates["partUuid"] = *p.PartUuid
 	}
 
 	if len(updates) == 0 {
@@ -0,0 +1,42 @@
+package DB
+
+import (
+	"context"
+	"goNAS/storage"
+)
+
+// SaveFsck inserts or updates a filesystem check record.
+func (db *DB) SaveFsck(ctx context.Context, fsck *storage.Fsck) error {
+	model := &FsckModel{}
+	model.FromFsck(fsck)
+
+	return db.conn.WithContext(ctx).Save(model).Error
+}
+
+// QueryPoolFscks returns the filesystem check history of a pool, newest first.
+func (db *DB) QueryPoolFscks(ctx context.Context, poolUuid string) ([]storage.Fsck, error) {
+	var models []FsckModel
+	if err := db.conn.WithContext(ctx).
+		Where("poolID = ?", poolUuid).
+		Order("startedAt DESC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	fscks := make([]storage.Fsck, 0, len(models))
+	for _, model := range models {
+		fscks = append(fscks, model.ToFsck())
+	}
+
+	return fscks, nil
+}
+
+// InterruptRunningFscks marks filesystem checks left running by a previous process as interrupted.
+func (db *DB) InterruptRunningFscks(ctx context.Context, endedAt string) error {
+	return db.conn.WithContext(ctx).Model(&FsckModel{}).
+		Where("result = ?", string(storage.FsckRunning)).
+		Updates(map[string]interface{}{
+			"result":  string(storage.FsckInterrupted),
+			"endedAt": endedAt,
+		}).Error
+}
@@ -61,11 +61,11 @@ func Init(path string) (*gorm.DB, error) {
 	return db, nil
 }
 
-// InitSchema creates or updates tables for pools and drives.
+// InitSchema creates or updates tables for pools, drives and jobs.
 func (db *DB) InitSchema(ctx context.Context) error {
 	// Use GORM AutoMigrate to create tables with foreign key constraints
 	// The Pool relationship in DriveModel will ensure the foreign key is created
-	if err := db.conn.WithContext(ctx).AutoMigrate(&PoolModel{}, &DriveModel{}); err != nil {
+	if err := db.conn.WithContext(ctx).AutoMigrate(&PoolModel{}, &DriveModel{}, &JobModel{}, &ScrubModel{}, &VolumeModel{}, &SubvolumeModel{}, &SnapshotModel{}, &FsckModel{}, &SmartModel{}); err != nil {
 		return err
 	}
 
@@ -2,6 +2,9 @@ package DB
 
 import (
 	"context"
+	"errors"
+	"goNAS/helper"
+	"goNAS/jobs"
 	"goNAS/storage"
 	"testing"
 	"time"
@@ -89,6 +92,17 @@ func TestDatabaseOperations(t *testing.T) {
 			t.Errorf("Expected mount point '/mnt/test', got '%s'", pools[poolID].MountPoint)
 		}
 
+		// Test PatchPoolCapacity
+		pool.TotalCapacity = 4096
+		pool.AvailableCapacity = 1024
+		if err := db.PatchPoolCapacity(ctx, pool); err != nil {
+			t.Fatalf("Failed to patch pool capacity: %v", err)
+		}
+		pools, _ = db.QueryAllPools(ctx)
+		if pools[poolID].TotalCapacity != 4096 || pools[poolID].AvailableCapacity != 1024 {
+			t.Errorf("Expected capacity 4096/1024, got %d/%d", pools[poolID].TotalCapacity, pools[poolID].AvailableCapacity)
+		}
+
 		// Test Delete
 		if err := db.DeletePool(ctx, poolID); err != nil {
 			t.Fatalf("Failed to delete pool: %v", err)
@@ -101,6 +115,132 @@ func TestDatabaseOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("Native Pool Types", func(t *testing.T) {
+		// Pools without an md layer store a NULL md device, so several may coexist
+		for _, name := range []string{"BtrfsOne", "BtrfsTwo"} {
+			pool, err := storage.NewPool(name, &storage.Btrfs{Profile: "raid1c3"}, "btrfs")
+			if err != nil {
+				t.Fatalf("Failed to create pool: %v", err)
+			}
+			if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+				t.Fatalf("Failed to insert pool %s: %v", name, err)
+			}
+		}
+
+		pools, err := db.QueryAllPools(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query pools: %v", err)
+		}
+		for id, pool := range pools {
+			if pool.Type.Value() != "btrfs-raid1c3" || pool.MdDevice != "" {
+				t.Errorf("Expected btrfs-raid1c3 without md device, got %s %q", pool.Type.Value(), pool.MdDevice)
+			}
+			db.DeletePool(ctx, id)
+		}
+	})
+
+	t.Run("Encrypted Pool", func(t *testing.T) {
+		pool, err := storage.NewPool("EncryptedPool", &storage.Raid{Level: 1}, "ext4")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		if err = pool.EnableEncryption(helper.LuksKey{Passphrase: "hunter2"}); err != nil {
+			t.Fatalf("Failed to enable encryption: %v", err)
+		}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+		pool.LuksUuid = uuid.New().String()
+		if err = db.PatchPoolEncryption(ctx, pool); err != nil {
+			t.Fatalf("Failed to patch pool encryption: %v", err)
+		}
+
+		pools, err := db.QueryAllPools(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query pools: %v", err)
+		}
+		loaded := pools[pool.Uuid]
+		if !loaded.Encrypted || loaded.LuksUuid != pool.LuksUuid {
+			t.Errorf("Expected encrypted pool with LUKS UUID %s, got %v %q", pool.LuksUuid, loaded.Encrypted, loaded.LuksUuid)
+		}
+		db.DeletePool(ctx, pool.Uuid)
+	})
+
+	t.Run("Partitioned Pool", func(t *testing.T) {
+		pool, err := storage.NewPool("PartitionedPool", &storage.Raid{Level: 1}, "ext4")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		if err = pool.EnablePartitioning(64 * helper.Megabyte); err != nil {
+			t.Fatalf("Failed to enable partitioning: %v", err)
+		}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+		drive := &storage.DriveInfo{
+			DriveKey: storage.DriveKey{Kind: "serial", Value: "PART123"},
+			Uuid:     uuid.New().String(),
+		}
+		if err = db.InsertDrive(ctx, drive, storage.CreationTime()); err != nil {
+			t.Fatalf("Failed to insert drive: %v", err)
+		}
+		partUuid := uuid.New().String()
+		if err = db.PatchDrive(ctx, drive.Uuid, DrivePatch{PoolID: &pool.Uuid, PartUuid: &partUuid}); err != nil {
+			t.Fatalf("Failed to patch drive: %v", err)
+		}
+
+		pools, err := db.QueryAllPools(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query pools: %v", err)
+		}
+		if loaded := pools[pool.Uuid]; !loaded.Partitioned || loaded.PartitionReserve != 64*helper.Megabyte {
+			t.Errorf("Expected partitioned pool with a 64MiB reserve, got %v %d", loaded.Partitioned, loaded.PartitionReserve)
+		}
+		adopted, found, err := db.QueryDriveByKey(ctx, drive.DriveKey)
+		if err != nil || !found {
+			t.Fatalf("Failed to query drive: %v", err)
+		}
+		if adopted.PartUuid != partUuid {
+			t.Errorf("Expected partition UUID %s, got %q", partUuid, adopted.PartUuid)
+		}
+		db.DeletePool(ctx, pool.Uuid)
+	})
+
+	t.Run("Mount Options", func(t *testing.T) {
+		pool, err := storage.NewPool("MountPool", &storage.Raid{Level: 1}, "btrfs")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		pool.MountOptions = []string{"noatime", "compress=zstd"}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+
+		options := []string{"ro"}
+		updated, err := db.PatchPool(ctx, pool, &PoolPatch{MountOptions: &options})
+		if err != nil {
+			t.Fatalf("Failed to patch mount options: %v", err)
+		}
+		if len(updated.MountOptions) != 1 || updated.MountOptions[0] != "ro" {
+			t.Errorf("Expected patched pool to mount ro, got %v", updated.MountOptions)
+		}
+
+		pools, _ := db.QueryAllPools(ctx)
+		if got := pools[pool.Uuid].MountOptions; len(got) != 1 || got[0] != "ro" {
+			t.Errorf("Expected persisted mount options [ro], got %v", got)
+		}
+
+		cleared := []string{}
+		if _, err = db.PatchPool(ctx, pool, &PoolPatch{MountOptions: &cleared}); err != nil {
+			t.Fatalf("Failed to clear mount options: %v", err)
+		}
+		pools, _ = db.QueryAllPools(ctx)
+		if got := pools[pool.Uuid].MountOptions; got != nil {
+			t.Errorf("Expected mount options to be cleared, got %v", got)
+		}
+		db.DeletePool(ctx, pool.Uuid)
+	})
+
 	t.Run("Drive Operations", func(t *testing.T) {
 		// Create a test pool first (needed for foreign key)
 		poolID := uuid.New().String()
@@ -146,6 +286,15 @@ func TestDatabaseOperations(t *testing.T) {
 			t.Errorf("Expected drive UUID '%s', got '%s'", driveID, adoptedDrive.GetUuid())
 		}
 
+		// Test QueryDriveByUuid
+		byUuid, found, err := db.QueryDriveByUuid(ctx, driveID)
+		if err != nil || !found || byUuid.Key() != drive.DriveKey.String() {
+			t.Errorf("Expected drive %s by uuid, got %+v found=%v err=%v", driveID, byUuid, found, err)
+		}
+		if _, found, err = db.QueryDriveByUuid(ctx, "unknown"); err != nil || found {
+			t.Errorf("Expected no drive for an unknown uuid, got found=%v err=%v", found, err)
+		}
+
 		// Test QueryAllAdoptedDrives
 		drives, err := db.QueryAllAdoptedDrives(ctx)
 		if err != nil {
@@ -166,6 +315,28 @@ func TestDatabaseOperations(t *testing.T) {
 		if adoptedDrive.GetPoolID() != poolID {
 			t.Errorf("Expected poolID '%s', got '%s'", poolID, adoptedDrive.GetPoolID())
 		}
+
+		// Test PatchDrive with an empty pool ID releases the drive
+		released := ""
+		if err := db.PatchDrive(ctx, driveID, DrivePatch{PoolID: &released}); err != nil {
+			t.Fatalf("Failed to release drive: %v", err)
+		}
+		adoptedDrive, _, _ = db.QueryDriveByKey(ctx, drive.DriveKey)
+		if adoptedDrive.GetPoolID() != "" {
+			t.Errorf("Expected empty poolID after release, got '%s'", adoptedDrive.GetPoolID())
+		}
+
+		// Test PatchDrive role round trip
+		if adoptedDrive.GetRole() != storage.RoleActive {
+			t.Errorf("Expected default role '%s', got '%s'", storage.RoleActive, adoptedDrive.GetRole())
+		}
+		if err := db.PatchDrive(ctx, driveID, DrivePatch{Role: &storage.RoleSpare}); err != nil {
+			t.Fatalf("Failed to mark drive as spare: %v", err)
+		}
+		adoptedDrive, _, _ = db.QueryDriveByKey(ctx, drive.DriveKey)
+		if !adoptedDrive.IsSpare() {
+			t.Errorf("Expected spare role, got '%s'", adoptedDrive.GetRole())
+		}
 	})
 
 	t.Run("Foreign Key Constraint", func(t *testing.T) {
@@ -214,4 +385,341 @@ func TestDatabaseOperations(t *testing.T) {
 			t.Error("Drive should still exist after pool deletion")
 		}
 	})
+
+	t.Run("Job Operations", func(t *testing.T) {
+		createdAt := time.Now().UTC().Format(time.RFC3339Nano)
+		job := jobs.Job{
+			ID:        uuid.New().String(),
+			Kind:      "build",
+			Target:    uuid.New().String(),
+			State:     jobs.Running,
+			Phase:     "resync",
+			Progress:  12.5,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		if err := db.SaveJob(ctx, job); err != nil {
+			t.Fatalf("Failed to save job: %v", err)
+		}
+
+		job.Progress = 50
+		if err := db.SaveJob(ctx, job); err != nil {
+			t.Fatalf("Failed to update job: %v", err)
+		}
+
+		// Running jobs from a previous process become interrupted
+		if err := db.InterruptRunningJobs(ctx, createdAt); err != nil {
+			t.Fatalf("Failed to interrupt jobs: %v", err)
+		}
+
+		saved, err := db.QueryAllJobs(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query jobs: %v", err)
+		}
+		if len(saved) != 1 {
+			t.Fatalf("Expected 1 job, got %d", len(saved))
+		}
+		if saved[0].State != jobs.Interrupted {
+			t.Errorf("Expected state 'interrupted', got '%s'", saved[0].State)
+		}
+		if saved[0].Progress != 50 {
+			t.Errorf("Expected progress 50, got %v", saved[0].Progress)
+		}
+	})
+
+	t.Run("Fsck Operations", func(t *testing.T) {
+		pool, err := storage.NewPool("FsckTestPool", &storage.Raid{Level: 1}, "ext4")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+
+		first := storage.NewFsck(pool.Uuid, storage.FsckCheck, "e2fsck")
+		first.Finish(storage.FsckReport{ExitCode: 4, Meaning: "errors left uncorrected", ErrorsFound: 3, Uncorrected: true}, nil, false)
+		if err = db.SaveFsck(ctx, first); err != nil {
+			t.Fatalf("Failed to save fsck: %v", err)
+		}
+		second := storage.NewFsck(pool.Uuid, storage.FsckRepair, "e2fsck")
+		if err = db.SaveFsck(ctx, second); err != nil {
+			t.Fatalf("Failed to save fsck: %v", err)
+		}
+		if err = db.InterruptRunningFscks(ctx, storage.CreationTime()); err != nil {
+			t.Fatalf("Failed to interrupt fscks: %v", err)
+		}
+
+		history, err := db.QueryPoolFscks(ctx, pool.Uuid)
+		if err != nil {
+			t.Fatalf("Failed to query fsck history: %v", err)
+		}
+		if len(history) != 2 || history[0].Result != storage.FsckInterrupted {
+			t.Fatalf("Expected the running fsck to be interrupted, got %+v", history)
+		}
+		if history[1].ExitCode != 4 || history[1].ErrorsFound != 3 || history[1].Result != storage.FsckErrors {
+			t.Errorf("Expected the first fsck to keep its parsed results, got %+v", history[1])
+		}
+
+		// Fsck history is removed with its pool
+		if err = db.DeletePool(ctx, pool.Uuid); err != nil {
+			t.Fatalf("Failed to delete pool: %v", err)
+		}
+		if history, _ = db.QueryPoolFscks(ctx, pool.Uuid); len(history) != 0 {
+			t.Errorf("Expected fsck history to be deleted with the pool, got %d", len(history))
+		}
+	})
+
+	t.Run("Scrub Operations", func(t *testing.T) {
+		poolID := uuid.New().String()
+		pool := &storage.Pool{
+			Uuid:          poolID,
+			Name:          "ScrubTestPool",
+			MdDevice:      "/dev/md2",
+			Status:        storage.Healthy,
+			Type:          &storage.Raid{Level: 1},
+			Format:        "ext4",
+			AdoptedDrives: make(map[string]*storage.AdoptedDrive),
+		}
+		if err := db.InsertPool(ctx, pool, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+
+		first := storage.NewScrub(poolID, storage.ScrubCheck)
+		first.Finish(4, nil, false)
+		if err := db.SaveScrub(ctx, first); err != nil {
+			t.Fatalf("Failed to save scrub: %v", err)
+		}
+		second := storage.NewScrub(poolID, storage.ScrubRepair)
+		if err := db.SaveScrub(ctx, second); err != nil {
+			t.Fatalf("Failed to save scrub: %v", err)
+		}
+
+		// Running scrubs from a previous process become interrupted
+		if err := db.InterruptRunningScrubs(ctx, storage.CreationTime()); err != nil {
+			t.Fatalf("Failed to interrupt scrubs: %v", err)
+		}
+
+		last, found, err := db.QueryLastScrub(ctx, poolID)
+		if err != nil || !found {
+			t.Fatalf("Failed to query last scrub: %v", err)
+		}
+		if last.ID != second.ID || last.Result != storage.ScrubInterrupted {
+			t.Errorf("Expected interrupted last scrub %s, got %+v", second.ID, last)
+		}
+
+		history, err := db.QueryPoolScrubs(ctx, poolID)
+		if err != nil {
+			t.Fatalf("Failed to query scrub history: %v", err)
+		}
+		if len(history) != 2 || history[1].Mismatches != 4 {
+			t.Fatalf("Expected 2 scrubs with 4 mismatches on the first, got %+v", history)
+		}
+
+		// Scrub history is removed with its pool
+		if err := db.DeletePool(ctx, poolID); err != nil {
+			t.Fatalf("Failed to delete pool: %v", err)
+		}
+		if history, _ = db.QueryPoolScrubs(ctx, poolID); len(history) != 0 {
+			t.Errorf("Expected scrub history to be deleted with the pool, got %d", len(history))
+		}
+	})
+
+	t.Run("Volume Operations", func(t *testing.T) {
+		pool, err := storage.NewPool("VolumePool", &storage.Raid{Level: 1}, "ext4")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		if err = pool.EnableLvm(); err != nil {
+			t.Fatalf("Failed to enable lvm: %v", err)
+		}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+
+		volume := &storage.Volume{
+			Uuid:       uuid.New().String(),
+			PoolID:     pool.Uuid,
+			Name:       "media",
+			SizeBytes:  1024,
+			Format:     "xfs",
+			MountPoint: "/mnt/pools/" + pool.Uuid + "/media",
+			CreatedAt:  storage.CreationTime(),
+		}
+		if err = db.InsertVolume(ctx, volume); err != nil {
+			t.Fatalf("Failed to insert volume: %v", err)
+		}
+		duplicate := *volume
+		duplicate.Uuid = uuid.New().String()
+		if err = db.InsertVolume(ctx, &duplicate); err == nil {
+			t.Error("Expected duplicate volume name in the same pool to be rejected")
+		}
+
+		volume.SizeBytes = 4096
+		if err = db.PatchVolumeSize(ctx, volume); err != nil {
+			t.Fatalf("Failed to patch volume size: %v", err)
+		}
+
+		pools, _ := db.QueryAllPools(ctx)
+		if pools[pool.Uuid].VolumeGroup != pool.VolumeGroup {
+			t.Errorf("Expected volume group '%s', got '%s'", pool.VolumeGroup, pools[pool.Uuid].VolumeGroup)
+		}
+		volumes, err := db.QueryAllVolumes(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query volumes: %v", err)
+		}
+		if len(volumes) != 1 || volumes[0].SizeBytes != 4096 || volumes[0].Format != "xfs" {
+			t.Fatalf("Expected one 4096 byte xfs volume, got %+v", volumes)
+		}
+
+		// Volumes are removed with their pool
+		if err = db.DeletePool(ctx, pool.Uuid); err != nil {
+			t.Fatalf("Failed to delete pool: %v", err)
+		}
+		if volumes, _ = db.QueryAllVolumes(ctx); len(volumes) != 0 {
+			t.Errorf("Expected volumes to be deleted with the pool, got %d", len(volumes))
+		}
+	})
+
+	t.Run("Subvolume Operations", func(t *testing.T) {
+		pool, err := storage.NewPool("SubvolumePool", &storage.Btrfs{Profile: "raid1"}, "btrfs")
+		if err != nil {
+			t.Fatalf("Failed to create pool: %v", err)
+		}
+		if err = db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert pool: %v", err)
+		}
+
+		subvolume := &storage.Subvolume{
+			Uuid:      uuid.New().String(),
+			PoolID:    pool.Uuid,
+			Name:      "home",
+			Path:      "/mnt/pools/" + pool.Uuid + "/home",
+			Policy:    storage.SnapshotPolicy{Hourly: 24},
+			CreatedAt: storage.CreationTime(),
+		}
+		if err = db.InsertSubvolume(ctx, subvolume); err != nil {
+			t.Fatalf("Failed to insert subvolume: %v", err)
+		}
+		duplicate := *subvolume
+		duplicate.Uuid = uuid.New().String()
+		if err = db.InsertSubvolume(ctx, &duplicate); err == nil {
+			t.Error("Expected duplicate subvolume name in the same pool to be rejected")
+		}
+
+		subvolume.Policy = storage.SnapshotPolicy{Hourly: 24, Daily: 7, Weekly: 4}
+		if err = db.PatchSubvolumePolicy(ctx, subvolume); err != nil {
+			t.Fatalf("Failed to patch subvolume policy: %v", err)
+		}
+		subvolumes, err := db.QueryAllSubvolumes(ctx)
+		if err != nil {
+			t.Fatalf("Failed to query subvolumes: %v", err)
+		}
+		if len(subvolumes) != 1 || subvolumes[0].Policy != subvolume.Policy {
+			t.Fatalf("Expected one subvolume with policy %+v, got %+v", subvolume.Policy, subvolumes)
+		}
+
+		for i, tag := range []string{storage.TagHourly, storage.TagManual} {
+			snapshot := &storage.Snapshot{
+				Uuid:        uuid.New().String(),
+				PoolID:      pool.Uuid,
+				SubvolumeID: subvolume.Uuid,
+				Name:        tag,
+				Path:        "/mnt/pools/" + pool.Uuid + "/.snapshots/home/" + tag,
+				Tag:         tag,
+				CreatedAt:   time.Now().Add(time.Duration(i) * time.Minute).UTC().Format(time.RFC3339Nano),
+			}
+			if err = db.InsertSnapshot(ctx, snapshot); err != nil {
+				t.Fatalf("Failed to insert snapshot: %v", err)
+			}
+		}
+		snapshots, err := db.QuerySnapshots(ctx, pool.Uuid, subvolume.Uuid)
+		if err != nil {
+			t.Fatalf("Failed to query snapshots: %v", err)
+		}
+		if len(snapshots) != 2 || snapshots[0].Tag != storage.TagManual {
+			t.Fatalf("Expected two snapshots newest first, got %+v", snapshots)
+		}
+		if _, err = db.QuerySnapshot(ctx, pool.Uuid, "missing"); !errors.Is(err, storage.ErrSnapshotNotFound) {
+			t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+		}
+		if err = db.DeleteSnapshot(ctx, snapshots[0].Uuid); err != nil {
+			t.Fatalf("Failed to delete snapshot: %v", err)
+		}
+
+		// Subvolumes and their snapshots are removed with their pool
+		if err = db.DeletePool(ctx, pool.Uuid); err != nil {
+			t.Fatalf("Failed to delete pool: %v", err)
+		}
+		if subvolumes, _ = db.QueryAllSubvolumes(ctx); len(subvolumes) != 0 {
+			t.Errorf("Expected subvolumes to be deleted with the pool, got %d", len(subvolumes))
+		}
+		if snapshots, _ = db.QuerySnapshots(ctx, pool.Uuid, ""); len(snapshots) != 0 {
+			t.Errorf("Expected snapshots to be deleted with the pool, got %d", len(snapshots))
+		}
+	})
+
+	t.Run("Delete Drive", func(t *testing.T) {
+		drive := &storage.DriveInfo{
+			DriveKey: storage.DriveKey{Kind: "serial", Value: "UNADOPT1"},
+			Uuid:     uuid.New().String(),
+		}
+		if err := db.InsertDrive(ctx, drive, storage.CreationTime()); err != nil {
+			t.Fatalf("Failed to insert drive: %v", err)
+		}
+		reading := storage.SmartReport{ID: uuid.New().String(), DriveID: drive.Uuid, CheckedAt: storage.CreationTime(), Health: storage.DriveHealthOK}
+		if err := db.InsertSmart(ctx, &reading); err != nil {
+			t.Fatalf("Failed to insert smart reading: %v", err)
+		}
+
+		if err := db.DeleteDrive(ctx, drive.Uuid); err != nil {
+			t.Fatalf("Failed to delete drive: %v", err)
+		}
+		if _, found, _ := db.QueryDriveByKey(ctx, drive.DriveKey); found {
+			t.Errorf("Expected the drive record to be deleted")
+		}
+		if history, _ := db.QueryDriveSmart(ctx, drive.Uuid, 0); len(history) != 0 {
+			t.Errorf("Expected SMART history to be deleted with the drive, got %d", len(history))
+		}
+		if err := db.DeleteDrive(ctx, drive.Uuid); !errors.Is(err, storage.ErrDriveNotFound) {
+			t.Errorf("Expected ErrDriveNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Smart History", func(t *testing.T) {
+		drive := &storage.DriveInfo{
+			DriveKey: storage.DriveKey{Kind: "serial", Value: "SMART123"},
+			Uuid:     uuid.New().String(),
+		}
+		if err := db.InsertDrive(ctx, drive, storage.CreationTime()); err != nil {
+			t.Fatalf("Failed to insert drive: %v", err)
+		}
+
+		readings := []storage.SmartReport{
+			{ID: uuid.New().String(), DriveID: drive.Uuid, CheckedAt: "2026-01-01T00:00:00Z", Protocol: "ATA", Passed: true, Health: storage.DriveHealthOK, Temperature: 33},
+			{ID: uuid.New().String(), DriveID: drive.Uuid, CheckedAt: "2026-02-01T00:00:00Z", Protocol: "ATA", Passed: true, Health: storage.DriveHealthWarning, PendingSectors: 3},
+		}
+		for i := range readings {
+			if err := db.InsertSmart(ctx, &readings[i]); err != nil {
+				t.Fatalf("Failed to insert smart reading: %v", err)
+			}
+		}
+
+		history, err := db.QueryDriveSmart(ctx, drive.Uuid, 0)
+		if err != nil {
+			t.Fatalf("Failed to query smart history: %v", err)
+		}
+		if len(history) != 2 || history[0] != readings[1] {
+			t.Fatalf("Expected two readings newest first, got %+v", history)
+		}
+		if latest, _ := db.QueryDriveSmart(ctx, drive.Uuid, 1); len(latest) != 1 || latest[0].Health != storage.DriveHealthWarning {
+			t.Errorf("Expected only the latest reading, got %+v", latest)
+		}
+
+		if err = db.PruneSmart(ctx, "2026-01-15T00:00:00Z"); err != nil {
+			t.Fatalf("Failed to prune smart history: %v", err)
+		}
+		if history, _ = db.QueryDriveSmart(ctx, drive.Uuid, 0); len(history) != 1 || history[0].ID != readings[1].ID {
+			t.Errorf("Expected only the newer reading to remain, got %+v", history)
+		}
+	})
 }
@@ -0,0 +1,40 @@
+package DB
+
+import (
+	"context"
+	"goNAS/jobs"
+)
+
+// SaveJob inserts or updates a job record.
+func (db *DB) SaveJob(ctx context.Context, job jobs.Job) error {
+	model := &JobModel{}
+	model.FromJob(job)
+
+	return db.conn.WithContext(ctx).Save(model).Error
+}
+
+// QueryAllJobs returns all jobs ordered by creation time.
+func (db *DB) QueryAllJobs(ctx context.Context) ([]jobs.Job, error) {
+	var models []JobModel
+	if err := db.conn.WithContext(ctx).Order("createdAt DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]jobs.Job, 0, len(models))
+	for _, model := range models {
+		result = append(result, model.ToJob())
+	}
+
+	return result, nil
+}
+
+// InterruptRunningJobs marks jobs left running by a previous process as interrupted.
+func (db *DB) InterruptRunningJobs(ctx context.Context, updatedAt string) error {
+	return db.conn.WithContext(ctx).Model(&JobModel{}).
+		Where("state = ?", string(jobs.Running)).
+		Updates(map[string]interface{}{
+			"state":     string(jobs.Interrupted),
+			"error":     "interrupted by server restart",
+			"updatedAt": updatedAt,
+		}).Error
+}
@@ -1,7 +1,9 @@
 package DB
 
 import (
+	"goNAS/jobs"
 	"goNAS/storage"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,14 +11,24 @@ import (
 
 // PoolModel represents the Pool table in GORM
 type PoolModel struct {
-	UUID       string `gorm:"primaryKey;column:uuid"`
-	Name       string `gorm:"unique;not null;column:name"`
-	MountPoint string `gorm:"column:mountPoint"`
-	MdDevice   string `gorm:"unique;column:mdDevice"`
-	Status     string `gorm:"not null;column:status"`
-	PoolType   string `gorm:"not null;column:poolType"`
-	Format     string `gorm:"column:format"`
-	CreatedAt  string `gorm:"not null;column:createdAt"`
+	UUID              string  `gorm:"primaryKey;column:uuid"`
+	Name              string  `gorm:"unique;not null;column:name"`
+	MountPoint        string  `gorm:"column:mountPoint"`
+	MdDevice          *string `gorm:"unique;column:mdDevice"` // NULL for pool types without an md layer
+	Status            string  `gorm:"not null;column:status"`
+	StatusReason      string  `gorm:"column:statusReason"`
+	PoolType          string  `gorm:"not null;column:poolType"`
+	Format            string  `gorm:"column:format"`
+	MountOptions      string  `gorm:"column:mountOptions"` // comma separated
+	TotalCapacity     uint64  `gorm:"column:totalCapacity"`
+	AvailableCapacity uint64  `gorm:"column:availableCapacity"`
+	ScrubSchedule     string  `gorm:"column:scrubSchedule"`
+	VolumeGroup       string  `gorm:"column:volumeGroup"`
+	Encrypted         bool    `gorm:"not null;default:false;column:encrypted"`
+	LuksUuid          string  `gorm:"column:luksUuid"` // LUKS header UUID only; keys are never stored
+	Partitioned       bool    `gorm:"not null;default:false;column:partitioned"`
+	PartitionReserve  uint64  `gorm:"column:partitionReserve"`
+	CreatedAt         string  `gorm:"not null;column:createdAt"`
 }
 
 // TableName sets the table name for GORM
@@ -31,16 +43,33 @@ func (p *PoolModel) ToStoragePool() (storage.Pool, error) {
 		return storage.Pool{}, err
 	}
 
+	mdDevice := ""
+	if p.MdDevice != nil {
+		mdDevice = *p.MdDevice
+	}
+
 	return storage.Pool{
-		Uuid:          p.UUID,
-		Name:          p.Name,
-		MdDevice:      p.MdDevice,
-		AdoptedDrives: make(map[string]*storage.AdoptedDrive),
-		Status:        storage.Status(p.Status),
-		Type:          convertedType,
-		MountPoint:    p.MountPoint,
-		Format:        p.Format,
-		CreatedAt:     p.CreatedAt,
+		Uuid:              p.UUID,
+		Name:              p.Name,
+		MdDevice:          mdDevice,
+		AdoptedDrives:     make(map[string]*storage.AdoptedDrive),
+		Status:            storage.Status(p.Status),
+		StatusReason:      p.StatusReason,
+		Type:              convertedType,
+		MountPoint:        p.MountPoint,
+		Format:            p.Format,
+		MountOptions:      splitMountOptions(p.MountOptions),
+		TotalCapacity:     p.TotalCapacity,
+		AvailableCapacity: p.AvailableCapacity,
+		ScrubSchedule:     p.ScrubSchedule,
+		VolumeGroup:       p.VolumeGroup,
+		Encrypted:         p.Encrypted,
+		LuksUuid:          p.LuksUuid,
+		Partitioned:       p.Partitioned,
+		PartitionReserve:  p.PartitionReserve,
+		Volumes:           make(map[string]*storage.Volume),
+		Subvolumes:        make(map[string]*storage.Subvolume),
+		CreatedAt:         p.CreatedAt,
 	}, nil
 }
 
@@ -48,20 +77,44 @@ func (p *PoolModel) ToStoragePool() (storage.Pool, error) {
 func (p *PoolModel) FromStoragePool(pool *storage.Pool) {
 	p.UUID = pool.Uuid
 	p.Name = pool.Name
-	p.MdDevice = pool.MdDevice
+	p.MdDevice = nil
+	if pool.MdDevice != "" {
+		mdDevice := pool.MdDevice
+		p.MdDevice = &mdDevice
+	}
 	p.Status = string(pool.Status.ToLower())
+	p.StatusReason = pool.StatusReason
 	p.PoolType = pool.Type.Value()
 	p.Format = pool.Format
+	p.MountOptions = strings.Join(pool.MountOptions, ",")
+	p.TotalCapacity = pool.TotalCapacity
+	p.AvailableCapacity = pool.AvailableCapacity
+	p.ScrubSchedule = pool.ScrubSchedule
+	p.VolumeGroup = pool.VolumeGroup
+	p.Encrypted = pool.Encrypted
+	p.LuksUuid = pool.LuksUuid
+	p.Partitioned = pool.Partitioned
+	p.PartitionReserve = pool.PartitionReserve
 	p.CreatedAt = pool.CreatedAt
 	p.MountPoint = pool.MountPoint
 }
 
+// splitMountOptions parses a comma separated mountOptions column.
+func splitMountOptions(options string) []string {
+	if options == "" {
+		return nil
+	}
+	return strings.Split(options, ",")
+}
+
 // DriveModel represents the Drive table in GORM
 type DriveModel struct {
 	Kind      string     `gorm:"primaryKey;not null;column:kind"`
 	Value     string     `gorm:"primaryKey;not null;column:value"`
 	UUID      string     `gorm:"unique;not null;column:uuid"`
 	PoolID    *string    `gorm:"column:poolID"` // Pointer handles NULL (nil = NULL in DB)
+	Role      string     `gorm:"not null;default:active;column:role"`
+	PartUuid  string     `gorm:"column:partUuid"` // GPT partition used as the md member, if any
 	CreatedAt string     `gorm:"not null;column:createdAt"`
 	Pool      *PoolModel `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:SET NULL;"`
 }
@@ -83,8 +136,13 @@ func (d *DriveModel) ToAdoptedDrive() storage.AdoptedDrive {
 
 	adoptedDrive := storage.AdoptedDrive{
 		Drive:     drive,
+		Role:      storage.RoleActive,
+		PartUuid:  d.PartUuid,
 		CreatedAt: d.CreatedAt,
 	}
+	if d.Role != "" {
+		adoptedDrive.SetRole(storage.DriveRole(d.Role))
+	}
 
 	adoptedDrive.SetUuid(d.UUID)
 	if d.PoolID != nil {
@@ -102,6 +160,281 @@ func (d *DriveModel) FromDriveInfo(drive *storage.DriveInfo, createdAt string) {
 	d.CreatedAt = createdAt
 }
 
+// JobModel represents the Job table in GORM
+type JobModel struct {
+	ID        string  `gorm:"primaryKey;column:id"`
+	Kind      string  `gorm:"not null;column:kind"`
+	Target    string  `gorm:"column:target"`
+	State     string  `gorm:"not null;column:state"`
+	Phase     string  `gorm:"column:phase"`
+	Progress  float64 `gorm:"column:progress"`
+	Error     string  `gorm:"column:error"`
+	CreatedAt string  `gorm:"not null;column:createdAt"`
+	UpdatedAt string  `gorm:"column:updatedAt"`
+}
+
+// TableName sets the table name for GORM
+func (JobModel) TableName() string {
+	return "Job"
+}
+
+// ToJob converts GORM model to jobs.Job
+func (j *JobModel) ToJob() jobs.Job {
+	return jobs.Job{
+		ID:        j.ID,
+		Kind:      j.Kind,
+		Target:    j.Target,
+		State:     jobs.State(j.State),
+		Phase:     j.Phase,
+		Progress:  j.Progress,
+		Error:     j.Error,
+		CreatedAt: j.CreatedAt,
+		UpdatedAt: j.UpdatedAt,
+	}
+}
+
+// FromJob converts jobs.Job to GORM model
+func (j *JobModel) FromJob(job jobs.Job) {
+	j.ID = job.ID
+	j.Kind = job.Kind
+	j.Target = job.Target
+	j.State = string(job.State)
+	j.Phase = job.Phase
+	j.Progress = job.Progress
+	j.Error = job.Error
+	j.CreatedAt = job.CreatedAt
+	j.UpdatedAt = job.UpdatedAt
+}
+
+// ScrubModel represents the Scrub table in GORM
+type ScrubModel struct {
+	ID         string     `gorm:"primaryKey;column:id"`
+	PoolID     string     `gorm:"not null;index;column:poolID"`
+	Action     string     `gorm:"not null;column:action"`
+	StartedAt  string     `gorm:"not null;column:startedAt"`
+	EndedAt    string     `gorm:"column:endedAt"`
+	Mismatches uint64     `gorm:"column:mismatches"`
+	Result     string     `gorm:"not null;column:result"`
+	Error      string     `gorm:"column:error"`
+	Pool       *PoolModel `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (ScrubModel) TableName() string {
+	return "Scrub"
+}
+
+// ToScrub converts GORM model to storage.Scrub
+func (s *ScrubModel) ToScrub() storage.Scrub {
+	return storage.Scrub{
+		ID:         s.ID,
+		PoolID:     s.PoolID,
+		Action:     storage.ScrubAction(s.Action),
+		StartedAt:  s.StartedAt,
+		EndedAt:    s.EndedAt,
+		Mismatches: s.Mismatches,
+		Result:     storage.ScrubResult(s.Result),
+		Error:      s.Error,
+	}
+}
+
+// FromScrub converts storage.Scrub to GORM model
+func (s *ScrubModel) FromScrub(scrub *storage.Scrub) {
+	s.ID = scrub.ID
+	s.PoolID = scrub.PoolID
+	s.Action = string(scrub.Action)
+	s.StartedAt = scrub.StartedAt
+	s.EndedAt = scrub.EndedAt
+	s.Mismatches = scrub.Mismatches
+	s.Result = string(scrub.Result)
+	s.Error = scrub.Error
+}
+
+// FsckModel represents the Fsck table in GORM
+type FsckModel struct {
+	ID          string     `gorm:"primaryKey;column:id"`
+	PoolID      string     `gorm:"not null;index;column:poolID"`
+	Mode        string     `gorm:"not null;column:mode"`
+	Tool        string     `gorm:"not null;column:tool"`
+	StartedAt   string     `gorm:"not null;column:startedAt"`
+	EndedAt     string     `gorm:"column:endedAt"`
+	ExitCode    int        `gorm:"column:exitCode"`
+	Meaning     string     `gorm:"column:meaning"`
+	ErrorsFound int        `gorm:"column:errorsFound"`
+	ErrorsFixed int        `gorm:"column:errorsFixed"`
+	Result      string     `gorm:"not null;column:result"`
+	Error       string     `gorm:"column:error"`
+	Pool        *PoolModel `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (FsckModel) TableName() string {
+	return "Fsck"
+}
+
+// ToFsck converts GORM model to storage.Fsck
+func (f *FsckModel) ToFsck() storage.Fsck {
+	return storage.Fsck{
+		ID:          f.ID,
+		PoolID:      f.PoolID,
+		Mode:        storage.FsckMode(f.Mode),
+		Tool:        f.Tool,
+		StartedAt:   f.StartedAt,
+		EndedAt:     f.EndedAt,
+		ExitCode:    f.ExitCode,
+		Meaning:     f.Meaning,
+		ErrorsFound: f.ErrorsFound,
+		ErrorsFixed: f.ErrorsFixed,
+		Result:      storage.FsckResult(f.Result),
+		Error:       f.Error,
+	}
+}
+
+// FromFsck converts storage.Fsck to GORM model
+func (f *FsckModel) FromFsck(fsck *storage.Fsck) {
+	f.ID = fsck.ID
+	f.PoolID = fsck.PoolID
+	f.Mode = string(fsck.Mode)
+	f.Tool = fsck.Tool
+	f.StartedAt = fsck.StartedAt
+	f.EndedAt = fsck.EndedAt
+	f.ExitCode = fsck.ExitCode
+	f.Meaning = fsck.Meaning
+	f.ErrorsFound = fsck.ErrorsFound
+	f.ErrorsFixed = fsck.ErrorsFixed
+	f.Result = string(fsck.Result)
+	f.Error = fsck.Error
+}
+
+// VolumeModel represents the Volume table in GORM
+type VolumeModel struct {
+	UUID       string     `gorm:"primaryKey;column:uuid"`
+	PoolID     string     `gorm:"not null;uniqueIndex:idx_volume_pool_name;column:poolID"`
+	Name       string     `gorm:"not null;uniqueIndex:idx_volume_pool_name;column:name"`
+	SizeBytes  uint64     `gorm:"not null;column:sizeBytes"`
+	Format     string     `gorm:"not null;column:format"`
+	MountPoint string     `gorm:"column:mountPoint"`
+	CreatedAt  string     `gorm:"not null;column:createdAt"`
+	Pool       *PoolModel `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (VolumeModel) TableName() string {
+	return "Volume"
+}
+
+// ToVolume converts GORM model to storage.Volume
+func (v *VolumeModel) ToVolume() storage.Volume {
+	return storage.Volume{
+		Uuid:       v.UUID,
+		PoolID:     v.PoolID,
+		Name:       v.Name,
+		SizeBytes:  v.SizeBytes,
+		Format:     v.Format,
+		MountPoint: v.MountPoint,
+		CreatedAt:  v.CreatedAt,
+	}
+}
+
+// FromVolume converts storage.Volume to GORM model
+func (v *VolumeModel) FromVolume(volume *storage.Volume) {
+	v.UUID = volume.Uuid
+	v.PoolID = volume.PoolID
+	v.Name = volume.Name
+	v.SizeBytes = volume.SizeBytes
+	v.Format = volume.Format
+	v.MountPoint = volume.MountPoint
+	v.CreatedAt = volume.CreatedAt
+}
+
+// SubvolumeModel represents the Subvolume table in GORM
+type SubvolumeModel struct {
+	UUID      string     `gorm:"primaryKey;column:uuid"`
+	PoolID    string     `gorm:"not null;uniqueIndex:idx_subvolume_pool_name;column:poolID"`
+	Name      string     `gorm:"not null;uniqueIndex:idx_subvolume_pool_name;column:name"`
+	Path      string     `gorm:"not null;column:path"`
+	Hourly    int        `gorm:"column:hourly"`
+	Daily     int        `gorm:"column:daily"`
+	Weekly    int        `gorm:"column:weekly"`
+	CreatedAt string     `gorm:"not null;column:createdAt"`
+	Pool      *PoolModel `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (SubvolumeModel) TableName() string {
+	return "Subvolume"
+}
+
+// ToSubvolume converts GORM model to storage.Subvolume
+func (s *SubvolumeModel) ToSubvolume() storage.Subvolume {
+	return storage.Subvolume{
+		Uuid:   s.UUID,
+		PoolID: s.PoolID,
+		Name:   s.Name,
+		Path:   s.Path,
+		Policy: storage.SnapshotPolicy{
+			Hourly: s.Hourly,
+			Daily:  s.Daily,
+			Weekly: s.Weekly,
+		},
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// FromSubvolume converts storage.Subvolume to GORM model
+func (s *SubvolumeModel) FromSubvolume(subvolume *storage.Subvolume) {
+	s.UUID = subvolume.Uuid
+	s.PoolID = subvolume.PoolID
+	s.Name = subvolume.Name
+	s.Path = subvolume.Path
+	s.Hourly = subvolume.Policy.Hourly
+	s.Daily = subvolume.Policy.Daily
+	s.Weekly = subvolume.Policy.Weekly
+	s.CreatedAt = subvolume.CreatedAt
+}
+
+// SnapshotModel represents the Snapshot table in GORM
+type SnapshotModel struct {
+	UUID        string          `gorm:"primaryKey;column:uuid"`
+	PoolID      string          `gorm:"not null;index;column:poolID"`
+	SubvolumeID string          `gorm:"not null;index;column:subvolumeID"`
+	Name        string          `gorm:"not null;column:name"`
+	Path        string          `gorm:"not null;column:path"`
+	Tag         string          `gorm:"not null;column:tag"`
+	CreatedAt   string          `gorm:"not null;column:createdAt"`
+	Pool        *PoolModel      `gorm:"foreignKey:PoolID;references:UUID;constraint:OnDelete:CASCADE;"`
+	Subvolume   *SubvolumeModel `gorm:"foreignKey:SubvolumeID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (SnapshotModel) TableName() string {
+	return "Snapshot"
+}
+
+// ToSnapshot converts GORM model to storage.Snapshot
+func (s *SnapshotModel) ToSnapshot() storage.Snapshot {
+	return storage.Snapshot{
+		Uuid:        s.UUID,
+		PoolID:      s.PoolID,
+		SubvolumeID: s.SubvolumeID,
+		Name:        s.Name,
+		Path:        s.Path,
+		Tag:         s.Tag,
+		CreatedAt:   s.CreatedAt,
+	}
+}
+
+// FromSnapshot converts storage.Snapshot to GORM model
+func (s *SnapshotModel) FromSnapshot(snapshot *storage.Snapshot) {
+	s.UUID = snapshot.Uuid
+	s.PoolID = snapshot.PoolID
+	s.SubvolumeID = snapshot.SubvolumeID
+	s.Name = snapshot.Name
+	s.Path = snapshot.Path
+	s.Tag = snapshot.Tag
+	s.CreatedAt = snapshot.CreatedAt
+}
+
 // BeforeCreate hook to set default timestamp if not provided
 func (p *PoolModel) BeforeCreate(tx *gorm.DB) error {
 	if p.CreatedAt == "" {
@@ -117,3 +450,62 @@ func (d *DriveModel) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// SmartModel represents the Smart table in GORM
+type SmartModel struct {
+	ID                 string      `gorm:"primaryKey;column:id"`
+	DriveID            string      `gorm:"not null;index;column:driveID"`
+	CheckedAt          string      `gorm:"not null;index;column:checkedAt"`
+	Protocol           string      `gorm:"column:protocol"`
+	Passed             bool        `gorm:"not null;column:passed"`
+	Health             string      `gorm:"not null;column:health"`
+	ReallocatedSectors uint64      `gorm:"column:reallocatedSectors"`
+	PendingSectors     uint64      `gorm:"column:pendingSectors"`
+	MediaErrors        uint64      `gorm:"column:mediaErrors"`
+	Temperature        int         `gorm:"column:temperature"`
+	PowerOnHours       uint64      `gorm:"column:powerOnHours"`
+	PercentageUsed     int         `gorm:"column:percentageUsed"`
+	CriticalWarning    int         `gorm:"column:criticalWarning"`
+	Drive              *DriveModel `gorm:"foreignKey:DriveID;references:UUID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName sets the table name for GORM
+func (SmartModel) TableName() string {
+	return "Smart"
+}
+
+// ToSmartReport converts GORM model to storage.SmartReport
+func (s *SmartModel) ToSmartReport() storage.SmartReport {
+	return storage.SmartReport{
+		ID:                 s.ID,
+		DriveID:            s.DriveID,
+		CheckedAt:          s.CheckedAt,
+		Protocol:           s.Protocol,
+		Passed:             s.Passed,
+		Health:             storage.DriveHealth(s.Health),
+		ReallocatedSectors: s.ReallocatedSectors,
+		PendingSectors:     s.PendingSectors,
+		MediaErrors:        s.MediaErrors,
+		Temperature:        s.Temperature,
+		PowerOnHours:       s.PowerOnHours,
+		PercentageUsed:     s.PercentageUsed,
+		CriticalWarning:    s.CriticalWarning,
+	}
+}
+
+// FromSmartReport converts storage.SmartReport to GORM model
+func (s *SmartModel) FromSmartReport(report *storage.SmartReport) {
+	s.ID = report.ID
+	s.DriveID = report.DriveID
+	s.CheckedAt = report.CheckedAt
+	s.Protocol = report.Protocol
+	s.Passed = report.Passed
+	s.Health = string(report.Health)
+	s.ReallocatedSectors = report.ReallocatedSectors
+	s.PendingSectors = report.PendingSectors
+	s.MediaErrors = report.MediaErrors
+	s.Temperature = report.Temperature
+	s.PowerOnHours = report.PowerOnHours
+	s.PercentageUsed = report.PercentageUsed
+	s.CriticalWarning = report.CriticalWarning
+}
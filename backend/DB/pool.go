@@ -3,6 +3,7 @@ package DB
 import (
 	"context"
 	"goNAS/storage"
+	"strings"
 )
 
 // InsertPool persists a new pool record.
@@ -23,6 +24,10 @@ type PoolPatch struct {
 	Name   string         `json:"name"`
 	Status storage.Status `json:"status"`
 	Format string         `json:"format"`
+	// ScrubSchedule is a duration such as "168h" or "off".
+	ScrubSchedule string `json:"scrubSchedule"`
+	// MountOptions replaces the pool mount options; an empty list clears them.
+	MountOptions *[]string `json:"mountOptions"`
 }
 
 // applyPoolPatch returns a modified copy of the pool based on the patch.
@@ -45,6 +50,14 @@ func applyPoolPatch(pool *storage.Pool, patch *PoolPatch) (updatedPool *storage.
 		updatedPool.SetFormat(patch.Format)
 	}
 
+	if patch.ScrubSchedule != "" {
+		updatedPool.ScrubSchedule = patch.ScrubSchedule
+	}
+
+	if patch.MountOptions != nil {
+		updatedPool.MountOptions = *patch.MountOptions
+	}
+
 	return
 }
 
@@ -55,6 +68,43 @@ func (db *DB) PatchPoolMount(uuid, mount string) error {
 		Update("mountPoint", mount).Error
 }
 
+// PatchPoolStatus updates the status and status reason for a pool record.
+func (db *DB) PatchPoolStatus(ctx context.Context, pool *storage.Pool) error {
+	return db.conn.WithContext(ctx).Model(&PoolModel{}).
+		Where("uuid = ?", pool.Uuid).
+		Updates(map[string]interface{}{
+			"status":       pool.Status.ToLower(),
+			"statusReason": pool.StatusReason,
+		}).Error
+}
+
+// PatchPoolEncryption updates whether a pool record is encrypted and its LUKS header UUID.
+func (db *DB) PatchPoolEncryption(ctx context.Context, pool *storage.Pool) error {
+	return db.conn.WithContext(ctx).Model(&PoolModel{}).
+		Where("uuid = ?", pool.Uuid).
+		Updates(map[string]interface{}{
+			"encrypted": pool.Encrypted,
+			"luksUuid":  pool.LuksUuid,
+		}).Error
+}
+
+// PatchPoolCapacity updates the recorded capacity for a pool record.
+func (db *DB) PatchPoolCapacity(ctx context.Context, pool *storage.Pool) error {
+	return db.conn.WithContext(ctx).Model(&PoolModel{}).
+		Where("uuid = ?", pool.Uuid).
+		Updates(map[string]interface{}{
+			"totalCapacity":     pool.TotalCapacity,
+			"availableCapacity": pool.AvailableCapacity,
+		}).Error
+}
+
+// PatchPoolType updates the pool type for a pool record.
+func (db *DB) PatchPoolType(ctx context.Context, pool *storage.Pool) error {
+	return db.conn.WithContext(ctx).Model(&PoolModel{}).
+		Where("uuid = ?", pool.Uuid).
+		Update("poolType", pool.Type.Value()).Error
+}
+
 // PatchPool applies a patch to a pool and persists changes.
 func (db *DB) PatchPool(ctx context.Context, pool *storage.Pool, patch *PoolPatch) (*storage.Pool, error) {
 	updatedPool := applyPoolPatch(pool, patch)
@@ -70,6 +120,12 @@ func (db *DB) PatchPool(ctx context.Context, pool *storage.Pool, patch *PoolPatc
 	if patch.Format != "" {
 		updates["format"] = patch.Format
 	}
+	if patch.ScrubSchedule != "" {
+		updates["scrubSchedule"] = patch.ScrubSchedule
+	}
+	if patch.MountOptions != nil {
+		updates["mountOptions"] = strings.Join(*patch.MountOptions, ",")
+	}
 
 	if len(updates) == 0 {
 		return pool, nil
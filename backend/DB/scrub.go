@@ -0,0 +1,62 @@
+package DB
+
+import (
+	"context"
+	"goNAS/storage"
+
+	"gorm.io/gorm"
+)
+
+// SaveScrub inserts or updates a scrub record.
+func (db *DB) SaveScrub(ctx context.Context, scrub *storage.Scrub) error {
+	model := &ScrubModel{}
+	model.FromScrub(scrub)
+
+	return db.conn.WithContext(ctx).Save(model).Error
+}
+
+// QueryPoolScrubs returns the scrub history of a pool, newest first.
+func (db *DB) QueryPoolScrubs(ctx context.Context, poolUuid string) ([]storage.Scrub, error) {
+	var models []ScrubModel
+	if err := db.conn.WithContext(ctx).
+		Where("poolID = ?", poolUuid).
+		Order("startedAt DESC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	scrubs := make([]storage.Scrub, 0, len(models))
+	for _, model := range models {
+		scrubs = append(scrubs, model.ToScrub())
+	}
+
+	return scrubs, nil
+}
+
+// QueryLastScrub returns the most recent scrub of a pool, if any.
+func (db *DB) QueryLastScrub(ctx context.Context, poolUuid string) (storage.Scrub, bool, error) {
+	var model ScrubModel
+	err := db.conn.WithContext(ctx).
+		Where("poolID = ?", poolUuid).
+		Order("startedAt DESC").
+		First(&model).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return storage.Scrub{}, false, nil
+	}
+	if err != nil {
+		return storage.Scrub{}, false, err
+	}
+
+	return model.ToScrub(), true, nil
+}
+
+// InterruptRunningScrubs marks scrubs left running by a previous process as interrupted.
+func (db *DB) InterruptRunningScrubs(ctx context.Context, endedAt string) error {
+	return db.conn.WithContext(ctx).Model(&ScrubModel{}).
+		Where("result = ?", string(storage.ScrubRunning)).
+		Updates(map[string]interface{}{
+			"result":  string(storage.ScrubInterrupted),
+			"endedAt": endedAt,
+		}).Error
+}
@@ -0,0 +1,43 @@
+package DB
+
+import (
+	"context"
+	"goNAS/storage"
+)
+
+// InsertSmart records a SMART reading of an adopted drive.
+func (db *DB) InsertSmart(ctx context.Context, report *storage.SmartReport) error {
+	model := &SmartModel{}
+	model.FromSmartReport(report)
+
+	return db.conn.WithContext(ctx).Create(model).Error
+}
+
+// QueryDriveSmart returns up to limit SMART readings of a drive, newest first.
+// A limit of zero returns the whole history.
+func (db *DB) QueryDriveSmart(ctx context.Context, driveUuid string, limit int) ([]storage.SmartReport, error) {
+	var models []SmartModel
+	query := db.conn.WithContext(ctx).
+		Where("driveID = ?", driveUuid).
+		Order("checkedAt DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	reports := make([]storage.SmartReport, 0, len(models))
+	for _, model := range models {
+		reports = append(reports, model.ToSmartReport())
+	}
+
+	return reports, nil
+}
+
+// PruneSmart deletes SMART readings taken before the given time.
+func (db *DB) PruneSmart(ctx context.Context, before string) error {
+	return db.conn.WithContext(ctx).
+		Where("checkedAt < ?", before).
+		Delete(&SmartModel{}).Error
+}
@@ -0,0 +1,101 @@
+package DB
+
+import (
+	"context"
+	"goNAS/storage"
+)
+
+// InsertSubvolume persists a new subvolume record.
+func (db *DB) InsertSubvolume(ctx context.Context, subvolume *storage.Subvolume) error {
+	model := &SubvolumeModel{}
+	model.FromSubvolume(subvolume)
+
+	return db.conn.WithContext(ctx).Create(model).Error
+}
+
+// PatchSubvolumePolicy updates the snapshot retention policy of a subvolume.
+func (db *DB) PatchSubvolumePolicy(ctx context.Context, subvolume *storage.Subvolume) error {
+	result := db.conn.WithContext(ctx).Model(&SubvolumeModel{}).
+		Where("uuid = ?", subvolume.Uuid).
+		Updates(map[string]interface{}{
+			"hourly": subvolume.Policy.Hourly,
+			"daily":  subvolume.Policy.Daily,
+			"weekly": subvolume.Policy.Weekly,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return storage.ErrSubvolumeNotFound
+	}
+	return nil
+}
+
+// DeleteSubvolume removes a subvolume record and, through the foreign key, its snapshots.
+func (db *DB) DeleteSubvolume(ctx context.Context, uuid string) error {
+	return db.conn.WithContext(ctx).Delete(&SubvolumeModel{}, "uuid = ?", uuid).Error
+}
+
+// QueryAllSubvolumes returns all subvolumes ordered by creation time.
+func (db *DB) QueryAllSubvolumes(ctx context.Context) ([]storage.Subvolume, error) {
+	var models []SubvolumeModel
+	if err := db.conn.WithContext(ctx).Order("createdAt ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	subvolumes := make([]storage.Subvolume, 0, len(models))
+	for _, model := range models {
+		subvolumes = append(subvolumes, model.ToSubvolume())
+	}
+
+	return subvolumes, nil
+}
+
+// InsertSnapshot persists a new snapshot record.
+func (db *DB) InsertSnapshot(ctx context.Context, snapshot *storage.Snapshot) error {
+	model := &SnapshotModel{}
+	model.FromSnapshot(snapshot)
+
+	return db.conn.WithContext(ctx).Create(model).Error
+}
+
+// DeleteSnapshot removes a snapshot record by UUID.
+func (db *DB) DeleteSnapshot(ctx context.Context, uuid string) error {
+	return db.conn.WithContext(ctx).Delete(&SnapshotModel{}, "uuid = ?", uuid).Error
+}
+
+// QuerySnapshot finds a snapshot of a pool by UUID.
+func (db *DB) QuerySnapshot(ctx context.Context, poolUuid string, uuid string) (storage.Snapshot, error) {
+	var models []SnapshotModel
+	if err := db.conn.WithContext(ctx).
+		Where("poolID = ? AND uuid = ?", poolUuid, uuid).
+		Limit(1).
+		Find(&models).Error; err != nil {
+		return storage.Snapshot{}, err
+	}
+	if len(models) == 0 {
+		return storage.Snapshot{}, storage.ErrSnapshotNotFound
+	}
+	return models[0].ToSnapshot(), nil
+}
+
+// QuerySnapshots returns the snapshots of a pool, optionally limited to one subvolume, newest first.
+func (db *DB) QuerySnapshots(ctx context.Context, poolUuid string, subvolumeUuid string) ([]storage.Snapshot, error) {
+	query := db.conn.WithContext(ctx).Where("poolID = ?", poolUuid)
+	if subvolumeUuid != "" {
+		query = query.Where("subvolumeID = ?", subvolumeUuid)
+	}
+
+	var models []SnapshotModel
+	if err := query.Order("createdAt DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]storage.Snapshot, 0, len(models))
+	for _, model := range models {
+		snapshots = append(snapshots, model.ToSnapshot())
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,49 @@
+package DB
+
+import (
+	"context"
+	"goNAS/storage"
+)
+
+// InsertVolume persists a new volume record.
+func (db *DB) InsertVolume(ctx context.Context, volume *storage.Volume) error {
+	model := &VolumeModel{}
+	model.FromVolume(volume)
+
+	return db.conn.WithContext(ctx).Create(model).Error
+}
+
+// PatchVolumeSize updates the recorded size of a volume.
+func (db *DB) PatchVolumeSize(ctx context.Context, volume *storage.Volume) error {
+	result := db.conn.WithContext(ctx).Model(&VolumeModel{}).
+		Where("uuid = ?", volume.Uuid).
+		Update("sizeBytes", volume.SizeBytes)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return storage.ErrVolumeNotFound
+	}
+	return nil
+}
+
+// DeleteVolume removes a volume record by UUID.
+func (db *DB) DeleteVolume(ctx context.Context, uuid string) error {
+	return db.conn.WithContext(ctx).Delete(&VolumeModel{}, "uuid = ?", uuid).Error
+}
+
+// QueryAllVolumes returns all volumes ordered by creation time.
+func (db *DB) QueryAllVolumes(ctx context.Context) ([]storage.Volume, error) {
+	var models []VolumeModel
+	if err := db.conn.WithContext(ctx).Order("createdAt ASC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	volumes := make([]storage.Volume, 0, len(models))
+	for _, model := range models {
+		volumes = append(volumes, model.ToVolume())
+	}
+
+	return volumes, nil
+}
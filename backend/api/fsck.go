@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/helper"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartFsck checks or repairs the filesystem of an unmounted pool, records it
+// in the fsck history and tracks it as a job.
+func (n *Nas) StartFsck(pool *storage.Pool, mode storage.FsckMode, key *helper.LuksKey, c context.Context) (jobs.Job, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.Job{}, jobs.ErrJobTargetBusy
+	}
+	if err := pool.CheckFsck(mode); err != nil {
+		return jobs.Job{}, err
+	}
+	if key != nil {
+		if err := key.Validate(); err != nil {
+			return jobs.Job{}, err
+		}
+	}
+
+	tool, _, _ := storage.FsckCommand(pool.Format, mode, pool.FsDevice())
+	fsck := storage.NewFsck(pool.Uuid, mode, tool)
+	if err := SERVER.Db.SaveFsck(c, fsck); err != nil {
+		return jobs.Job{}, err
+	}
+
+	return SERVER.Jobs.Start("fsck", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		report, err := pool.RunFsck(ctx, mode, key, progress(r))
+		fsck.Finish(report, err, r.Canceled())
+		// The job context may already be canceled; the result must still be recorded
+		if saveErr := SERVER.Db.SaveFsck(context.Background(), fsck); saveErr != nil {
+			log.Println("Error persisting fsck:", saveErr)
+		}
+		return err
+	})
+}
+
+// fsckPool starts a check or repair of an unmounted pool filesystem.
+func fsckPool(c *gin.Context) {
+	var req struct {
+		Mode       string `json:"mode"`
+		Passphrase string `json:"passphrase"`
+		KeyFile    string `json:"keyFile"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	// The body is optional; an empty request runs a check
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+			return
+		}
+	}
+	mode, err := storage.ParseFsckMode(req.Mode)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	var key *helper.LuksKey
+	if req.Passphrase != "" || req.KeyFile != "" {
+		key = &helper.LuksKey{Passphrase: req.Passphrase, KeyFile: req.KeyFile}
+	}
+
+	job, err := NAS.StartFsck(pool, mode, key, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
+
+// listPoolFscks returns the filesystem check history of a pool, newest first.
+func listPoolFscks(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if _, err := NAS.POOLS.GetPool(uuid); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	fscks, err := SERVER.Db.QueryPoolFscks(c, uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, fscks)
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"goNAS/DB"
 	"goNAS/helper"
+	"goNAS/jobs"
 	"goNAS/storage"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -19,6 +21,12 @@ type Server struct {
 	httpServer *http.Server
 	Ctx        *context.Context
 	Db         *DB.DB
+	Jobs       *jobs.Manager
+	Io         *storage.IoSampler
+	cancel     context.CancelFunc
+
+	subscribersMu sync.RWMutex
+	subscribers   []DriveSubscriber
 }
 
 var SERVER = &Server{}
@@ -44,7 +52,9 @@ func NewAPIServer(addr string, db *DB.DB) *Server {
 			IdleTimeout:  10 * time.Second,
 			Handler:      r,
 		},
-		Db: db,
+		Db:   db,
+		Jobs: jobs.NewManager(db),
+		Io:   storage.NewIoSampler(IoHistorySize),
 	}
 	SERVER = server
 	return server
@@ -63,18 +73,41 @@ func (s *Server) Start() error {
 	if err != nil {
 		return nil
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Ctx = &ctx
+	s.cancel = cancel
+	s.ReconcilePools(ctx)
+	go s.MonitorHealth(ctx, HealthInterval)
+	go s.ScheduleScrubs(ctx, ScrubCheckInterval)
+	go s.ScheduleSnapshots(ctx, SnapshotCheckInterval)
+	go s.CollectSmart(ctx, SmartInterval)
+	go s.SampleIo(ctx, IoInterval)
+	s.SubscribeDrives(s.refreshHealthOnHotplug, s.collectSmartOnHotplug)
+	if source, err := storage.NewNetlinkUeventSource(); err != nil {
+		log.Println("Hotplug detection disabled:", err)
+	} else {
+		go s.WatchDrives(ctx, source)
+	}
 	log.Println("Server started on", s.httpServer.Addr)
 	return nil
 }
 
-// Shutdown gracefully stops the HTTP server.
+// Shutdown gracefully stops the HTTP server, background monitors and running jobs.
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.Jobs.Stop()
 	return s.httpServer.Shutdown(ctx)
 }
 
-// LoadData hydrates in-memory pools and adopted drives from the database.
+// LoadData hydrates in-memory pools, adopted drives and jobs from the database.
 func (s *Server) LoadData(c context.Context) error {
-	err := s.Nas.LoadPools(c)
+	err := s.LoadJobs(c)
+	if err != nil {
+		return err
+	}
+	err = s.Nas.LoadPools(c)
 	if err != nil {
 		return err
 	}
@@ -85,10 +118,38 @@ func (s *Server) LoadData(c context.Context) error {
 	return nil
 }
 
+// LoadJobs marks jobs and scrubs left running by a previous process as interrupted
+// and restores the jobs.
+func (s *Server) LoadJobs(c context.Context) error {
+	err := s.Db.InterruptRunningJobs(c, storage.CreationTime())
+	if err != nil {
+		return err
+	}
+	err = s.Db.InterruptRunningScrubs(c, storage.CreationTime())
+	if err != nil {
+		return err
+	}
+	err = s.Db.InterruptRunningFscks(c, storage.CreationTime())
+	if err != nil {
+		return err
+	}
+	persisted, err := s.Db.QueryAllJobs(c)
+	if err != nil {
+		return err
+	}
+	s.Jobs.Restore(persisted...)
+	return nil
+}
+
 type Nas struct {
+	// mu guards the pool, drive, spare and wipe token maps, and the drive,
+	// pool and presence of every adopted drive.
+	mu            sync.RWMutex
 	POOLS         *storage.Pools
 	SystemDrives  map[string]*storage.DriveInfo
 	AdoptedDrives map[string]*storage.AdoptedDrive
+	GlobalSpares  map[string]*storage.AdoptedDrive
+	wipeTokens    map[string]storage.WipeConfirmation
 }
 
 var NAS = &Nas{}
@@ -99,7 +160,10 @@ func (n *Nas) LoadAdoptedDrives(c context.Context) error {
 	if err != nil {
 		return err
 	}
+	n.mu.Lock()
 	n.AdoptedDrives = make(map[string]*storage.AdoptedDrive)
+	n.GlobalSpares = make(map[string]*storage.AdoptedDrive)
+	n.mu.Unlock()
 	for i := range adoptedDrives {
 		adoptedDrive := adoptedDrives[i]
 		drive := n.getDriveByKey(adoptedDrives[i].Key())
@@ -111,19 +175,38 @@ func (n *Nas) LoadAdoptedDrives(c context.Context) error {
 }
 
 // ClaimDrive merges a persisted adopted drive with the current system drive.
+// Drives that are absent are kept, marked missing, until hotplug sees them
+// again; pool members stay in their pool so they can still be replaced.
 func (n *Nas) ClaimDrive(drive *storage.DriveInfo, adoptedDrive storage.AdoptedDrive) error {
-	if drive == nil {
-		return storage.ErrDriveNotFound
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	missing := drive == nil
+	if missing {
+		// The persisted record only carries the drive key and UUID
+		drive = adoptedDrive.Drive
 	}
 	drive.Uuid = adoptedDrive.GetUuid()
 	adoptedDrive.Drive = drive
+	adoptedDrive.Missing = missing
 
 	if adoptedDrive.GetPoolID() != "" {
 		pool, err := n.POOLS.GetPool(adoptedDrive.GetPoolID())
 		if err != nil {
 			return err
 		}
-		pool.AddDrives(drive)
+		if adoptedDrive.IsSpare() {
+			pool.AddSpares(drive)
+		} else {
+			pool.AddDrives(drive)
+		}
+		member, _ := pool.Member(adoptedDrive.GetUuid())
+		member.PartUuid = adoptedDrive.PartUuid
+		member.Missing = missing
+		return nil
+	}
+
+	if adoptedDrive.IsSpare() {
+		n.GlobalSpares[adoptedDrive.GetUuid()] = &adoptedDrive
 		return nil
 	}
 
@@ -132,24 +215,87 @@ func (n *Nas) ClaimDrive(drive *storage.DriveInfo, adoptedDrive storage.AdoptedD
 	return nil
 }
 
-// LoadPools loads persisted pools into memory.
+// LoadPools loads persisted pools with their volumes and subvolumes into memory.
 func (n *Nas) LoadPools(c context.Context) error {
 	pools, err := SERVER.Db.QueryAllPools(c)
 	if err != nil {
 		return err
 	}
-	n.POOLS = &storage.Pools{}
+	loaded := &storage.Pools{}
 	for _, pool := range pools {
-		err = n.POOLS.AddPool(&pool)
+		err = loaded.AddPool(&pool)
 		if err != nil {
 			return err
 		}
 	}
+	volumes, err := SERVER.Db.QueryAllVolumes(c)
+	if err != nil {
+		return err
+	}
+	for i := range volumes {
+		pool, err := loaded.GetPool(volumes[i].PoolID)
+		if err != nil {
+			log.Println("Error loading volume:", err)
+			continue
+		}
+		pool.AddVolumes(&volumes[i])
+	}
+	subvolumes, err := SERVER.Db.QueryAllSubvolumes(c)
+	if err != nil {
+		return err
+	}
+	for i := range subvolumes {
+		pool, err := loaded.GetPool(subvolumes[i].PoolID)
+		if err != nil {
+			log.Println("Error loading subvolume:", err)
+			continue
+		}
+		pool.AddSubvolumes(&subvolumes[i])
+	}
+	n.mu.Lock()
+	n.POOLS = loaded
+	n.mu.Unlock()
 	return nil
 }
 
+// PoolList returns a snapshot of the in-memory pools for background iteration.
+func (n *Nas) PoolList() []*storage.Pool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	list := make([]*storage.Pool, 0, len(*n.POOLS))
+	for _, pool := range *n.POOLS {
+		list = append(list, pool)
+	}
+	return list
+}
+
+// SystemDriveList returns a snapshot of the system drives for background iteration.
+func (n *Nas) SystemDriveList() []*storage.DriveInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	list := make([]*storage.DriveInfo, 0, len(n.SystemDrives))
+	for _, drive := range n.SystemDrives {
+		list = append(list, drive)
+	}
+	return list
+}
+
+// copySystemDrives returns copies of the system drives keyed like SystemDrives,
+// safe to serialize while SMART and I/O sampling update the drives.
+func (n *Nas) copySystemDrives() map[string]storage.DriveInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	drives := make(map[string]storage.DriveInfo, len(n.SystemDrives))
+	for key, drive := range n.SystemDrives {
+		drives[key] = *drive
+	}
+	return drives
+}
+
 // updatePool replaces a pool entry in memory.
 func (n *Nas) updatePool(pool *storage.Pool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	if _, exists := (*n.POOLS)[pool.Uuid]; !exists {
 		return storage.ErrPoolNotInMemory
 	}
@@ -157,38 +303,73 @@ func (n *Nas) updatePool(pool *storage.Pool) error {
 	return nil
 }
 
-// deletePool removes a pool and returns adopted drives to the available set.
-func (n *Nas) deletePool(p *storage.Pool) error {
-	adoptedDrives := p.AdoptedDrives
-	for _, adopt := range adoptedDrives {
-		adopt.SetPoolID("")
-		n.AdoptedDrives[adopt.GetUuid()] = adopt
+// deletePool takes a pool offline, removes it and returns adopted drives to the available set.
+func (n *Nas) deletePool(p *storage.Pool, c context.Context) error {
+	// Refuse before taking the pool offline so a pool with volumes stays in use
+	if err := p.CheckNoVolumes(); err != nil {
+		return err
 	}
-	err := n.setOffline(p)
+	err := n.setOffline(p, c)
 	if err != nil {
 		return err
 	}
 
-	err = NAS.POOLS.DeletePool(p.Uuid)
+	n.mu.Lock()
+	err = n.POOLS.DeletePool(p.Uuid)
+	n.mu.Unlock()
 	if err != nil {
 		return err
 	}
+
+	for _, adopt := range p.Members() {
+		n.mu.Lock()
+		adopt.SetPoolID("")
+		adopt.SetState("")
+		n.AdoptedDrives[adopt.GetUuid()] = adopt
+		n.mu.Unlock()
+		if err = n.clearPartition(adopt, c); err != nil {
+			return err
+		}
+	}
+	for _, spare := range p.DedicatedSpares() {
+		if err = n.releaseDrive(spare, c); err != nil {
+			return err
+		}
+	}
+	if p.MdDevice != "" && p.IsBuilt() {
+		n.syncMdadmConf()
+	}
 	log.Println("Pool", p.Uuid, "deleted from memory")
 	return nil
 }
 
 // GetAdoptedDriveByKey retrieves an adopted drive by its key.
 func (n *Nas) GetAdoptedDriveByKey(key string) *storage.AdoptedDrive {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.adoptedByKey(key)
+}
+
+// adoptedByKey returns the free adopted drive or global spare with the drive key.
+// Callers hold n.mu.
+func (n *Nas) adoptedByKey(key string) *storage.AdoptedDrive {
 	for _, drive := range n.AdoptedDrives {
 		if drive.Key() == key {
 			return drive
 		}
 	}
+	for _, drive := range n.GlobalSpares {
+		if drive.Key() == key {
+			return drive
+		}
+	}
 	return nil
 }
 
 // GetDriveByUuid returns an adopted drive's DriveInfo by UUID.
 func (n *Nas) GetDriveByUuid(id string) *storage.DriveInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	if drive, exists := n.AdoptedDrives[id]; exists {
 		return drive.Drive
 	}
@@ -197,7 +378,7 @@ func (n *Nas) GetDriveByUuid(id string) *storage.DriveInfo {
 
 // getDriveByKey retrieves a drive from the system drives by its key.
 func (n *Nas) getDriveByKey(key string) *storage.DriveInfo {
-	for _, drive := range n.SystemDrives {
+	for _, drive := range n.SystemDriveList() {
 		if drive.DriveKey.String() == key {
 			return drive
 		}
@@ -219,7 +400,9 @@ func ensureUniqueKeys(keys ...string) error {
 
 // AddPool adds the pool to memory and persists pool ownership on drives.
 func (n *Nas) AddPool(p *storage.Pool, c *gin.Context) error {
+	n.mu.Lock()
 	err := n.POOLS.AddPool(p)
+	n.mu.Unlock()
 	if err != nil {
 		return err
 	}
@@ -227,7 +410,7 @@ func (n *Nas) AddPool(p *storage.Pool, c *gin.Context) error {
 		PoolID: &p.Uuid,
 	}
 
-	for _, drive := range p.AdoptedDrives {
+	for _, drive := range p.Members() {
 		err = SERVER.Db.PatchDrive(c, drive.GetUuid(), drivePatch)
 		if err != nil {
 			return err
@@ -237,8 +420,252 @@ func (n *Nas) AddPool(p *storage.Pool, c *gin.Context) error {
 	return nil
 }
 
+// progress adapts a job reporter to storage progress callbacks.
+func progress(r *jobs.Reporter) storage.ProgressFunc {
+	return func(phase storage.Phase, percent float64) {
+		r.Update(string(phase), percent)
+	}
+}
+
+// StartBuild builds the pool in a background job and persists its mount point and status.
+func (n *Nas) StartBuild(pool *storage.Pool) (jobs.Job, error) {
+	if !pool.HasBuildKey() {
+		return jobs.Job{}, helper.ErrLuksKeyRequired
+	}
+	return SERVER.Jobs.Start("build", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		err := pool.Build(ctx, progress(r))
+		if err != nil {
+			// The pool was saved before the build, so its failure must be saved too
+			if saveErr := SERVER.Db.PatchPoolStatus(context.Background(), pool); saveErr != nil {
+				log.Println("Error persisting failed build:", saveErr)
+			}
+			return err
+		}
+		if err = n.persistPartitions(pool, ctx); err != nil {
+			return err
+		}
+		if err = SERVER.Db.PatchPoolMount(pool.Uuid, pool.MountPoint); err != nil {
+			return err
+		}
+		if pool.IsEncrypted() {
+			if err = SERVER.Db.PatchPoolEncryption(ctx, pool); err != nil {
+				return err
+			}
+		}
+		if err = SERVER.Db.PatchPoolCapacity(ctx, pool); err != nil {
+			return err
+		}
+		if pool.MdDevice != "" {
+			n.syncMdadmConf()
+		}
+		return SERVER.Db.PatchPoolStatus(ctx, pool)
+	})
+}
+
+// driveAvailable reports whether an adopted drive can join a pool or the spare
+// set: it belongs to no pool, is attached and no job such as a wipe runs on it.
+// Callers hold n.mu.
+func driveAvailable(adopted *storage.AdoptedDrive) bool {
+	return adopted.GetPoolID() == "" && !adopted.Missing && !SERVER.Jobs.Busy(adopted.GetUuid())
+}
+
+// freeAdoptedDrives returns the drives for free adopted drive UUIDs that are available to use.
+func (n *Nas) freeAdoptedDrives(driveUuids []string) ([]*storage.DriveInfo, error) {
+	if err := ensureUniqueKeys(driveUuids...); err != nil {
+		return nil, err
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	drives := make([]*storage.DriveInfo, 0, len(driveUuids))
+	for _, id := range driveUuids {
+		adopted, ok := n.AdoptedDrives[id]
+		if !ok || !driveAvailable(adopted) {
+			return nil, storage.ErrDriveNotFoundOrInUse
+		}
+		drives = append(drives, adopted.Drive)
+	}
+	return drives, nil
+}
+
+// assignDrives removes drives from the free adopted set and persists their pool ownership.
+func (n *Nas) assignDrives(pool *storage.Pool, driveUuids []string, c context.Context) error {
+	n.mu.Lock()
+	for _, id := range driveUuids {
+		delete(n.AdoptedDrives, id)
+	}
+	n.mu.Unlock()
+	for _, id := range driveUuids {
+		if err := SERVER.Db.PatchDrive(c, id, DB.DrivePatch{PoolID: &pool.Uuid}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistPartitions records the partition UUIDs of a partitioned pool's members and spares.
+func (n *Nas) persistPartitions(pool *storage.Pool, c context.Context) error {
+	if !pool.Partitioned {
+		return nil
+	}
+	for _, d := range append(pool.Members(), pool.DedicatedSpares()...) {
+		if d.PartUuid == "" {
+			continue
+		}
+		partUuid := d.PartUuid
+		if err := SERVER.Db.PatchDrive(c, d.GetUuid(), DB.DrivePatch{PartUuid: &partUuid}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearPartition forgets the partition a drive held as a pool member.
+func (n *Nas) clearPartition(drive *storage.AdoptedDrive, c context.Context) error {
+	if drive.PartUuid == "" {
+		return nil
+	}
+	drive.PartUuid = ""
+	cleared := ""
+	return SERVER.Db.PatchDrive(c, drive.GetUuid(), DB.DrivePatch{PartUuid: &cleared})
+}
+
+// GrowPool adds free adopted drives to a built pool, persists their ownership and
+// tracks the reshape and filesystem resize as a job.
+func (n *Nas) GrowPool(pool *storage.Pool, driveUuids []string, c context.Context) (jobs.Job, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.Job{}, jobs.ErrJobTargetBusy
+	}
+	drives, err := n.freeAdoptedDrives(driveUuids)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	if err = pool.Grow(drives...); err != nil {
+		return jobs.Job{}, err
+	}
+
+	if err = n.assignDrives(pool, driveUuids, c); err != nil {
+		return jobs.Job{}, err
+	}
+	if err = n.persistPartitions(pool, c); err != nil {
+		return jobs.Job{}, err
+	}
+
+	return SERVER.Jobs.Start("grow", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		err := pool.FinishGrow(ctx, progress(r))
+		if err != nil {
+			return err
+		}
+		n.syncMdadmConf()
+		return SERVER.Db.PatchPoolCapacity(ctx, pool)
+	})
+}
+
+// MigratePool converts a built raid pool to another level, optionally adding
+// free adopted drives, and tracks the reshape as a job. The new level is only
+// persisted once the reshape completes.
+func (n *Nas) MigratePool(pool *storage.Pool, level int, driveUuids []string, c context.Context) (jobs.Job, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.Job{}, jobs.ErrJobTargetBusy
+	}
+	drives, err := n.freeAdoptedDrives(driveUuids)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	if err = pool.MigrateLevel(level, drives...); err != nil {
+		if errors.Is(err, storage.ErrMigrationPartial) {
+			// The array changed level before failing; keep the saved type in step with it
+			if saveErr := SERVER.Db.PatchPoolType(c, pool); saveErr != nil {
+				log.Println("Error persisting pool type:", saveErr)
+			}
+			n.syncMdadmConf()
+		}
+		return jobs.Job{}, err
+	}
+
+	if err = n.assignDrives(pool, driveUuids, c); err != nil {
+		return jobs.Job{}, err
+	}
+	if err = n.persistPartitions(pool, c); err != nil {
+		return jobs.Job{}, err
+	}
+
+	return SERVER.Jobs.Start("migrate", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		report := progress(r)
+		if err := pool.FinishMigration(ctx, level, report); err != nil {
+			return err
+		}
+		if err := SERVER.Db.PatchPoolType(ctx, pool); err != nil {
+			return err
+		}
+		n.syncMdadmConf()
+		if err := pool.GrowFilesystem(report); err != nil {
+			return err
+		}
+		return SERVER.Db.PatchPoolCapacity(ctx, pool)
+	})
+}
+
+// ReplaceDrive swaps a pool member for a free adopted drive, moves drive
+// ownership in memory and in the database, and tracks the rebuild as a job.
+func (n *Nas) ReplaceDrive(pool *storage.Pool, failedUuid string, replacementUuid string, c context.Context) (jobs.Job, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.Job{}, jobs.ErrJobTargetBusy
+	}
+	replacements, err := n.freeAdoptedDrives([]string{replacementUuid})
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	removed, err := pool.ReplaceDrive(failedUuid, replacements[0])
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	n.mu.Lock()
+	delete(n.AdoptedDrives, replacementUuid)
+	removed.SetPoolID("")
+	removed.SetState("")
+	n.AdoptedDrives[removed.GetUuid()] = removed
+	n.mu.Unlock()
+
+	if err = SERVER.Db.PatchDrive(c, replacementUuid, DB.DrivePatch{PoolID: &pool.Uuid}); err != nil {
+		return jobs.Job{}, err
+	}
+	released := ""
+	if err = SERVER.Db.PatchDrive(c, removed.GetUuid(), DB.DrivePatch{PoolID: &released}); err != nil {
+		return jobs.Job{}, err
+	}
+	if err = n.clearPartition(removed, c); err != nil {
+		return jobs.Job{}, err
+	}
+	if err = n.persistPartitions(pool, c); err != nil {
+		return jobs.Job{}, err
+	}
+	return n.StartRebuild(pool)
+}
+
+// StartRebuild tracks an md recovery in a background job and persists the resulting health.
+func (n *Nas) StartRebuild(pool *storage.Pool) (jobs.Job, error) {
+	return SERVER.Jobs.Start("rebuild", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		err := pool.WaitForSync(ctx, storage.PhaseRebuild, progress(r))
+		if err != nil {
+			return err
+		}
+		arrays, err := storage.ReadMdstat()
+		if err != nil {
+			return err
+		}
+		pool.ApplyHealth(pool.CheckHealth(arrays))
+		return SERVER.Db.PatchPoolStatus(ctx, pool)
+	})
+}
+
 // AreDrivesAlreadyInPool checks whether any drive UUID already has a pool.
 func (n *Nas) AreDrivesAlreadyInPool(d []string) (string, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	for _, uuid := range d {
 		adoptedDrive, ok := n.AdoptedDrives[uuid]
 		if !ok {
@@ -254,6 +681,8 @@ func (n *Nas) AreDrivesAlreadyInPool(d []string) (string, bool) {
 // RemoveAdoptedDrives removes an in mem adopted drive by its UUID.
 // Returns an error if the drive is not found or if it is currently in use by a pool.
 func (n *Nas) RemoveAdoptedDrives(uuid []string, c *gin.Context) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 	removedCount := 0
 	for _, id := range uuid {
 		if _, exists := n.AdoptedDrives[id]; !exists {
@@ -267,21 +696,13 @@ func (n *Nas) RemoveAdoptedDrives(uuid []string, c *gin.Context) int {
 
 // PopulatePool populates a storage pool with the specified drives ids.
 func (n *Nas) PopulatePool(pool *storage.Pool, drives []string, c *gin.Context) error {
-	if err := ensureUniqueKeys(drives...); err != nil {
+	poolDrives, err := n.freeAdoptedDrives(drives)
+	if err != nil {
 		return err
 	}
 
-	poolDrives := make([]*storage.DriveInfo, 0, len(drives))
-	for _, driveID := range drives {
-		drive := n.GetDriveByUuid(driveID)
-		if drive == nil {
-			return storage.ErrDriveNotFoundOrInUse
-		}
-		poolDrives = append(poolDrives, drive)
-	}
-
 	pool.AddDrives(poolDrives...)
-	err := SERVER.Db.InsertPool(c, pool, pool.CreatedAt)
+	err = SERVER.Db.InsertPool(c, pool, pool.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -304,10 +725,78 @@ func (n *Nas) AdoptDriveByKey(key string, c *gin.Context) (*storage.AdoptedDrive
 	if err != nil {
 		return nil, err
 	}
+	n.mu.Lock()
 	n.AdoptedDrives[adoptedDrive.GetUuid()] = adoptedDrive
+	n.mu.Unlock()
 	return adoptedDrive, nil
 }
 
+// UnadoptDrive removes a free adopted drive or global spare from memory and the
+// database, wiping its md and filesystem signatures first when wipe is set.
+// Drives only recorded in the database are removed too; missing ones cannot be wiped.
+func (n *Nas) UnadoptDrive(driveUuid string, wipe bool, c context.Context) (*storage.AdoptedDrive, error) {
+	adopted, err := n.FindAdoptedDrive(driveUuid)
+	if errors.Is(err, storage.ErrDriveNotFound) {
+		adopted, err = n.queryAdoptedDrive(driveUuid, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	n.mu.RLock()
+	poolID, missing, drive := adopted.GetPoolID(), adopted.Missing, adopted.Drive
+	n.mu.RUnlock()
+	if poolID != "" {
+		return nil, storage.ErrDriveInPool
+	}
+	if SERVER.Jobs.Busy(driveUuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+
+	if wipe {
+		if missing {
+			return nil, storage.ErrDriveMissing
+		}
+		if err = storage.CheckWipe(drive, storage.WipeSignatures); err != nil {
+			return nil, err
+		}
+		if err = storage.WipeDrive(c, drive, storage.WipeSignatures, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = SERVER.Db.DeleteDrive(c, driveUuid); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	delete(n.AdoptedDrives, driveUuid)
+	delete(n.GlobalSpares, driveUuid)
+	delete(n.wipeTokens, driveUuid)
+	// The system drive is no longer adopted and must not record SMART history
+	adopted.Drive.SetUuid("")
+	n.mu.Unlock()
+	return adopted, nil
+}
+
+// queryAdoptedDrive returns an adopted drive that is only recorded in the
+// database, such as one that could not be loaded at startup. It is marked
+// missing unless its system drive is attached.
+func (n *Nas) queryAdoptedDrive(driveUuid string, c context.Context) (*storage.AdoptedDrive, error) {
+	adopted, found, err := SERVER.Db.QueryDriveByUuid(c, driveUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, storage.ErrDriveNotFound
+	}
+	if drive := n.getDriveByKey(adopted.Key()); drive != nil {
+		drive.Uuid = adopted.GetUuid()
+		adopted.Drive = drive
+	} else {
+		adopted.Missing = true
+	}
+	return &adopted, nil
+}
+
 // ValidatePoolPatch validates patch fields before persistence.
 func (n *Nas) ValidatePoolPatch(patch *DB.PoolPatch) error {
 	if patch == nil {
@@ -336,13 +825,42 @@ func (n *Nas) ValidatePoolPatch(patch *DB.PoolPatch) error {
 		}
 	}
 
+	if patch.ScrubSchedule != "" {
+		err := storage.ValidateScrubSchedule(patch.ScrubSchedule)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// Todo: implement actual offline logic
-// setOffline sets the status of the pool to offline.
-func (n *Nas) setOffline(pool *storage.Pool) error {
-	pool.SetStatus(storage.Offline)
+// RemountPool applies new mount options to a mounted pool before they are persisted.
+func (n *Nas) RemountPool(pool *storage.Pool, options []string) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	return pool.Remount(options)
+}
 
-	return nil
+// setOffline unmounts and stops the pool array, then persists the offline status.
+func (n *Nas) setOffline(pool *storage.Pool, c context.Context) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	if err := pool.Offline(); err != nil {
+		return err
+	}
+	return SERVER.Db.PatchPoolStatus(c, pool)
+}
+
+// setOnline assembles and mounts the pool array, then persists the resulting status.
+func (n *Nas) setOnline(pool *storage.Pool, c context.Context) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	if err := pool.Online(); err != nil {
+		return err
+	}
+	return SERVER.Db.PatchPoolStatus(c, pool)
 }
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"goNAS/DB"
 	"goNAS/helper"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidatePoolPatchName(t *testing.T) {
@@ -32,3 +38,200 @@ func TestValidatePoolPatchName(t *testing.T) {
 		}
 	})
 }
+
+func TestRequestPoolType(t *testing.T) {
+	level := 5
+	poolType, err := requestPoolType("", &level)
+	if err != nil || poolType.Value() != "raid5" {
+		t.Fatalf("expected raid5 from raid level, got %v (%v)", poolType, err)
+	}
+
+	poolType, err = requestPoolType("mirrored", &level)
+	if err != nil || poolType.Value() != "mirrored" {
+		t.Fatalf("expected type to take precedence, got %v (%v)", poolType, err)
+	}
+
+	if _, err = requestPoolType("", nil); !errors.Is(err, storage.ErrInvalidRequestBody) {
+		t.Fatalf("expected ErrInvalidRequestBody, got %v", err)
+	}
+	if _, err = requestPoolType("jbod", nil); !errors.Is(err, storage.ErrInvalidPoolType) {
+		t.Fatalf("expected ErrInvalidPoolType, got %v", err)
+	}
+}
+
+func TestUnadoptDrive(t *testing.T) {
+	db := DB.NewDB(filepath.Join(t.TempDir(), "unadopt.db"))
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.InitSchema(ctx); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	previous := SERVER
+	SERVER = &Server{Db: db, Jobs: jobs.NewManager(db)}
+	defer func() { SERVER = previous }()
+
+	free := storage.NewAdoptedDrive(&storage.DriveInfo{Name: "sda", DriveKey: storage.DriveKey{Kind: "serial", Value: "FREE1"}})
+	member := storage.NewAdoptedDrive(&storage.DriveInfo{Name: "sdb", DriveKey: storage.DriveKey{Kind: "serial", Value: "MEMBER1"}})
+	member.SetPoolID("pool-1")
+	n := &Nas{
+		POOLS:         &storage.Pools{},
+		AdoptedDrives: map[string]*storage.AdoptedDrive{free.GetUuid(): free, member.GetUuid(): member},
+		GlobalSpares:  make(map[string]*storage.AdoptedDrive),
+		wipeTokens:    map[string]storage.WipeConfirmation{free.GetUuid(): {Token: "t"}},
+	}
+	for _, d := range []*storage.AdoptedDrive{free, member} {
+		if err := db.InsertDrive(ctx, d.Drive, d.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert drive: %v", err)
+		}
+	}
+
+	if _, err := n.UnadoptDrive(member.GetUuid(), false, ctx); !errors.Is(err, storage.ErrDriveInPool) {
+		t.Fatalf("expected ErrDriveInPool, got %v", err)
+	}
+	if _, err := n.UnadoptDrive("unknown", false, ctx); !errors.Is(err, storage.ErrDriveNotFound) {
+		t.Fatalf("expected ErrDriveNotFound, got %v", err)
+	}
+
+	id := free.GetUuid()
+	released, err := n.UnadoptDrive(id, false, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if released.Drive.Uuid != "" {
+		t.Fatalf("expected the system drive to lose its uuid, got %q", released.Drive.Uuid)
+	}
+	if _, ok := n.AdoptedDrives[id]; ok {
+		t.Fatalf("expected the drive to leave memory")
+	}
+	if _, ok := n.wipeTokens[id]; ok {
+		t.Fatalf("expected the wipe token to be dropped")
+	}
+	if _, found, _ := db.QueryDriveByKey(ctx, released.Drive.DriveKey); found {
+		t.Fatalf("expected the drive record to be deleted")
+	}
+}
+
+func TestUnadoptMissingDrive(t *testing.T) {
+	db := DB.NewDB(filepath.Join(t.TempDir(), "unadopt.db"))
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.InitSchema(ctx); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	previous := SERVER
+	SERVER = &Server{Db: db, Jobs: jobs.NewManager(db)}
+	defer func() { SERVER = previous }()
+
+	n := &Nas{
+		POOLS:         &storage.Pools{},
+		AdoptedDrives: make(map[string]*storage.AdoptedDrive),
+		GlobalSpares:  make(map[string]*storage.AdoptedDrive),
+		wipeTokens:    make(map[string]storage.WipeConfirmation),
+	}
+	// One drive was absent at startup, the other was never loaded into memory
+	absent := storage.NewAdoptedDrive(&storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "GONE1"}})
+	unloaded := storage.NewAdoptedDrive(&storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "GONE2"}})
+	for _, d := range []*storage.AdoptedDrive{absent, unloaded} {
+		if err := db.InsertDrive(ctx, d.Drive, d.CreatedAt); err != nil {
+			t.Fatalf("Failed to insert drive: %v", err)
+		}
+	}
+	if err := n.ClaimDrive(nil, *absent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range []string{absent.GetUuid(), unloaded.GetUuid()} {
+		if _, err := n.UnadoptDrive(id, true, ctx); !errors.Is(err, storage.ErrDriveMissing) {
+			t.Fatalf("expected ErrDriveMissing when wiping a missing drive, got %v", err)
+		}
+		if _, err := n.UnadoptDrive(id, false, ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, found, _ := db.QueryDriveByUuid(ctx, id); found {
+			t.Fatalf("expected the drive record %s to be deleted", id)
+		}
+	}
+	if len(n.AdoptedDrives) != 0 {
+		t.Fatalf("expected the missing drive to leave memory, got %v", n.AdoptedDrives)
+	}
+}
+
+func TestClaimDriveKeepsMissingPoolMember(t *testing.T) {
+	pool, _ := storage.NewPool("media", &storage.Raid{Level: 1}, "ext4")
+	pools := &storage.Pools{}
+	_ = pools.AddPool(pool)
+	n := &Nas{POOLS: pools, AdoptedDrives: make(map[string]*storage.AdoptedDrive)}
+
+	persisted := storage.AdoptedDrive{
+		Drive:  &storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "GONE1"}, Uuid: "gone"},
+		Uuid:   "gone",
+		PoolID: pool.Uuid,
+		Role:   storage.RoleActive,
+	}
+	if err := n.ClaimDrive(nil, persisted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	member, ok := pool.AdoptedDrives["gone"]
+	if !ok || !member.Missing || member.Key() != "serial:GONE1" {
+		t.Fatalf("expected a missing placeholder member, got %+v", member)
+	}
+	if paths := pool.MemberPaths(); len(paths) != 0 {
+		t.Fatalf("expected missing members to be left out of the member paths, got %v", paths)
+	}
+}
+
+func TestStartBuildRequiresLuksKey(t *testing.T) {
+	// An encrypted pool loaded from the database has lost its build key
+	pool, _ := storage.NewPool("secret", &storage.Raid{Level: 1}, "ext4")
+	pool.Encrypted = true
+	n := &Nas{}
+
+	if _, err := n.StartBuild(pool); !errors.Is(err, helper.ErrLuksKeyRequired) {
+		t.Fatalf("expected ErrLuksKeyRequired, got %v", err)
+	}
+	if err := pool.EnableEncryption(helper.LuksKey{Passphrase: "hunter2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pool.HasBuildKey() {
+		t.Fatal("expected the pool to hold its build key again")
+	}
+}
+
+func TestStartBuildRecordsFailure(t *testing.T) {
+	db := DB.NewDB(filepath.Join(t.TempDir(), "build.db"))
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.InitSchema(ctx); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	previous := SERVER
+	SERVER = &Server{Db: db, Jobs: jobs.NewManager(db)}
+	defer func() { SERVER = previous }()
+
+	// A raid5 pool of one drive cannot be built
+	pool, _ := storage.NewPool("broken", &storage.Raid{Level: 5}, "ext4", &storage.DriveInfo{Name: "sda", Uuid: "a"})
+	if err := db.InsertPool(ctx, pool, pool.CreatedAt); err != nil {
+		t.Fatalf("Failed to insert pool: %v", err)
+	}
+	job, err := (&Nas{}).StartBuild(pool)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if job, _ = SERVER.Jobs.Get(job.ID); job.Done() || time.Now().After(deadline) {
+			break
+		}
+	}
+	if job.State != jobs.Failed {
+		t.Fatalf("expected the build job to fail, got %+v", job)
+	}
+
+	pools, err := db.QueryAllPools(ctx)
+	if err != nil {
+		t.Fatalf("Failed to query pools: %v", err)
+	}
+	saved := pools[pool.Uuid]
+	if saved.Status != storage.Offline || !strings.HasPrefix(saved.StatusReason, storage.ReasonBuildFailed) {
+		t.Fatalf("expected the failed build to be saved, got %q %q", saved.Status, saved.StatusReason)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"goNAS/storage"
+	"log"
+)
+
+// DriveEvent describes a system drive that appeared, changed or disappeared.
+type DriveEvent struct {
+	Action  storage.UeventAction  `json:"action"`
+	Name    string                `json:"name"`
+	Drive   *storage.DriveInfo    `json:"drive"`
+	Adopted *storage.AdoptedDrive `json:"adopted,omitempty"`
+}
+
+// DriveSubscriber is called for every drive event after the system drives are updated.
+type DriveSubscriber func(ctx context.Context, event DriveEvent)
+
+// SubscribeDrives registers subscribers for drive events.
+func (s *Server) SubscribeDrives(subscribers ...DriveSubscriber) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	s.subscribers = append(s.subscribers, subscribers...)
+}
+
+// publishDrive delivers a drive event to every subscriber.
+func (s *Server) publishDrive(ctx context.Context, event DriveEvent) {
+	s.subscribersMu.RLock()
+	subscribers := append([]DriveSubscriber(nil), s.subscribers...)
+	s.subscribersMu.RUnlock()
+	for _, subscriber := range subscribers {
+		subscriber(ctx, event)
+	}
+}
+
+// WatchDrives applies block device uevents from source to the system drives
+// until ctx is done or the source fails.
+func (s *Server) WatchDrives(ctx context.Context, source storage.UeventSource) {
+	defer source.Close()
+	for {
+		event, err := source.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, storage.ErrUeventParse) {
+			continue
+		}
+		if err != nil {
+			log.Println("Error reading uevent, hotplug detection stopped:", err)
+			return
+		}
+		s.HandleUevent(ctx, event)
+	}
+}
+
+// HandleUevent updates the system drives and adopted drive presence for a
+// whole disk uevent and notifies subscribers. Other events are ignored.
+func (s *Server) HandleUevent(ctx context.Context, event storage.Uevent) {
+	if !event.IsDisk() || event.DevName == "" {
+		return
+	}
+	var (
+		driveEvent DriveEvent
+		ok         bool
+	)
+	switch event.Action {
+	case storage.UeventAdd, storage.UeventChange:
+		driveEvent, ok = s.attachDrive(event)
+	case storage.UeventRemove:
+		driveEvent, ok = s.Nas.detachDrive(event.DevName)
+	}
+	if !ok {
+		return
+	}
+	if driveEvent.Adopted != nil {
+		log.Printf("Drive %s (%s) %s, adopted drive %s", event.DevName, driveEvent.Drive.DriveKey, event.Action, driveEvent.Adopted.GetUuid())
+	}
+	s.publishDrive(ctx, driveEvent)
+}
+
+// attachDrive reads the drive named by an add or change event into the system
+// drives and marks its adopted drive present.
+func (s *Server) attachDrive(event storage.Uevent) (DriveEvent, bool) {
+	drive, ok := storage.GetSystemDrive(event.DevName)
+	if !ok {
+		return DriveEvent{}, false
+	}
+	key := drive.DriveKey.String()
+
+	s.Nas.mu.Lock()
+	// Replace rather than mutate the map so background loops ranging over it are unaffected
+	drives := make(map[string]*storage.DriveInfo, len(s.Nas.SystemDrives)+1)
+	for k, d := range s.Nas.SystemDrives {
+		drives[k] = d
+	}
+	if previous, exists := drives[key]; exists {
+		drive.Health = previous.Health
+		drive.Io = previous.Io
+	}
+	drives[key] = drive
+	s.Nas.SystemDrives = drives
+
+	adopted := s.Nas.findAdoptedByKey(key)
+	if adopted != nil {
+		drive.Uuid = adopted.GetUuid()
+		adopted.Drive = drive
+		adopted.Missing = false
+	}
+	s.Nas.mu.Unlock()
+	return DriveEvent{Action: event.Action, Name: event.DevName, Drive: drive, Adopted: adopted}, true
+}
+
+// detachDrive removes the named drive from the system drives and marks its adopted drive missing.
+func (n *Nas) detachDrive(name string) (DriveEvent, bool) {
+	n.mu.Lock()
+	var removed *storage.DriveInfo
+	drives := make(map[string]*storage.DriveInfo, len(n.SystemDrives))
+	for k, d := range n.SystemDrives {
+		if d.Name == name {
+			removed = d
+			continue
+		}
+		drives[k] = d
+	}
+	if removed == nil {
+		n.mu.Unlock()
+		return DriveEvent{}, false
+	}
+	n.SystemDrives = drives
+
+	adopted := n.findAdoptedByKey(removed.DriveKey.String())
+	if adopted != nil {
+		adopted.Missing = true
+	}
+	n.mu.Unlock()
+	return DriveEvent{Action: storage.UeventRemove, Name: name, Drive: removed, Adopted: adopted}, true
+}
+
+// findAdoptedByKey returns the adopted drive with the drive key, whether it is
+// free, a global spare or part of a pool. Callers hold n.mu.
+func (n *Nas) findAdoptedByKey(key string) *storage.AdoptedDrive {
+	if drive := n.adoptedByKey(key); drive != nil {
+		return drive
+	}
+	for _, pool := range *n.POOLS {
+		for _, drive := range append(pool.Members(), pool.DedicatedSpares()...) {
+			if drive.Key() == key {
+				return drive
+			}
+		}
+	}
+	return nil
+}
+
+// refreshHealthOnHotplug rechecks pool health as soon as a pool member comes or goes.
+func (s *Server) refreshHealthOnHotplug(ctx context.Context, event DriveEvent) {
+	if event.Adopted == nil {
+		return
+	}
+	s.Nas.mu.RLock()
+	poolID := event.Adopted.GetPoolID()
+	s.Nas.mu.RUnlock()
+	if poolID == "" {
+		return
+	}
+	s.RefreshHealth(ctx)
+}
+
+// collectSmartOnHotplug reads SMART data from a drive as soon as it is attached.
+func (s *Server) collectSmartOnHotplug(ctx context.Context, event DriveEvent) {
+	if event.Action != storage.UeventAdd {
+		return
+	}
+	s.recordSmart(ctx, event.Drive)
+}
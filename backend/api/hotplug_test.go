@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeUeventSource replays events and cancels the watch once they run out.
+type fakeUeventSource struct {
+	events []storage.Uevent
+	cancel context.CancelFunc
+	closed bool
+}
+
+func (f *fakeUeventSource) Receive(ctx context.Context) (storage.Uevent, error) {
+	if len(f.events) == 0 {
+		f.cancel()
+		return storage.Uevent{}, ctx.Err()
+	}
+	event := f.events[0]
+	f.events = f.events[1:]
+	if event.Action == "" {
+		return storage.Uevent{}, storage.ErrUeventParse
+	}
+	return event, nil
+}
+
+func (f *fakeUeventSource) Close() error {
+	f.closed = true
+	return nil
+}
+
+func diskEvent(action storage.UeventAction, name string) storage.Uevent {
+	return storage.Uevent{Action: action, DevPath: "/block/" + name, Subsystem: "block", DevName: name, DevType: "disk"}
+}
+
+func TestWatchDrives(t *testing.T) {
+	root := t.TempDir()
+	previous := storage.SysBlockPath
+	storage.SysBlockPath = root
+	defer func() { storage.SysBlockPath = previous }()
+	for file, value := range map[string]string{"size": "4194304", "device/serial": "HOTPLUG1"} {
+		path := filepath.Join(root, "sdx", file)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		Nas: &Nas{
+			POOLS:         &storage.Pools{},
+			SystemDrives:  make(map[string]*storage.DriveInfo),
+			AdoptedDrives: make(map[string]*storage.AdoptedDrive),
+			GlobalSpares:  make(map[string]*storage.AdoptedDrive),
+		},
+	}
+	// The drive was adopted before but absent when the server started
+	persisted := storage.AdoptedDrive{
+		Drive: &storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "HOTPLUG1"}, Uuid: "drive-1"},
+		Uuid:  "drive-1",
+		Role:  storage.RoleActive,
+	}
+	if err := s.Nas.ClaimDrive(s.Nas.getDriveByKey(persisted.Key()), persisted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adopted := s.Nas.AdoptedDrives["drive-1"]; adopted == nil || !adopted.Missing {
+		t.Fatalf("expected the absent drive to be loaded as missing, got %+v", adopted)
+	}
+
+	var seen []DriveEvent
+	s.SubscribeDrives(func(_ context.Context, event DriveEvent) {
+		missing := event.Adopted != nil && event.Adopted.Missing
+		seen = append(seen, event)
+		if event.Action == storage.UeventRemove && !missing {
+			t.Errorf("expected the adopted drive to be missing on remove")
+		}
+	})
+
+	partition := diskEvent(storage.UeventAdd, "sdx1")
+	partition.DevType = "partition"
+	source := &fakeUeventSource{cancel: cancel, events: []storage.Uevent{
+		diskEvent(storage.UeventAdd, "sdx"),
+		partition,
+		{}, // unparsable message
+		diskEvent(storage.UeventRemove, "sdx"),
+		diskEvent(storage.UeventRemove, "sdz"),
+	}}
+	s.WatchDrives(ctx, source)
+
+	if !source.closed {
+		t.Fatalf("expected the source to be closed")
+	}
+	if len(seen) != 2 || seen[0].Action != storage.UeventAdd || seen[1].Action != storage.UeventRemove {
+		t.Fatalf("expected an add and a remove event, got %+v", seen)
+	}
+	if len(s.Nas.SystemDrives) != 0 {
+		t.Fatalf("expected the removed drive to leave the system drives, got %v", s.Nas.SystemDrives)
+	}
+	adopted, ok := s.Nas.AdoptedDrives["drive-1"]
+	if !ok || !adopted.Missing || adopted.Drive.Name != "sdx" {
+		t.Fatalf("expected the drive to be marked missing again, got %+v", adopted)
+	}
+
+	s.HandleUevent(context.Background(), diskEvent(storage.UeventAdd, "sdx"))
+	if adopted.Missing || adopted.Drive.Uuid != "drive-1" || len(s.Nas.SystemDrives) != 1 {
+		t.Fatalf("expected the drive to be present again, got %+v", adopted)
+	}
+}
+
+func TestHotplugDuringDriveRequests(t *testing.T) {
+	root := t.TempDir()
+	previousSysBlock := storage.SysBlockPath
+	storage.SysBlockPath = root
+	defer func() { storage.SysBlockPath = previousSysBlock }()
+	for file, value := range map[string]string{"size": "4194304", "device/serial": "HOTPLUG1"} {
+		path := filepath.Join(root, "sdx", file)
+		_ = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := &Nas{
+		POOLS:         &storage.Pools{},
+		SystemDrives:  make(map[string]*storage.DriveInfo),
+		AdoptedDrives: make(map[string]*storage.AdoptedDrive),
+		GlobalSpares:  make(map[string]*storage.AdoptedDrive),
+	}
+	previousNas, previousServer := NAS, SERVER
+	NAS = n
+	SERVER = &Server{Nas: n, Jobs: jobs.NewManager(nil)}
+	defer func() { NAS, SERVER = previousNas, previousServer }()
+	persisted := storage.AdoptedDrive{
+		Drive: &storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "HOTPLUG1"}, Uuid: "drive-1"},
+		Uuid:  "drive-1",
+		Role:  storage.RoleActive,
+	}
+	if err := n.ClaimDrive(nil, persisted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			SERVER.HandleUevent(context.Background(), diskEvent(storage.UeventAdd, "sdx"))
+			SERVER.HandleUevent(context.Background(), diskEvent(storage.UeventRemove, "sdx"))
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		adopted, err := n.FindAdoptedDrive("drive-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = n.driveHealth(adopted)
+		_, _ = n.freeAdoptedDrives([]string{"drive-1"})
+		_, _ = n.AreDrivesAlreadyInPool([]string{"drive-1"})
+	}
+
+	if adopted, _ := n.FindAdoptedDrive("drive-1"); !adopted.Missing {
+		t.Fatalf("expected the drive to be missing after the last remove, got %+v", adopted)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IoInterval controls how often drive I/O counters are sampled.
+var IoInterval = 5 * time.Second
+
+// IoHistorySize is how many I/O samples are kept per drive.
+var IoHistorySize = 120
+
+// SampleIo samples the I/O counters of the system drives on every interval until ctx is done.
+func (s *Server) SampleIo(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunIoSample(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunIoSample records an I/O sample for every system drive and attaches the
+// latest one to the drive.
+func (s *Server) RunIoSample(now time.Time) {
+	drives := s.Nas.SystemDriveList()
+	names := make([]string, 0, len(drives))
+	for _, drive := range drives {
+		names = append(names, drive.Name)
+	}
+	s.Io.Sample(names, now)
+	s.attachIo()
+}
+
+// attachIo sets the latest I/O sample on every system drive.
+func (s *Server) attachIo() {
+	s.Nas.mu.Lock()
+	defer s.Nas.mu.Unlock()
+	for _, drive := range s.Nas.SystemDrives {
+		if latest, ok := s.Io.Latest(drive.Name); ok {
+			drive.Io = &latest
+		}
+	}
+}
+
+// getDriveIo returns the recent I/O samples of an adopted drive, oldest first.
+func getDriveIo(c *gin.Context) {
+	drive, err := NAS.FindAdoptedDrive(c.Param("uuid"))
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	NAS.mu.RLock()
+	name := drive.Drive.Name
+	NAS.mu.RUnlock()
+	SuccessResponse(c, gin.H{
+		"name":     name,
+		"interval": IoInterval.Seconds(),
+		"samples":  SERVER.Io.History(name),
+	})
+}
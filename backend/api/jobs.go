@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"goNAS/jobs"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobError writes a job-related error response with the appropriate status.
+func jobError(err error, c *gin.Context) {
+	message := gin.H{"error": err.Error()}
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, message)
+	case errors.Is(err, jobs.ErrJobTargetBusy):
+		c.JSON(http.StatusConflict, message)
+	default:
+		internalServerError(c, err)
+	}
+}
+
+// listJobs returns all known jobs, newest first.
+func listJobs(c *gin.Context) {
+	list := SERVER.Jobs.List()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt > list[j].CreatedAt
+	})
+	SuccessResponse(c, list)
+}
+
+// getJob returns a job by ID.
+func getJob(c *gin.Context) {
+	job, err := SERVER.Jobs.Get(c.Param("id"))
+	if err != nil {
+		jobError(err, c)
+		return
+	}
+	SuccessResponse(c, job)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/helper"
+	"goNAS/jobs"
+	"goNAS/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockPool opens the LUKS device of an encrypted pool, mounts it and persists its status.
+func (n *Nas) UnlockPool(pool *storage.Pool, key helper.LuksKey, c context.Context) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	if err := pool.Unlock(key); err != nil {
+		return err
+	}
+	if err := SERVER.Db.PatchPoolCapacity(c, pool); err != nil {
+		return err
+	}
+	return SERVER.Db.PatchPoolStatus(c, pool)
+}
+
+// LockPool unmounts an encrypted pool, closes its LUKS device and persists the locked status.
+func (n *Nas) LockPool(pool *storage.Pool, c context.Context) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	if err := pool.Lock(); err != nil {
+		return err
+	}
+	return SERVER.Db.PatchPoolStatus(c, pool)
+}
+
+// unlockPool unlocks an encrypted pool with a passphrase or keyfile.
+func unlockPool(c *gin.Context) {
+	var req helper.LuksKey
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	if err = NAS.UnlockPool(pool, req, c); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
+
+// lockPool locks an encrypted pool.
+func lockPool(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = NAS.LockPool(pool, c); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
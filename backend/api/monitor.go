@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"goNAS/storage"
+	"log"
+	"time"
+)
+
+// HealthInterval controls how often pool health is refreshed from mdstat and sysfs.
+var HealthInterval = 30 * time.Second
+
+// MonitorHealth refreshes pool health on every interval until ctx is done.
+func (s *Server) MonitorHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RefreshHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcilePools assembles and mounts every built pool after a restart and
+// persists the resulting status.
+func (s *Server) ReconcilePools(ctx context.Context) {
+	for _, pool := range s.Nas.PoolList() {
+		if err := pool.Reconcile(); err != nil {
+			log.Printf("Pool %s failed to come up: %v", pool.Uuid, err)
+		}
+		if err := s.Db.PatchPoolStatus(ctx, pool); err != nil {
+			log.Println("Error persisting pool status:", err)
+		}
+	}
+}
+
+// RefreshHealth updates the status and member states of every built pool,
+// persists status changes and hands global spares to degraded pools.
+func (s *Server) RefreshHealth(ctx context.Context) {
+	arrays, err := storage.ReadMdstat()
+	if err != nil {
+		log.Println("Error reading mdstat:", err)
+		return
+	}
+	for _, pool := range s.Nas.PoolList() {
+		// Pools that were never built have no array to inspect, and pools held
+		// offline would lose the marker that keeps them down across restarts
+		if pool.MountPoint == "" || pool.HeldOffline() {
+			continue
+		}
+		previous := pool.Status
+		changed := pool.ApplyHealth(pool.CheckHealth(arrays))
+		s.PromoteSpares(ctx, pool)
+		if changed {
+			log.Printf("Pool %s status changed: %s -> %s %s", pool.Uuid, previous, pool.Status, pool.StatusReason)
+			if err = s.Db.PatchPoolStatus(ctx, pool); err != nil {
+				log.Println("Error persisting pool status:", err)
+			}
+		}
+		s.HandOffGlobalSpare(ctx, pool)
+	}
+}
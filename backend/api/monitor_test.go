@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"goNAS/storage"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefreshHealthSkipsPoolsHeldOffline(t *testing.T) {
+	mdstat := filepath.Join(t.TempDir(), "mdstat")
+	if err := os.WriteFile(mdstat, []byte("Personalities : [raid1]\nunused devices: <none>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := storage.MdstatPath
+	storage.MdstatPath = mdstat
+	defer func() { storage.MdstatPath = previous }()
+
+	pools := &storage.Pools{}
+	for _, reason := range []string{storage.ReasonTakenOffline, storage.ReasonLocked} {
+		pool, _ := storage.NewPool("held", &storage.Raid{Level: 1}, "ext4", &storage.DriveInfo{Name: "sda", Uuid: "a"})
+		pool.Name += reason[:4]
+		pool.MountPoint = "/mnt/" + pool.Uuid
+		pool.Status = storage.Offline
+		pool.StatusReason = reason
+		if err := pools.AddPool(pool); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No database is configured: persisting a status change would panic
+	s := &Server{Nas: &Nas{POOLS: pools}}
+	s.RefreshHealth(context.Background())
+
+	for _, pool := range *pools {
+		if !pool.HeldOffline() {
+			t.Fatalf("expected the pool to stay held offline, got %s %q", pool.Status, pool.StatusReason)
+		}
+	}
+}
@@ -9,13 +9,41 @@ func Register(r *gin.Engine) {
 
 	RegisterDrives(v1)
 	RegisterPools(v1)
+	RegisterJobs(v1)
+	RegisterSystem(v1)
 }
 
 // RegisterPools registers pool-related endpoints on the router group.
 func RegisterPools(r *gin.RouterGroup) {
 	r.GET("/pools", listPools)
+	r.POST("/pools/preview", previewPool)
 	r.GET("/pool/:uuid", getPool)
 	r.POST("/pool/:uuid/build", buildPool)
+	r.POST("/pool/:uuid/offline", offlinePool)
+	r.POST("/pool/:uuid/online", onlinePool)
+	r.POST("/pool/:uuid/unlock", unlockPool)
+	r.POST("/pool/:uuid/lock", lockPool)
+	r.POST("/pool/:uuid/replace", replacePoolDrive)
+	r.POST("/pool/:uuid/drives", addPoolDrives)
+	r.POST("/pool/:uuid/level", migratePoolLevel)
+	r.POST("/pool/:uuid/spares", addPoolSpares)
+	r.DELETE("/pool/:uuid/spares/:drive", removePoolSpare)
+	r.POST("/pool/:uuid/scrub", scrubPool)
+	r.POST("/pool/:uuid/scrub/cancel", cancelPoolScrub)
+	r.GET("/pool/:uuid/scrubs", listPoolScrubs)
+	r.POST("/pool/:uuid/fsck", fsckPool)
+	r.GET("/pool/:uuid/fscks", listPoolFscks)
+	r.GET("/pool/:uuid/volumes", listPoolVolumes)
+	r.POST("/pool/:uuid/volumes", createPoolVolume)
+	r.PATCH("/pool/:uuid/volumes/:volume", resizePoolVolume)
+	r.DELETE("/pool/:uuid/volumes/:volume", deletePoolVolume)
+	r.GET("/pool/:uuid/subvolumes", listPoolSubvolumes)
+	r.POST("/pool/:uuid/subvolumes", createPoolSubvolume)
+	r.PATCH("/pool/:uuid/subvolumes/:subvolume", patchPoolSubvolume)
+	r.DELETE("/pool/:uuid/subvolumes/:subvolume", deletePoolSubvolume)
+	r.POST("/pool/:uuid/subvolumes/:subvolume/snapshots", snapshotPoolSubvolume)
+	r.GET("/pool/:uuid/snapshots", listPoolSnapshots)
+	r.DELETE("/pool/:uuid/snapshots/:snapshot", deletePoolSnapshot)
 	r.POST("/pool", createPool)
 	r.PATCH("/pool/:uuid", updatePool)
 	r.DELETE("/pool/:uuid", deletePool)
@@ -30,6 +58,27 @@ func RegisterDrives(r *gin.RouterGroup) {
 		listDrives(c, true)
 	})
 	r.GET("/drives/adopted", listAdoptedDrives)
+	r.DELETE("/drives/adopted/:uuid", unadoptDrive)
 
 	r.POST("/drives/adopt/:key", adoptDrive)
+	r.POST("/drives/spares/:uuid", addGlobalSpare)
+	r.DELETE("/drives/spares/:uuid", removeGlobalSpare)
+	r.POST("/drives/:uuid/wipe/prepare", prepareDriveWipe)
+	r.POST("/drives/:uuid/wipe", wipeDrive)
+	r.GET("/drives/:uuid/smart", getDriveSmart)
+	r.GET("/drives/:uuid/io", getDriveIo)
+}
+
+// RegisterJobs registers background job endpoints on the router group.
+func RegisterJobs(r *gin.RouterGroup) {
+	r.GET("/jobs", listJobs)
+	r.GET("/jobs/:id", getJob)
+}
+
+// RegisterSystem registers endpoints for host configuration managed by goNAS.
+func RegisterSystem(r *gin.RouterGroup) {
+	r.GET("/system/fstab", getFstab)
+	r.POST("/system/fstab", writeFstab)
+	r.GET("/system/mdadm", getMdadmConf)
+	r.POST("/system/mdadm", writeMdadmConf)
 }
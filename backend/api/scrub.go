@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScrubCheckInterval controls how often pool scrub schedules are checked.
+var ScrubCheckInterval = time.Hour
+
+// StartScrub starts a check or repair of the pool array, records it in the
+// scrub history and tracks it as a job until md finishes.
+func (n *Nas) StartScrub(pool *storage.Pool, action storage.ScrubAction, c context.Context) (jobs.Job, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.Job{}, jobs.ErrJobTargetBusy
+	}
+	if err := pool.StartScrub(action); err != nil {
+		return jobs.Job{}, err
+	}
+
+	scrub := storage.NewScrub(pool.Uuid, action)
+	if err := SERVER.Db.SaveScrub(c, scrub); err != nil {
+		return jobs.Job{}, err
+	}
+
+	return SERVER.Jobs.Start("scrub", pool.Uuid, func(ctx context.Context, r *jobs.Reporter) error {
+		mismatches, err := pool.FinishScrub(ctx, progress(r))
+		scrub.Finish(mismatches, err, r.Canceled())
+		// The job context may already be canceled; the result must still be recorded
+		if saveErr := SERVER.Db.SaveScrub(context.Background(), scrub); saveErr != nil {
+			log.Println("Error persisting scrub:", saveErr)
+		}
+		return err
+	})
+}
+
+// CancelScrub stops the running scrub of the pool and cancels its job.
+func (n *Nas) CancelScrub(pool *storage.Pool) error {
+	job, ok := SERVER.Jobs.Running(pool.Uuid)
+	if !ok || job.Kind != "scrub" {
+		return storage.ErrNoScrubRunning
+	}
+	if err := pool.CancelScrub(); err != nil {
+		return err
+	}
+	return SERVER.Jobs.Cancel(job.ID)
+}
+
+// ScheduleScrubs starts scheduled scrubs of healthy pools on every interval until ctx is done.
+func (s *Server) ScheduleScrubs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunDueScrubs(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDueScrubs starts a check of every healthy pool whose scrub schedule has elapsed.
+func (s *Server) RunDueScrubs(ctx context.Context, now time.Time) {
+	for _, pool := range s.Nas.PoolList() {
+		if pool.Status != storage.Healthy || !pool.IsRedundant() || s.Jobs.Busy(pool.Uuid) {
+			continue
+		}
+		last, _, err := s.Db.QueryLastScrub(ctx, pool.Uuid)
+		if err != nil {
+			log.Println("Error reading scrub history:", err)
+			continue
+		}
+		if !pool.ScrubDue(last.StartedAt, now) {
+			continue
+		}
+		if _, err = s.Nas.StartScrub(pool, storage.ScrubCheck, ctx); err != nil {
+			log.Printf("Pool %s scheduled scrub failed to start: %v", pool.Uuid, err)
+		}
+	}
+}
+
+// scrubPool starts a check or repair of a pool array.
+func scrubPool(c *gin.Context) {
+	var req struct {
+		Action string `json:"action"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	// The body is optional; an empty request runs a check
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+			return
+		}
+	}
+	action, err := storage.ParseScrubAction(req.Action)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	job, err := NAS.StartScrub(pool, action, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
+
+// cancelPoolScrub stops the running scrub of a pool.
+func cancelPoolScrub(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = NAS.CancelScrub(pool); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
+
+// listPoolScrubs returns the scrub history of a pool, newest first.
+func listPoolScrubs(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if _, err := NAS.POOLS.GetPool(uuid); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	scrubs, err := SERVER.Db.QueryPoolScrubs(c, uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, scrubs)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"goNAS/storage"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SmartInterval controls how often SMART data is read from the system drives.
+var SmartInterval = time.Hour
+
+// SmartRetention is how long SMART readings are kept.
+var SmartRetention = 90 * 24 * time.Hour
+
+// CollectSmart reads SMART data on every interval until ctx is done.
+func (s *Server) CollectSmart(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunSmartCollection(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunSmartCollection updates the health of every system drive, records the
+// readings of adopted drives and prunes readings past the retention period.
+func (s *Server) RunSmartCollection(ctx context.Context, now time.Time) {
+	for _, drive := range s.Nas.SystemDriveList() {
+		s.recordSmart(ctx, drive)
+	}
+	before := now.Add(-SmartRetention).UTC().Format(time.RFC3339Nano)
+	if err := s.Db.PruneSmart(ctx, before); err != nil {
+		log.Println("Error pruning SMART history:", err)
+	}
+}
+
+// recordSmart updates the health of a drive and records the reading if the drive is adopted.
+func (s *Server) recordSmart(ctx context.Context, drive *storage.DriveInfo) {
+	report, err := storage.ReadSmart(drive)
+	if err != nil {
+		if !errors.Is(err, storage.ErrSmartUnavailable) {
+			log.Printf("Drive %s SMART read failed: %v", drive.Path, err)
+		}
+		s.Nas.setHealth(drive, storage.DriveHealthUnknown)
+		return
+	}
+	if previous := s.Nas.setHealth(drive, report.Health); previous != report.Health && previous != storage.DriveHealthUnknown {
+		log.Printf("Drive %s health changed: %s -> %s", drive.Path, previous, report.Health)
+	}
+
+	// Only adopted drives have a stable UUID to keep history under
+	if drive.Uuid == "" {
+		return
+	}
+	if err = s.Db.InsertSmart(ctx, &report); err != nil {
+		log.Println("Error persisting SMART reading:", err)
+	}
+}
+
+// setHealth updates the health of a drive and returns the previous one.
+func (n *Nas) setHealth(drive *storage.DriveInfo, health storage.DriveHealth) storage.DriveHealth {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	previous := drive.Health
+	drive.Health = health
+	return previous
+}
+
+// driveHealth returns the health of an adopted drive as last set by setHealth.
+func (n *Nas) driveHealth(adopted *storage.AdoptedDrive) storage.DriveHealth {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return adopted.Drive.Health
+}
+
+// FindAdoptedDrive returns the adopted drive with the UUID, whether it is free,
+// a global spare or part of a pool.
+func (n *Nas) FindAdoptedDrive(driveUuid string) (*storage.AdoptedDrive, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if drive, ok := n.AdoptedDrives[driveUuid]; ok {
+		return drive, nil
+	}
+	drive, ok := n.GlobalSpares[driveUuid]
+	if ok {
+		return drive, nil
+	}
+	for _, pool := range *n.POOLS {
+		if drive, ok = pool.Member(driveUuid); ok {
+			return drive, nil
+		}
+	}
+	return nil, storage.ErrDriveNotFound
+}
+
+// getDriveSmart returns the current health and SMART history of an adopted drive, newest first.
+func getDriveSmart(c *gin.Context) {
+	uuid := c.Param("uuid")
+	drive, err := NAS.FindAdoptedDrive(uuid)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+
+	history, err := SERVER.Db.QueryDriveSmart(c, uuid, 0)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"health":  NAS.driveHealth(drive),
+		"history": history,
+	})
+}
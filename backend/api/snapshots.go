@@ -0,0 +1,281 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SnapshotCheckInterval controls how often subvolume snapshot policies are enforced.
+var SnapshotCheckInterval = 15 * time.Minute
+
+// CreateSubvolume creates a btrfs subvolume in a pool and persists it.
+func (n *Nas) CreateSubvolume(pool *storage.Pool, name string, policy storage.SnapshotPolicy, c context.Context) (*storage.Subvolume, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	subvolume, err := pool.CreateSubvolume(name, policy)
+	if err != nil {
+		return nil, err
+	}
+	return subvolume, SERVER.Db.InsertSubvolume(c, subvolume)
+}
+
+// SetSnapshotPolicy replaces the retention policy of a subvolume and persists it.
+func (n *Nas) SetSnapshotPolicy(pool *storage.Pool, subvolumeUuid string, policy storage.SnapshotPolicy, c context.Context) (*storage.Subvolume, error) {
+	subvolume, err := pool.SetSubvolumePolicy(subvolumeUuid, policy)
+	if err != nil {
+		return nil, err
+	}
+	return subvolume, SERVER.Db.PatchSubvolumePolicy(c, subvolume)
+}
+
+// DeleteSubvolume deletes the snapshots of a subvolume, then the subvolume itself.
+func (n *Nas) DeleteSubvolume(pool *storage.Pool, subvolumeUuid string, c context.Context) (*storage.Subvolume, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	if _, err := pool.GetSubvolume(subvolumeUuid); err != nil {
+		return nil, err
+	}
+	snapshots, err := SERVER.Db.QuerySnapshots(c, pool.Uuid, subvolumeUuid)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if err = n.removeSnapshot(pool, &snapshots[i], c); err != nil {
+			return nil, err
+		}
+	}
+
+	subvolume, err := pool.DeleteSubvolume(subvolumeUuid)
+	if err != nil {
+		return nil, err
+	}
+	return subvolume, SERVER.Db.DeleteSubvolume(c, subvolume.Uuid)
+}
+
+// CreateSnapshot takes a tagged read-only snapshot of a subvolume and records it.
+func (n *Nas) CreateSnapshot(pool *storage.Pool, subvolumeUuid string, tag string, c context.Context) (*storage.Snapshot, error) {
+	subvolume, err := pool.GetSubvolume(subvolumeUuid)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := pool.CreateSnapshot(subvolume, tag, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, SERVER.Db.InsertSnapshot(c, snapshot)
+}
+
+// DeleteSnapshot deletes a snapshot of a pool by UUID.
+func (n *Nas) DeleteSnapshot(pool *storage.Pool, snapshotUuid string, c context.Context) (*storage.Snapshot, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	snapshot, err := SERVER.Db.QuerySnapshot(c, pool.Uuid, snapshotUuid)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, n.removeSnapshot(pool, &snapshot, c)
+}
+
+// removeSnapshot deletes a snapshot from disk and from the database.
+func (n *Nas) removeSnapshot(pool *storage.Pool, snapshot *storage.Snapshot, c context.Context) error {
+	if err := pool.DeleteSnapshot(snapshot); err != nil {
+		return err
+	}
+	return SERVER.Db.DeleteSnapshot(c, snapshot.Uuid)
+}
+
+// ScheduleSnapshots enforces subvolume snapshot policies on every interval until ctx is done.
+func (s *Server) ScheduleSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.RunSnapshotPolicies(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunSnapshotPolicies takes the due snapshots of every subvolume and prunes
+// those beyond the retention counts of its policy.
+func (s *Server) RunSnapshotPolicies(ctx context.Context, now time.Time) {
+	for _, pool := range s.Nas.PoolList() {
+		if !pool.SupportsSubvolumes() || pool.Status == storage.Offline || s.Jobs.Busy(pool.Uuid) {
+			continue
+		}
+		for _, subvolume := range pool.CopySubvolumes() {
+			snapshots, err := s.Db.QuerySnapshots(ctx, pool.Uuid, subvolume.Uuid)
+			if err != nil {
+				log.Println("Error reading snapshots:", err)
+				continue
+			}
+			for _, tag := range subvolume.Policy.Due(snapshots, now) {
+				snapshot, err := pool.CreateSnapshot(&subvolume, tag, now)
+				if err != nil {
+					log.Printf("Subvolume %s %s snapshot failed: %v", subvolume.Name, tag, err)
+					continue
+				}
+				if err = s.Db.InsertSnapshot(ctx, snapshot); err != nil {
+					log.Println("Error persisting snapshot:", err)
+					continue
+				}
+				snapshots = append(snapshots, *snapshot)
+			}
+			for _, snapshot := range subvolume.Policy.Expired(snapshots) {
+				if err = s.Nas.removeSnapshot(pool, &snapshot, ctx); err != nil {
+					log.Printf("Subvolume %s snapshot %s failed to prune: %v", subvolume.Name, snapshot.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// listPoolSubvolumes returns the subvolumes of a pool.
+func listPoolSubvolumes(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	if !pool.SupportsSubvolumes() {
+		NAS.poolError(storage.ErrSubvolumeUnsupported, c)
+		return
+	}
+	SuccessResponse(c, pool.CopySubvolumes())
+}
+
+// createPoolSubvolume creates a subvolume in a pool.
+func createPoolSubvolume(c *gin.Context) {
+	var req struct {
+		Name   string                 `json:"name" binding:"required"`
+		Policy storage.SnapshotPolicy `json:"policy"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	subvolume, err := NAS.CreateSubvolume(pool, req.Name, req.Policy, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, subvolume)
+}
+
+// patchPoolSubvolume replaces the snapshot policy of a subvolume.
+func patchPoolSubvolume(c *gin.Context) {
+	var req struct {
+		Policy storage.SnapshotPolicy `json:"policy"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	subvolume, err := NAS.SetSnapshotPolicy(pool, c.Param("subvolume"), req.Policy, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, subvolume)
+}
+
+// deletePoolSubvolume removes a subvolume and its snapshots from a pool.
+func deletePoolSubvolume(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	subvolume, err := NAS.DeleteSubvolume(pool, c.Param("subvolume"), c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, subvolume)
+}
+
+// snapshotPoolSubvolume takes a manual snapshot of a subvolume.
+func snapshotPoolSubvolume(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	snapshot, err := NAS.CreateSnapshot(pool, c.Param("subvolume"), storage.TagManual, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, snapshot)
+}
+
+// listPoolSnapshots returns the snapshots of a pool, newest first, optionally
+// filtered by the subvolume query parameter.
+func listPoolSnapshots(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	if !pool.SupportsSubvolumes() {
+		NAS.poolError(storage.ErrSubvolumeUnsupported, c)
+		return
+	}
+
+	snapshots, err := SERVER.Db.QuerySnapshots(c, uuid, c.Query("subvolume"))
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, snapshots)
+}
+
+// deletePoolSnapshot removes a snapshot from a pool.
+func deletePoolSnapshot(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	snapshot, err := NAS.DeleteSnapshot(pool, c.Param("snapshot"), c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, snapshot)
+}
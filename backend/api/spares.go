@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/DB"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddPoolSpares attaches free adopted drives to a pool as dedicated hot spares.
+func (n *Nas) AddPoolSpares(pool *storage.Pool, driveUuids []string, c context.Context) error {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return jobs.ErrJobTargetBusy
+	}
+	drives, err := n.freeAdoptedDrives(driveUuids)
+	if err != nil {
+		return err
+	}
+	if err = pool.AttachSpares(drives...); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	for _, id := range driveUuids {
+		delete(n.AdoptedDrives, id)
+	}
+	n.mu.Unlock()
+	spare := storage.RoleSpare
+	for _, id := range driveUuids {
+		if err = SERVER.Db.PatchDrive(c, id, DB.DrivePatch{PoolID: &pool.Uuid, Role: &spare}); err != nil {
+			return err
+		}
+	}
+	return n.persistPartitions(pool, c)
+}
+
+// RemovePoolSpare detaches a dedicated spare from a pool and returns it to the free adopted set.
+func (n *Nas) RemovePoolSpare(pool *storage.Pool, driveUuid string, c context.Context) (*storage.AdoptedDrive, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	removed, err := pool.DetachSpare(driveUuid)
+	if err != nil {
+		return nil, err
+	}
+	if err = n.releaseDrive(removed, c); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// releaseDrive returns a drive to the free adopted set as an active drive and persists it.
+func (n *Nas) releaseDrive(drive *storage.AdoptedDrive, c context.Context) error {
+	n.mu.Lock()
+	drive.SetPoolID("")
+	drive.SetRole(storage.RoleActive)
+	drive.SetState("")
+	drive.PartUuid = ""
+	n.AdoptedDrives[drive.GetUuid()] = drive
+	n.mu.Unlock()
+
+	released := ""
+	return SERVER.Db.PatchDrive(c, drive.GetUuid(), DB.DrivePatch{PoolID: &released, Role: &storage.RoleActive, PartUuid: &released})
+}
+
+// AddGlobalSpare moves a free adopted drive into the global spare set.
+func (n *Nas) AddGlobalSpare(driveUuid string, c context.Context) (*storage.AdoptedDrive, error) {
+	n.mu.RLock()
+	drive, ok := n.AdoptedDrives[driveUuid]
+	available := ok && driveAvailable(drive)
+	n.mu.RUnlock()
+	if !available {
+		return nil, storage.ErrDriveNotFoundOrInUse
+	}
+	if err := SERVER.Db.PatchDrive(c, driveUuid, DB.DrivePatch{Role: &storage.RoleSpare}); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	drive.SetRole(storage.RoleSpare)
+	delete(n.AdoptedDrives, driveUuid)
+	n.GlobalSpares[driveUuid] = drive
+	n.mu.Unlock()
+	return drive, nil
+}
+
+// RemoveGlobalSpare returns a global spare to the free adopted set.
+func (n *Nas) RemoveGlobalSpare(driveUuid string, c context.Context) (*storage.AdoptedDrive, error) {
+	n.mu.Lock()
+	drive, ok := n.GlobalSpares[driveUuid]
+	delete(n.GlobalSpares, driveUuid)
+	n.mu.Unlock()
+	if !ok {
+		return nil, storage.ErrDriveNotSpare
+	}
+	if err := n.releaseDrive(drive, c); err != nil {
+		return nil, err
+	}
+	return drive, nil
+}
+
+// takeGlobalSpare removes and returns the first available global spare large
+// enough for the pool, with its system drive.
+func (n *Nas) takeGlobalSpare(pool *storage.Pool) (*storage.AdoptedDrive, *storage.DriveInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, spare := range n.GlobalSpares {
+		if driveAvailable(spare) && pool.SpareFits(spare.Drive) {
+			delete(n.GlobalSpares, id)
+			return spare, spare.Drive
+		}
+	}
+	return nil, nil
+}
+
+// PromoteSpares persists dedicated spares that md pulled into the pool as active members.
+func (s *Server) PromoteSpares(ctx context.Context, pool *storage.Pool) {
+	for _, promoted := range pool.PromoteSpares() {
+		log.Printf("Pool %s spare %s took over a failed member", pool.Uuid, promoted.GetUuid())
+		if err := s.Db.PatchDrive(ctx, promoted.GetUuid(), DB.DrivePatch{Role: &storage.RoleActive}); err != nil {
+			log.Println("Error persisting promoted spare:", err)
+		}
+	}
+}
+
+// HandOffGlobalSpare attaches a global spare to a degraded pool that has none
+// of its own and tracks the rebuild as a job.
+func (s *Server) HandOffGlobalSpare(ctx context.Context, pool *storage.Pool) {
+	if !pool.NeedsSpare() || s.Jobs.Busy(pool.Uuid) {
+		return
+	}
+	spare, drive := s.Nas.takeGlobalSpare(pool)
+	if spare == nil {
+		return
+	}
+	if err := pool.AttachSpares(drive); err != nil {
+		log.Printf("Pool %s failed to take global spare %s: %v", pool.Uuid, spare.GetUuid(), err)
+		s.Nas.mu.Lock()
+		s.Nas.GlobalSpares[spare.GetUuid()] = spare
+		s.Nas.mu.Unlock()
+		return
+	}
+	log.Printf("Pool %s took global spare %s", pool.Uuid, spare.GetUuid())
+	if err := s.Db.PatchDrive(ctx, spare.GetUuid(), DB.DrivePatch{PoolID: &pool.Uuid}); err != nil {
+		log.Println("Error persisting spare ownership:", err)
+	}
+	if err := s.Nas.persistPartitions(pool, ctx); err != nil {
+		log.Println("Error persisting spare partition:", err)
+	}
+	if _, err := s.Nas.StartRebuild(pool); err != nil {
+		log.Println("Error starting rebuild:", err)
+	}
+}
+
+// addPoolSpares attaches adopted drives to a pool as dedicated hot spares.
+func addPoolSpares(c *gin.Context) {
+	var req struct {
+		Drives []string `json:"drives" binding:"required"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	if err = NAS.AddPoolSpares(pool, req.Drives, c); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
+
+// removePoolSpare detaches a dedicated spare from a pool.
+func removePoolSpare(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	removed, err := NAS.RemovePoolSpare(pool, c.Param("drive"), c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, removed)
+}
+
+// addGlobalSpare marks an adopted drive as a global hot spare.
+func addGlobalSpare(c *gin.Context) {
+	spare, err := NAS.AddGlobalSpare(c.Param("uuid"), c)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, spare)
+}
+
+// removeGlobalSpare returns a global hot spare to the adopted drives.
+func removeGlobalSpare(c *gin.Context) {
+	drive, err := NAS.RemoveGlobalSpare(c.Param("uuid"), c)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, drive)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"testing"
+)
+
+func TestBusyOrMissingDrivesAreNotSpares(t *testing.T) {
+	manager := jobs.NewManager(nil)
+	defer manager.Stop()
+	previous := SERVER
+	SERVER = &Server{Jobs: manager}
+	defer func() { SERVER = previous }()
+
+	wiping := storage.NewAdoptedDrive(&storage.DriveInfo{Name: "sda", DriveKey: storage.DriveKey{Kind: "serial", Value: "WIPE1"}})
+	missing := storage.NewAdoptedDrive(&storage.DriveInfo{DriveKey: storage.DriveKey{Kind: "serial", Value: "GONE1"}})
+	missing.Missing = true
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := manager.Start("wipe", wiping.GetUuid(), func(ctx context.Context, r *jobs.Reporter) error {
+		<-release
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n := &Nas{
+		POOLS:         &storage.Pools{},
+		AdoptedDrives: map[string]*storage.AdoptedDrive{wiping.GetUuid(): wiping, missing.GetUuid(): missing},
+		GlobalSpares:  map[string]*storage.AdoptedDrive{wiping.GetUuid(): wiping, missing.GetUuid(): missing},
+	}
+	for _, id := range []string{wiping.GetUuid(), missing.GetUuid()} {
+		if _, err := n.AddGlobalSpare(id, context.Background()); !errors.Is(err, storage.ErrDriveNotFoundOrInUse) {
+			t.Fatalf("expected ErrDriveNotFoundOrInUse, got %v", err)
+		}
+		if _, err := n.freeAdoptedDrives([]string{id}); !errors.Is(err, storage.ErrDriveNotFoundOrInUse) {
+			t.Fatalf("expected ErrDriveNotFoundOrInUse, got %v", err)
+		}
+	}
+
+	pool, _ := storage.NewPool("media", &storage.Raid{Level: 1}, "ext4")
+	if spare, _ := n.takeGlobalSpare(pool); spare != nil {
+		t.Fatalf("expected no spare to be handed off, got %s", spare.GetUuid())
+	}
+	if len(n.GlobalSpares) != 2 {
+		t.Fatalf("expected both spares to stay in the global set, got %d", len(n.GlobalSpares))
+	}
+}
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"goNAS/DB"
 	"goNAS/helper"
+	"goNAS/jobs"
 	"goNAS/storage"
 	"log"
 	"net/http"
@@ -40,11 +42,72 @@ func (n *Nas) poolError(err error, c *gin.Context) {
 		c.JSON(http.StatusBadRequest, message)
 	case errors.Is(err, storage.ErrPoolAlreadyExists):
 		c.JSON(http.StatusConflict, message)
-	case errors.Is(err, storage.ErrPoolNotOffline):
+	case errors.Is(err, storage.ErrPoolNotOffline),
+		errors.Is(err, storage.ErrPoolHasVolumes):
 		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrPoolNotBuilt):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrNoRedundancy):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrDriveNotInPool):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrGrowUnsupported):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrMigrationUnsupported):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrDriveNotSpare):
+		c.JSON(http.StatusNotFound, message)
+	case errors.Is(err, storage.ErrSpareTooSmall):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrVolumeNotFound):
+		c.JSON(http.StatusNotFound, message)
+	case errors.Is(err, storage.ErrVolumeExists),
+		errors.Is(err, storage.ErrPoolAlreadyBuilt):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrLvmUnsupported),
+		errors.Is(err, storage.ErrVolumeSize),
+		errors.Is(err, storage.ErrVolumeShrink):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrSubvolumeNotFound),
+		errors.Is(err, storage.ErrSnapshotNotFound):
+		c.JSON(http.StatusNotFound, message)
+	case errors.Is(err, storage.ErrSubvolumeExists):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrSubvolumeUnsupported),
+		errors.Is(err, storage.ErrInvalidSnapshotPolicy):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrEncryptionUnsupported),
+		errors.Is(err, storage.ErrPoolNotEncrypted),
+		errors.Is(err, helper.ErrLuksKeyRequired):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrPartitionUnsupported),
+		errors.Is(err, helper.ErrPartitionTooSmall):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrPoolLocked):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, helper.ErrLuksBadKey):
+		c.JSON(http.StatusForbidden, message)
+	case errors.Is(err, storage.ErrInvalidMountOption),
+		errors.Is(err, storage.ErrMountOptionsUnsupported):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrScrubUnsupported),
+		errors.Is(err, storage.ErrInvalidScrubAction),
+		errors.Is(err, storage.ErrInvalidScrubSchedule):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrInvalidFsckMode),
+		errors.Is(err, storage.ErrFsckUnsupported):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrPoolMounted):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrNoScrubRunning),
+		errors.Is(err, storage.ErrPoolSyncing):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrDuplicateDriveKey):
+		c.JSON(http.StatusBadRequest, message)
 	case errors.Is(err, storage.ErrPoolFormatRequired):
 		c.JSON(http.StatusBadRequest, message)
-	case errors.Is(err, storage.ErrInvalidPoolType):
+	case errors.Is(err, storage.ErrInvalidPoolType),
+		errors.Is(err, storage.ErrBtrfsProfile):
 		c.JSON(http.StatusBadRequest, message)
 	case errors.Is(err, storage.ErrUnsupportedFormat):
 		c.JSON(http.StatusBadRequest, message)
@@ -52,6 +115,8 @@ func (n *Nas) poolError(err error, c *gin.Context) {
 		c.JSON(http.StatusBadRequest, message)
 	case errors.Is(err, storage.ErrInvalidRequestBody):
 		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, jobs.ErrJobTargetBusy):
+		c.JSON(http.StatusConflict, message)
 	case errors.Is(err, helper.ErrRaid0RequiresDrives),
 		errors.Is(err, helper.ErrRaid1RequiresDrives),
 		errors.Is(err, helper.ErrRaid5RequiresDrives),
@@ -79,7 +144,23 @@ func (n *Nas) poolError(err error, c *gin.Context) {
 		c.JSON(http.StatusNotFound, message)
 	case errors.Is(err, storage.ErrPoolDeleteUnmount),
 		errors.Is(err, storage.ErrPoolDeleteRmdir),
-		errors.Is(err, storage.ErrPoolDeleteRemove),
+		errors.Is(err, storage.ErrPoolAssemble),
+		errors.Is(err, storage.ErrDriveReplace),
+		errors.Is(err, storage.ErrPoolGrow),
+		errors.Is(err, storage.ErrPoolMigrate),
+		errors.Is(err, storage.ErrPoolSpare),
+		errors.Is(err, storage.ErrPoolScrub),
+		errors.Is(err, storage.ErrFsckFailed),
+		errors.Is(err, storage.ErrPoolVolume),
+		errors.Is(err, helper.ErrLvmCommand),
+		errors.Is(err, helper.ErrLvmOutputParse),
+		errors.Is(err, helper.ErrResizeFilesystem),
+		errors.Is(err, helper.ErrBtrfsScan),
+		errors.Is(err, helper.ErrWipeSignatures),
+		errors.Is(err, storage.ErrSnapshotCreate),
+		errors.Is(err, storage.ErrPoolEncrypt),
+		errors.Is(err, helper.ErrLuksCommand),
+		errors.Is(err, helper.ErrBtrfsSubvolume),
 		errors.Is(err, storage.ErrPoolDeleteStop),
 		errors.Is(err, storage.ErrPoolDeleteZeroSB),
 		errors.Is(err, storage.ErrPoolCapacityRead),
@@ -104,14 +185,32 @@ func (n *Nas) driveError(err error, c *gin.Context) {
 		c.JSON(http.StatusBadRequest, message)
 	case errors.Is(err, storage.ErrDuplicateDriveKey):
 		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrDriveNotSpare):
+		c.JSON(http.StatusNotFound, message)
+	case errors.Is(err, storage.ErrInvalidRequestBody),
+		errors.Is(err, storage.ErrInvalidWipeMode),
+		errors.Is(err, storage.ErrWipeUnsupported):
+		c.JSON(http.StatusBadRequest, message)
+	case errors.Is(err, storage.ErrDriveMounted),
+		errors.Is(err, storage.ErrDriveHeld),
+		errors.Is(err, storage.ErrDriveInPool),
+		errors.Is(err, storage.ErrDriveMissing),
+		errors.Is(err, jobs.ErrJobTargetBusy):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, storage.ErrWipeTokenInvalid),
+		errors.Is(err, storage.ErrWipeTokenExpired):
+		c.JSON(http.StatusForbidden, message)
 	default:
 		internalServerError(c, err)
 	}
 }
 
-// listAdoptedDrives returns all adopted drives.
+// listAdoptedDrives returns all free adopted drives and the global spares.
 func listAdoptedDrives(c *gin.Context) {
-	SuccessResponse(c, NAS.AdoptedDrives)
+	NAS.lockedSuccessResponse(c, gin.H{
+		"drives": NAS.AdoptedDrives,
+		"spares": NAS.GlobalSpares,
+	})
 }
 
 // Todo Make UUID System for drives
@@ -123,41 +222,171 @@ func adoptDrive(c *gin.Context) {
 		NAS.driveError(err, c)
 		return
 	}
-	SuccessResponse(c, driveToAdopt)
+	NAS.lockedSuccessResponse(c, driveToAdopt)
+}
+
+// unadoptDrive releases an adopted drive that is not part of a pool, optionally
+// wiping its signatures with ?wipe=true.
+func unadoptDrive(c *gin.Context) {
+	released, err := NAS.UnadoptDrive(c.Param("uuid"), c.Query("wipe") == "true", c)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	SuccessResponse(c, gin.H{"Released": released.GetUuid()})
 }
 
 // listDrives returns known drives, optionally rescanning system devices.
 func listDrives(c *gin.Context, rescan bool) {
-	if len(NAS.SystemDrives) == 0 || rescan {
-		NAS.SystemDrives = storage.GetSystemDriveMap()
+	if rescan || len(NAS.SystemDriveList()) == 0 {
+		drives := storage.GetSystemDriveMap()
+		NAS.mu.Lock()
+		NAS.SystemDrives = drives
+		NAS.mu.Unlock()
+		SERVER.attachIo()
 	}
-	SuccessResponse(c, NAS.SystemDrives)
+	SuccessResponse(c, NAS.copySystemDrives())
 }
 
 // listPools returns all pools from memory.
 func listPools(c *gin.Context) {
-	SuccessResponse(c, NAS.POOLS)
+	NAS.lockedSuccessResponse(c, NAS.POOLS)
+}
+
+// requestPoolType resolves the pool type of a create request from its type
+// name, falling back to the raid level when no type is given.
+func requestPoolType(typeName string, raidLevel *int) (storage.PoolType, error) {
+	if typeName != "" {
+		return storage.ParsePoolType(typeName)
+	}
+	if raidLevel == nil {
+		return nil, fmt.Errorf("%w: type or raidLevel is required", storage.ErrInvalidRequestBody)
+	}
+	return &storage.Raid{Level: *raidLevel}, nil
+}
+
+// planPool validates the drives of an unsaved pool and responds with the commands
+// building it would run. Nothing is written to the database or the drives.
+func (n *Nas) planPool(pool *storage.Pool, driveUuids []string, c *gin.Context) {
+	drives, err := n.freeAdoptedDrives(driveUuids)
+	if err != nil {
+		n.poolError(err, c)
+		return
+	}
+	pool.AddDrives(drives...)
+	steps, err := pool.PlanBuild()
+	if err != nil {
+		n.poolError(err, c)
+		return
+	}
+	commands := make([]string, 0, len(steps))
+	for _, step := range steps {
+		commands = append(commands, step.String())
+	}
+	NAS.lockedSuccessResponse(c, gin.H{
+		"pool":     pool,
+		"steps":    steps,
+		"commands": commands,
+	})
+}
+
+// previewPool reports the capacity a pool of the requested type would have on the
+// given free adopted drives, or on explicit drive sizes, without creating it.
+func previewPool(c *gin.Context) {
+	var req struct {
+		Type      string   `json:"type"`
+		RaidLevel *int     `json:"raidLevel"`
+		Drives    []string `json:"drives"`
+		Sizes     []uint64 `json:"sizes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+	poolType, err := requestPoolType(req.Type, req.RaidLevel)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	sizes := req.Sizes
+	if len(req.Drives) > 0 {
+		drives, err := NAS.freeAdoptedDrives(req.Drives)
+		if err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+		sizes = append(storage.DriveSizes(drives), sizes...)
+	}
+	plan, err := storage.PlanCapacity(poolType, sizes)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, gin.H{
+		"type": poolType.Value(),
+		"plan": plan,
+	})
 }
 
 // createPool validates input, persists, and optionally builds a pool.
 func createPool(c *gin.Context) {
 	var req struct {
-		Name      string   `json:"name" binding:"required"`
-		RaidLevel *int     `json:"raidLevel" binding:"required"`
-		Drives    []string `json:"drives" binding:"required"`
-		Format    string   `json:"format" binding:"required"`
-		Build     bool     `json:"build"`
+		Name         string          `json:"name" binding:"required"`
+		Type         string          `json:"type"`
+		RaidLevel    *int            `json:"raidLevel"`
+		Drives       []string        `json:"drives" binding:"required"`
+		Format       string          `json:"format" binding:"required"`
+		Lvm          bool            `json:"lvm"`
+		MountOptions []string        `json:"mountOptions"`
+		Encryption   *helper.LuksKey `json:"encryption"`
+		// Partitioned builds the array on a GPT partition of each drive.
+		Partitioned      bool   `json:"partitioned"`
+		PartitionReserve uint64 `json:"partitionReserve"`
+		Build            bool   `json:"build"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
 		return
 	}
-	pool, err := NAS.POOLS.NewPool(req.Name, &storage.Raid{Level: *req.RaidLevel}, req.Format)
+	poolType, err := requestPoolType(req.Type, req.RaidLevel)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	pool, err := NAS.POOLS.NewPool(req.Name, poolType, req.Format)
 	if err != nil {
 		NAS.poolError(err, c)
 		return
 	}
+	if req.Lvm {
+		if err = pool.EnableLvm(); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
+	if len(req.MountOptions) > 0 {
+		if err = pool.SetMountOptions(req.MountOptions); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
+	if req.Encryption != nil {
+		if err = pool.EnableEncryption(*req.Encryption); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
+	if req.Partitioned {
+		if err = pool.EnablePartitioning(req.PartitionReserve); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
+	if c.Query("dryRun") == "true" {
+		NAS.planPool(pool, req.Drives, c)
+		return
+	}
 	err = NAS.PopulatePool(pool, req.Drives, c)
 	if err != nil {
 		NAS.poolError(err, c)
@@ -173,14 +402,16 @@ func createPool(c *gin.Context) {
 	_ = NAS.RemoveAdoptedDrives(req.Drives, c) // Clean up adopted drives after pool creation
 
 	if req.Build {
-		err = pool.Build()
+		job, err := NAS.StartBuild(pool)
 		if err != nil {
 			buildFailedAfterCreate(c, pool, err)
 			return
 		}
+		AcceptedResponse(c, gin.H{"pool": pool, "job": job})
+		return
 	}
 
-	SuccessResponse(c, pool)
+	NAS.lockedSuccessResponse(c, pool)
 }
 
 // deletePool removes the pool from the database and memory.
@@ -192,7 +423,7 @@ func deletePool(c *gin.Context) {
 		return
 	}
 
-	err = NAS.deletePool(pool)
+	err = NAS.deletePool(pool, c)
 	if err != nil {
 		NAS.poolError(err, c)
 		return
@@ -215,7 +446,7 @@ func getPool(c *gin.Context) {
 		NAS.poolError(err, c)
 		return
 	}
-	SuccessResponse(c, pool)
+	NAS.lockedSuccessResponse(c, pool)
 }
 
 // updatePool applies a patch to a pool in storage and memory.
@@ -236,6 +467,12 @@ func updatePool(c *gin.Context) {
 		NAS.poolError(err, c)
 		return
 	}
+	if req.MountOptions != nil {
+		if err = NAS.RemountPool(pool, *req.MountOptions); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
 	updatedPool, err := SERVER.Db.PatchPool(c, pool, &req)
 	if err != nil {
 		NAS.poolError(err, c)
@@ -246,11 +483,107 @@ func updatePool(c *gin.Context) {
 		NAS.poolError(err, c)
 		return
 	}
-	SuccessResponse(c, updatedPool)
+	NAS.lockedSuccessResponse(c, updatedPool)
 }
 
-// buildPool builds an existing pool and persists its mount point.
+// buildPool starts a background build of an existing pool and returns its job.
+// An optional passphrase or keyfile encrypts the pool.
 func buildPool(c *gin.Context) {
+	var req helper.LuksKey
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	// Keys are not persisted, so encrypted pools built later pass theirs again
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+			return
+		}
+		if err = pool.EnableEncryption(req); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+		if err = SERVER.Db.PatchPoolEncryption(c, pool); err != nil {
+			NAS.poolError(err, c)
+			return
+		}
+	}
+
+	job, err := NAS.StartBuild(pool)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
+
+// offlinePool unmounts the pool and stops its array.
+func offlinePool(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = NAS.setOffline(pool, c); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
+
+// onlinePool assembles the pool array and mounts it.
+func onlinePool(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = NAS.setOnline(pool, c); err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	NAS.lockedSuccessResponse(c, pool)
+}
+
+// replacePoolDrive replaces a failing pool member with a free adopted drive.
+func replacePoolDrive(c *gin.Context) {
+	var req struct {
+		Failed      string `json:"failed" binding:"required"`
+		Replacement string `json:"replacement" binding:"required"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	job, err := NAS.ReplaceDrive(pool, req.Failed, req.Replacement, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
+
+// addPoolDrives grows a built pool onto additional adopted drives.
+func addPoolDrives(c *gin.Context) {
+	var req struct {
+		Drives []string `json:"drives" binding:"required"`
+	}
 	uuid := c.Param("uuid")
 	pool, err := NAS.POOLS.GetPool(uuid)
 	if err != nil {
@@ -258,17 +591,43 @@ func buildPool(c *gin.Context) {
 		return
 	}
 
-	err = pool.Build()
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	job, err := NAS.GrowPool(pool, req.Drives, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
+
+// migratePoolLevel converts a built pool to another raid level.
+func migratePoolLevel(c *gin.Context) {
+	var req struct {
+		RaidLevel *int     `json:"raidLevel" binding:"required"`
+		Drives    []string `json:"drives"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
 	if err != nil {
 		NAS.poolError(err, c)
 		return
 	}
-	err = SERVER.Db.PatchPoolMount(pool.Uuid, pool.MountPoint)
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	job, err := NAS.MigratePool(pool, *req.RaidLevel, req.Drives, c)
 	if err != nil {
 		NAS.poolError(err, c)
 		return
 	}
-	SuccessResponse(c, gin.H{"built": pool.Uuid})
+	AcceptedResponse(c, job)
 }
 
 // SuccessResponse writes a standard success response envelope.
@@ -278,3 +637,25 @@ func SuccessResponse(c *gin.Context, data interface{}) {
 		"data":   data,
 	})
 }
+
+// lockedSuccessResponse writes the success envelope, encoding data under the
+// nas lock so drive health and I/O samples are not written while the pools
+// and drives in it are encoded.
+func (n *Nas) lockedSuccessResponse(c *gin.Context, data interface{}) {
+	n.mu.RLock()
+	encoded, err := json.Marshal(data)
+	n.mu.RUnlock()
+	if err != nil {
+		internalServerError(c, err)
+		return
+	}
+	SuccessResponse(c, json.RawMessage(encoded))
+}
+
+// AcceptedResponse writes the success envelope with 202 for work continuing in the background.
+func AcceptedResponse(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data":   data,
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"goNAS/storage"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListPoolsDuringSampling(t *testing.T) {
+	sysBlock := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(sysBlock, "sda"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	previousSysBlock := storage.SysBlockPath
+	storage.SysBlockPath = sysBlock
+	defer func() { storage.SysBlockPath = previousSysBlock }()
+
+	drive := &storage.DriveInfo{Name: "sda", DriveKey: storage.DriveKey{Kind: "serial", Value: "SDA1"}}
+	pool, _ := storage.NewPool("media", &storage.Raid{Level: 1}, "ext4", drive)
+	pools := &storage.Pools{}
+	_ = pools.AddPool(pool)
+	n := &Nas{POOLS: pools, SystemDrives: map[string]*storage.DriveInfo{drive.DriveKey.String(): drive}}
+	previousNas, previousServer := NAS, SERVER
+	NAS = n
+	SERVER = &Server{Nas: n, Io: storage.NewIoSampler(IoHistorySize)}
+	defer func() { NAS, SERVER = previousNas, previousServer }()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		now := time.Now()
+		for i := 0; i < 50; i++ {
+			stat := fmt.Sprintf("%d 0 %d 0 %d 0 %d 0 0 %d 0\n", i, i*8, i, i*8, i*10)
+			if err := os.WriteFile(filepath.Join(sysBlock, "sda", "stat"), []byte(stat), 0644); err != nil {
+				t.Error(err)
+				return
+			}
+			SERVER.RunIoSample(now.Add(time.Duration(i) * time.Second))
+			n.setHealth(drive, storage.DriveHealthUnknown)
+			pool.AddSpares(&storage.DriveInfo{Name: fmt.Sprintf("sd%d", i)})
+		}
+	}()
+	gin.SetMode(gin.TestMode)
+	for i := 0; i < 50; i++ {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		listPools(c)
+		if recorder.Code != http.StatusOK || !json.Valid(recorder.Body.Bytes()) {
+			t.Fatalf("expected a JSON pool list, got %d %s", recorder.Code, recorder.Body)
+		}
+	}
+	<-done
+}
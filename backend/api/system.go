@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"goNAS/helper"
+	"goNAS/storage"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// systemError writes a host configuration error response with the appropriate status.
+func systemError(err error, c *gin.Context) {
+	message := gin.H{"error": err.Error()}
+	switch {
+	case errors.Is(err, helper.ErrManagedBlock):
+		c.JSON(http.StatusConflict, message)
+	case errors.Is(err, helper.ErrMdadmScan):
+		c.JSON(http.StatusServiceUnavailable, message)
+	default:
+		internalServerError(c, err)
+	}
+}
+
+// FstabEntries returns the managed fstab entries of every built pool, ordered
+// by mount point. Pools whose filesystem cannot be identified, such as a
+// stopped array, keep their current managed entry.
+func (n *Nas) FstabEntries() ([]helper.FstabEntry, error) {
+	lines, err := helper.ReadManagedBlock(helper.FstabPath, helper.FstabBegin, helper.FstabEnd)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]helper.FstabEntry)
+	for _, e := range helper.ParseFstabEntries(lines) {
+		current[e.MountPoint] = e
+	}
+
+	var entries []helper.FstabEntry
+	for _, pool := range n.PoolList() {
+		poolEntries, err := pool.FstabEntries()
+		if err != nil {
+			e, ok := current[pool.MountPoint]
+			if !ok {
+				log.Printf("Pool %s left out of fstab: %v", pool.Uuid, err)
+				continue
+			}
+			log.Printf("Pool %s keeps its current fstab entry: %v", pool.Uuid, err)
+			poolEntries = []helper.FstabEntry{e}
+		}
+		entries = append(entries, poolEntries...)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].MountPoint < entries[j].MountPoint
+	})
+	return entries, nil
+}
+
+// fstabResponse describes the managed fstab block for the given entries.
+func fstabResponse(entries []helper.FstabEntry) gin.H {
+	return gin.H{
+		"path":    helper.FstabPath,
+		"entries": entries,
+		"block":   helper.RenderManagedBlock(helper.FstabBegin, helper.FstabEnd, helper.FstabLines(entries)),
+	}
+}
+
+// getFstab returns the managed fstab block goNAS would write.
+func getFstab(c *gin.Context) {
+	entries, err := NAS.FstabEntries()
+	if err != nil {
+		systemError(err, c)
+		return
+	}
+	SuccessResponse(c, fstabResponse(entries))
+}
+
+// writeFstab replaces the managed block in fstab so pools mount at boot
+// without goNAS running.
+func writeFstab(c *gin.Context) {
+	entries, err := NAS.FstabEntries()
+	if err != nil {
+		systemError(err, c)
+		return
+	}
+	if err = helper.WriteFstab(entries); err != nil {
+		systemError(err, c)
+		return
+	}
+	SuccessResponse(c, fstabResponse(entries))
+}
+
+// MdadmConf returns the current managed mdadm.conf lines and the lines goNAS
+// would write for the pools it owns.
+func (n *Nas) MdadmConf() (current []string, desired []string, err error) {
+	current, err = helper.ReadManagedBlock(helper.MdadmConfPath, helper.MdadmConfBegin, helper.MdadmConfEnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	scanned, err := helper.ScanMdadmArrays()
+	if err != nil {
+		return nil, nil, err
+	}
+	arrays := storage.MdadmConfArrays(n.PoolList(), scanned, helper.ParseMdadmArrays(strings.Join(current, "\n")), storage.ResolveKernelDevice)
+	return current, helper.MdadmConfLines(arrays), nil
+}
+
+// SyncMdadmConf rewrites the managed mdadm.conf section for the pools goNAS owns.
+func (n *Nas) SyncMdadmConf() error {
+	_, desired, err := n.MdadmConf()
+	if err != nil {
+		return err
+	}
+	return helper.WriteManagedBlock(helper.MdadmConfPath, helper.MdadmConfBegin, helper.MdadmConfEnd, desired)
+}
+
+// syncMdadmConf rewrites mdadm.conf after a pool change, logging failures
+// since the pool change itself already succeeded.
+func (n *Nas) syncMdadmConf() {
+	if err := n.SyncMdadmConf(); err != nil {
+		log.Println("Error syncing mdadm.conf:", err)
+	}
+}
+
+// mdadmConfResponse describes the managed mdadm.conf section and its pending changes.
+func mdadmConfResponse(current []string, desired []string) gin.H {
+	return gin.H{
+		"path":    helper.MdadmConfPath,
+		"current": current,
+		"desired": desired,
+		"diff":    helper.DiffLines(current, desired),
+	}
+}
+
+// getMdadmConf returns the diff between the managed mdadm.conf section and the pools goNAS owns.
+func getMdadmConf(c *gin.Context) {
+	current, desired, err := NAS.MdadmConf()
+	if err != nil {
+		systemError(err, c)
+		return
+	}
+	SuccessResponse(c, mdadmConfResponse(current, desired))
+}
+
+// writeMdadmConf rewrites the managed mdadm.conf section.
+func writeMdadmConf(c *gin.Context) {
+	current, desired, err := NAS.MdadmConf()
+	if err != nil {
+		systemError(err, c)
+		return
+	}
+	if err = helper.WriteManagedBlock(helper.MdadmConfPath, helper.MdadmConfBegin, helper.MdadmConfEnd, desired); err != nil {
+		systemError(err, c)
+		return
+	}
+	SuccessResponse(c, mdadmConfResponse(current, desired))
+}
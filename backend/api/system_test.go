@@ -0,0 +1,42 @@
+package api
+
+import (
+	"goNAS/helper"
+	"goNAS/storage"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFstabEntriesKeepUnidentifiedPools(t *testing.T) {
+	fstab := filepath.Join(t.TempDir(), "fstab")
+	content := helper.FstabBegin + "\n" +
+		"UUID=abc /mnt/pools/stopped ext4 noatime,nofail 0 2\n" +
+		"UUID=def /mnt/pools/deleted ext4 defaults,nofail 0 2\n" +
+		helper.FstabEnd + "\n"
+	if err := os.WriteFile(fstab, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := helper.FstabPath
+	helper.FstabPath = fstab
+	defer func() { helper.FstabPath = previous }()
+
+	// Neither array exists, so blkid cannot identify their filesystems
+	pools := &storage.Pools{}
+	for _, name := range []string{"stopped", "new"} {
+		pool, _ := storage.NewPool(name, &storage.Raid{Level: 1}, "ext4")
+		pool.MountPoint = "/mnt/pools/" + name
+		if err := pools.AddPool(pool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n := &Nas{POOLS: pools}
+
+	entries, err := n.FstabEntries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].String() != "UUID=abc /mnt/pools/stopped ext4 noatime,nofail 0 2" {
+		t.Fatalf("expected only the stopped pool to keep its entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goNAS/jobs"
+	"goNAS/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateVolume creates a logical volume in an lvm pool and persists it.
+func (n *Nas) CreateVolume(pool *storage.Pool, name string, sizeBytes uint64, format string, c context.Context) (*storage.Volume, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	volume, err := pool.CreateVolume(name, sizeBytes, format)
+	if err != nil {
+		return nil, err
+	}
+	if err = SERVER.Db.InsertVolume(c, volume); err != nil {
+		// An unrecorded volume would be lost on restart, so remove it again
+		if _, rmErr := pool.DeleteVolume(volume.Uuid); rmErr != nil {
+			err = errors.Join(err, rmErr)
+		}
+		return nil, err
+	}
+	return volume, SERVER.Db.PatchPoolCapacity(c, pool)
+}
+
+// ResizeVolume grows a logical volume in an lvm pool and persists its size.
+func (n *Nas) ResizeVolume(pool *storage.Pool, volumeUuid string, sizeBytes uint64, c context.Context) (*storage.Volume, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	volume, err := pool.ResizeVolume(volumeUuid, sizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = SERVER.Db.PatchVolumeSize(c, volume); err != nil {
+		return nil, err
+	}
+	return volume, SERVER.Db.PatchPoolCapacity(c, pool)
+}
+
+// DeleteVolume removes a logical volume from an lvm pool and from the database.
+func (n *Nas) DeleteVolume(pool *storage.Pool, volumeUuid string, c context.Context) (*storage.Volume, error) {
+	if SERVER.Jobs.Busy(pool.Uuid) {
+		return nil, jobs.ErrJobTargetBusy
+	}
+	volume, err := pool.DeleteVolume(volumeUuid)
+	if err != nil {
+		return nil, err
+	}
+	if err = SERVER.Db.DeleteVolume(c, volume.Uuid); err != nil {
+		return nil, err
+	}
+	return volume, SERVER.Db.PatchPoolCapacity(c, pool)
+}
+
+// listPoolVolumes returns the logical volumes of a pool.
+func listPoolVolumes(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	if !pool.IsLvm() {
+		NAS.poolError(storage.ErrLvmUnsupported, c)
+		return
+	}
+	SuccessResponse(c, pool.ListVolumes())
+}
+
+// createPoolVolume creates a logical volume in a pool.
+func createPoolVolume(c *gin.Context) {
+	var req struct {
+		Name      string `json:"name" binding:"required"`
+		SizeBytes uint64 `json:"sizeBytes" binding:"required"`
+		Format    string `json:"format"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	volume, err := NAS.CreateVolume(pool, req.Name, req.SizeBytes, req.Format, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, volume)
+}
+
+// resizePoolVolume grows a logical volume in a pool.
+func resizePoolVolume(c *gin.Context) {
+	var req struct {
+		SizeBytes uint64 `json:"sizeBytes" binding:"required"`
+	}
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	if err = c.ShouldBindJSON(&req); err != nil {
+		NAS.poolError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+
+	volume, err := NAS.ResizeVolume(pool, c.Param("volume"), req.SizeBytes, c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, volume)
+}
+
+// deletePoolVolume removes a logical volume from a pool.
+func deletePoolVolume(c *gin.Context) {
+	uuid := c.Param("uuid")
+	pool, err := NAS.POOLS.GetPool(uuid)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+
+	volume, err := NAS.DeleteVolume(pool, c.Param("volume"), c)
+	if err != nil {
+		NAS.poolError(err, c)
+		return
+	}
+	SuccessResponse(c, volume)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goNAS/jobs"
+	"goNAS/storage"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wipeableDrive returns a free adopted drive that no job is using.
+func (n *Nas) wipeableDrive(driveUuid string) (*storage.DriveInfo, error) {
+	drives, err := n.freeAdoptedDrives([]string{driveUuid})
+	if err != nil {
+		return nil, err
+	}
+	return drives[0], nil
+}
+
+// PrepareWipe checks that a free adopted drive can be wiped in mode and issues
+// the confirmation token the wipe must present. Preparing again replaces the token.
+func (n *Nas) PrepareWipe(driveUuid string, mode storage.WipeMode) (storage.WipeConfirmation, error) {
+	drive, err := n.wipeableDrive(driveUuid)
+	if err != nil {
+		return storage.WipeConfirmation{}, err
+	}
+	if err = storage.CheckWipe(drive, mode); err != nil {
+		return storage.WipeConfirmation{}, err
+	}
+	confirmation, err := storage.NewWipeConfirmation(driveUuid, mode)
+	if err != nil {
+		return storage.WipeConfirmation{}, err
+	}
+
+	n.mu.Lock()
+	if n.wipeTokens == nil {
+		n.wipeTokens = make(map[string]storage.WipeConfirmation)
+	}
+	n.wipeTokens[driveUuid] = confirmation
+	n.mu.Unlock()
+	return confirmation, nil
+}
+
+// StartWipe consumes the prepared confirmation for the drive and erases it as a job.
+func (n *Nas) StartWipe(driveUuid string, mode storage.WipeMode, token string) (jobs.Job, error) {
+	drive, err := n.wipeableDrive(driveUuid)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	n.mu.Lock()
+	err = n.wipeTokens[driveUuid].Confirm(driveUuid, mode, token, time.Now())
+	if err == nil {
+		// Tokens are single use
+		delete(n.wipeTokens, driveUuid)
+	}
+	n.mu.Unlock()
+	if err != nil {
+		return jobs.Job{}, err
+	}
+
+	// The drive may have been mounted or assembled since the wipe was prepared
+	if err = storage.CheckWipe(drive, mode); err != nil {
+		return jobs.Job{}, err
+	}
+	return SERVER.Jobs.Start("wipe", driveUuid, func(ctx context.Context, r *jobs.Reporter) error {
+		return storage.WipeDrive(ctx, drive, mode, progress(r))
+	})
+}
+
+// prepareDriveWipe validates a wipe of a free adopted drive and returns its confirmation token.
+func prepareDriveWipe(c *gin.Context) {
+	var req struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		NAS.driveError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+	mode, err := storage.ParseWipeMode(req.Mode)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+
+	confirmation, err := NAS.PrepareWipe(c.Param("uuid"), mode)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	SuccessResponse(c, confirmation)
+}
+
+// wipeDrive erases a free adopted drive once given the token from prepareDriveWipe.
+func wipeDrive(c *gin.Context) {
+	var req struct {
+		Mode  string `json:"mode" binding:"required"`
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		NAS.driveError(fmt.Errorf("%w: %v", storage.ErrInvalidRequestBody, err), c)
+		return
+	}
+	mode, err := storage.ParseWipeMode(req.Mode)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+
+	job, err := NAS.StartWipe(c.Param("uuid"), mode, req.Token)
+	if err != nil {
+		NAS.driveError(err, c)
+		return
+	}
+	AcceptedResponse(c, job)
+}
@@ -130,7 +130,7 @@ func createSystemPool(pools *storage.Pools, level int) (*storage.Pool, error) {
 		return nil, err
 	}
 	myPool.SetFormat("mkfs.ext4")
-	err = myPool.Build()
+	err = myPool.Build(context.Background(), nil)
 	if err != nil {
 		return nil, err
 	}
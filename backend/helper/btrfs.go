@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Btrfs-related errors
+var (
+	ErrBtrfsSubvolume = errors.New("btrfs subvolume command failed")
+)
+
+// runBtrfsSubvolume runs a `btrfs subvolume` subcommand and wraps failures with its output.
+func runBtrfsSubvolume(args ...string) error {
+	args = append([]string{"subvolume"}, args...)
+	if out, err := exec.Command("btrfs", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: btrfs %s: %v: %s", ErrBtrfsSubvolume, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CreateSubvolume creates a btrfs subvolume at path.
+func CreateSubvolume(path string) error {
+	return runBtrfsSubvolume("create", path)
+}
+
+// SnapshotSubvolume creates a read-only snapshot of source at dest.
+func SnapshotSubvolume(source string, dest string) error {
+	return runBtrfsSubvolume("snapshot", "-r", source, dest)
+}
+
+// DeleteSubvolume deletes the btrfs subvolume or snapshot at path.
+func DeleteSubvolume(path string) error {
+	return runBtrfsSubvolume("delete", path)
+}
@@ -0,0 +1,88 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var FstabPath = "/etc/fstab"
+
+const (
+	FstabBegin = "# BEGIN goNAS managed mounts"
+	FstabEnd   = "# END goNAS managed mounts"
+)
+
+var ErrFilesystemUUID = errors.New("failed to read filesystem uuid")
+
+// FstabEntry is one managed /etc/fstab line.
+type FstabEntry struct {
+	Device     string   `json:"device"`
+	MountPoint string   `json:"mountPoint"`
+	Format     string   `json:"format"`
+	Options    []string `json:"options"`
+}
+
+// String renders the entry as an fstab line. Managed mounts always carry
+// nofail so a missing array does not stop the host from booting.
+func (e FstabEntry) String() string {
+	options := append([]string{}, e.Options...)
+	if len(options) == 0 {
+		options = append(options, "defaults")
+	}
+	options = append(options, "nofail")
+
+	pass := 0
+	if strings.HasPrefix(e.Format, "ext") {
+		pass = 2
+	}
+	return fmt.Sprintf("%s %s %s %s 0 %d", e.Device, e.MountPoint, e.Format, strings.Join(options, ","), pass)
+}
+
+// FstabLines renders entries as fstab lines.
+func FstabLines(entries []FstabEntry) []string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.String())
+	}
+	return lines
+}
+
+// ParseFstabEntries parses fstab lines as rendered by FstabEntry.String,
+// skipping comments and malformed lines.
+func ParseFstabEntries(lines []string) []FstabEntry {
+	var entries []FstabEntry
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var options []string
+		for _, option := range strings.Split(fields[3], ",") {
+			if option != "defaults" && option != "nofail" {
+				options = append(options, option)
+			}
+		}
+		entries = append(entries, FstabEntry{Device: fields[0], MountPoint: fields[1], Format: fields[2], Options: options})
+	}
+	return entries
+}
+
+// WriteFstab replaces the goNAS managed block in FstabPath with entries.
+func WriteFstab(entries []FstabEntry) error {
+	return WriteManagedBlock(FstabPath, FstabBegin, FstabEnd, FstabLines(entries))
+}
+
+// FilesystemUUID returns the UUID of the filesystem on device.
+func FilesystemUUID(device string) (string, error) {
+	out, err := exec.Command("blkid", "-s", "UUID", "-o", "value", device).Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrFilesystemUUID, device, err)
+	}
+	uuid := strings.TrimSpace(string(out))
+	if uuid == "" {
+		return "", fmt.Errorf("%w: %s has no filesystem", ErrFilesystemUUID, device)
+	}
+	return uuid, nil
+}
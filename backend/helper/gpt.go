@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GPT-related errors
+var (
+	ErrPartitionDrive    = errors.New("failed to partition drive")
+	ErrPartitionTooSmall = errors.New("drive is too small for a partition with the requested reserve")
+	ErrPartitionUuid     = errors.New("failed to read partition uuid")
+)
+
+// GptAlignment is the boundary partitions start and end on.
+const GptAlignment = 1024 * 1024
+
+// GptLinuxRaid is the sgdisk type code of a Linux RAID member partition.
+const GptLinuxRaid = "FD00"
+
+// gptEntriesBytes is the size of the standard 128 entry partition array.
+const gptEntriesBytes = 128 * 128
+
+// gptBackupBytes returns the space the backup GPT header and partition array
+// take at the end of a disk with the given logical sector size.
+func gptBackupBytes(sectorSize uint64) uint64 {
+	return sectorSize + (gptEntriesBytes+sectorSize-1)/sectorSize*sectorSize
+}
+
+// GptPartitionRange returns the first and last sector of a single partition
+// that starts at 1MiB and leaves at least reserve bytes unused at the end of
+// the disk. The end is aligned down to 1MiB. A sectorSize of zero means 512.
+func GptPartitionRange(sizeBytes uint64, sectorSize uint64, reserve uint64) (uint64, uint64, error) {
+	if sectorSize == 0 {
+		sectorSize = 512
+	}
+	tail := gptBackupBytes(sectorSize) + reserve
+	if sizeBytes < tail+2*GptAlignment {
+		return 0, 0, fmt.Errorf("%w: %d bytes with %d reserved", ErrPartitionTooSmall, sizeBytes, reserve)
+	}
+	end := (sizeBytes - tail) / GptAlignment * GptAlignment
+	return GptAlignment / sectorSize, end/sectorSize - 1, nil
+}
+
+// GptPartitionSize returns the size in bytes of the partition GptPartitionRange lays out.
+func GptPartitionSize(sizeBytes uint64, sectorSize uint64, reserve uint64) (uint64, error) {
+	first, last, err := GptPartitionRange(sizeBytes, sectorSize, reserve)
+	if err != nil {
+		return 0, err
+	}
+	if sectorSize == 0 {
+		sectorSize = 512
+	}
+	return (last - first + 1) * sectorSize, nil
+}
+
+// PartitionPath returns the device path of partition number of device. Devices
+// whose names end in a digit, such as /dev/nvme0n1, separate it with a p.
+func PartitionPath(device string, number int) string {
+	if last := device[len(device)-1]; last >= '0' && last <= '9' {
+		return device + "p" + strconv.Itoa(number)
+	}
+	return device + strconv.Itoa(number)
+}
+
+// GptPartitionArgs returns the sgdisk invocations that replace the partition
+// table of device with a single Linux RAID partition spanning first to last.
+func GptPartitionArgs(device string, first uint64, last uint64, name string) [][]string {
+	return [][]string{
+		{"--zap-all", device},
+		{
+			fmt.Sprintf("--new=1:%d:%d", first, last),
+			"--typecode=1:" + GptLinuxRaid,
+			"--change-name=1:" + name,
+			device,
+		},
+	}
+}
+
+// CreateGptPartition writes a fresh GPT to device holding one Linux RAID
+// partition that leaves reserve bytes free at the end, and returns the
+// partition's unique GUID.
+func CreateGptPartition(device string, sizeBytes uint64, sectorSize uint64, reserve uint64, name string) (string, error) {
+	first, last, err := GptPartitionRange(sizeBytes, sectorSize, reserve)
+	if err != nil {
+		return "", err
+	}
+	for _, args := range GptPartitionArgs(device, first, last, name) {
+		if out, err := exec.Command("sgdisk", args...).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%w: sgdisk %s: %v: %s", ErrPartitionDrive, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	// Wait for udev to create the partition device node
+	_ = exec.Command("udevadm", "settle").Run()
+	return PartitionUUID(device, 1)
+}
+
+// ParseSgdiskPartitionUUID extracts the unique GUID from `sgdisk --info` output.
+func ParseSgdiskPartitionUUID(out string) (string, error) {
+	for _, line := range strings.Split(out, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "Partition unique GUID:"); ok {
+			return strings.ToLower(strings.TrimSpace(value)), nil
+		}
+	}
+	return "", ErrPartitionUuid
+}
+
+// PartitionUUID returns the unique GUID of partition number of device.
+func PartitionUUID(device string, number int) (string, error) {
+	out, err := exec.Command("sgdisk", fmt.Sprintf("--info=%d", number), device).Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrPartitionUuid, err)
+	}
+	return ParseSgdiskPartitionUUID(string(out))
+}
@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// LUKS-related errors
+var (
+	ErrLuksCommand     = errors.New("cryptsetup command failed")
+	ErrLuksBadKey      = errors.New("no key slot matches the passphrase or keyfile")
+	ErrLuksKeyRequired = errors.New("exactly one of passphrase or keyFile is required")
+)
+
+// luksBadKeyExit is the cryptsetup exit status for a passphrase or keyfile that opens no key slot.
+const luksBadKeyExit = 2
+
+// LuksKey is the passphrase or keyfile path used to format or open a LUKS device.
+// It is only ever held in memory.
+type LuksKey struct {
+	Passphrase string `json:"passphrase"`
+	KeyFile    string `json:"keyFile"`
+}
+
+// Validate ensures exactly one of the passphrase and keyfile is set.
+func (k LuksKey) Validate() error {
+	if (k.Passphrase == "") == (k.KeyFile == "") {
+		return ErrLuksKeyRequired
+	}
+	return nil
+}
+
+// runCryptsetup runs cryptsetup, passing key through --key-file when given, and
+// wraps failures with its output.
+func runCryptsetup(key *LuksKey, args ...string) (string, error) {
+	var cmd *exec.Cmd
+	switch {
+	case key == nil:
+		cmd = exec.Command("cryptsetup", args...)
+	case key.KeyFile != "":
+		cmd = exec.Command("cryptsetup", append([]string{"--key-file", key.KeyFile}, args...)...)
+	default:
+		// Passphrases are read from stdin so they never appear in the process list
+		cmd = exec.Command("cryptsetup", append([]string{"--key-file", "-"}, args...)...)
+		cmd.Stdin = strings.NewReader(key.Passphrase)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == luksBadKeyExit {
+			return "", errors.Join(ErrLuksBadKey, fmt.Errorf("%w: %s", ErrLuksCommand, strings.TrimSpace(string(out))))
+		}
+		return "", fmt.Errorf("%w: cryptsetup %s: %v: %s", ErrLuksCommand, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// LuksFormat writes a LUKS2 header to device, destroying its contents.
+func LuksFormat(device string, key LuksKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	_, err := runCryptsetup(&key, "luksFormat", "--type", "luks2", "--batch-mode", device)
+	return err
+}
+
+// LuksUUID returns the UUID stored in the LUKS header of device.
+func LuksUUID(device string) (string, error) {
+	return runCryptsetup(nil, "luksUUID", device)
+}
+
+// LuksOpen unlocks device as /dev/mapper/name. The volume key is kept in the
+// device-mapper table rather than the kernel keyring so the mapping can be
+// resized later without the key.
+func LuksOpen(device string, name string, key LuksKey) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	_, err := runCryptsetup(&key, "open", "--type", "luks", "--disable-keyring", device, name)
+	return err
+}
+
+// LuksClose locks the mapping /dev/mapper/name.
+func LuksClose(name string) error {
+	_, err := runCryptsetup(nil, "close", name)
+	return err
+}
+
+// LuksResize grows the mapping /dev/mapper/name to fill its underlying device.
+func LuksResize(name string) error {
+	_, err := runCryptsetup(nil, "resize", name)
+	return err
+}
+
+// MapperDevice returns the device-mapper path for name.
+func MapperDevice(name string) string {
+	return "/dev/mapper/" + name
+}
+
+// IsLuksOpen reports whether the mapping /dev/mapper/name exists.
+func IsLuksOpen(name string) bool {
+	_, err := os.Stat(MapperDevice(name))
+	return err == nil
+}
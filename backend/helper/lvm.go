@@ -0,0 +1,86 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LVM-related errors
+var (
+	ErrLvmCommand     = errors.New("lvm command failed")
+	ErrLvmOutputParse = errors.New("failed to parse lvm output")
+)
+
+// runLvm runs an LVM tool and wraps failures with its output.
+func runLvm(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s %s: %v: %s", ErrLvmCommand, name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CreateVolumeGroup initialises device as a physical volume and creates volume group vg on it.
+func CreateVolumeGroup(vg string, device string) error {
+	if _, err := runLvm("pvcreate", "-y", device); err != nil {
+		return err
+	}
+	_, err := runLvm("vgcreate", vg, device)
+	return err
+}
+
+// ActivateVolumeGroup activates or deactivates every logical volume of vg.
+func ActivateVolumeGroup(vg string, active bool) error {
+	flag := "-an"
+	if active {
+		flag = "-ay"
+	}
+	_, err := runLvm("vgchange", flag, vg)
+	return err
+}
+
+// ResizePhysicalVolume grows the physical volume on device to fill it.
+func ResizePhysicalVolume(device string) error {
+	_, err := runLvm("pvresize", device)
+	return err
+}
+
+// VolumeGroupCapacity returns the size and free space of vg in bytes.
+func VolumeGroupCapacity(vg string) (total uint64, free uint64, err error) {
+	out, err := runLvm("vgs", "--noheadings", "--units", "b", "--nosuffix", "-o", "vg_size,vg_free", vg)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("%w: unexpected vgs output %q", ErrLvmOutputParse, out)
+	}
+	if total, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrLvmOutputParse, err)
+	}
+	if free, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrLvmOutputParse, err)
+	}
+	return total, free, nil
+}
+
+// CreateLogicalVolume creates logical volume name of sizeBytes in vg.
+func CreateLogicalVolume(vg string, name string, sizeBytes uint64) error {
+	_, err := runLvm("lvcreate", "-y", "--wipesignatures", "y", "-n", name, "-L", fmt.Sprintf("%dB", sizeBytes), vg)
+	return err
+}
+
+// ExtendLogicalVolume grows logical volume name in vg to sizeBytes.
+func ExtendLogicalVolume(vg string, name string, sizeBytes uint64) error {
+	_, err := runLvm("lvextend", "-L", fmt.Sprintf("%dB", sizeBytes), vg+"/"+name)
+	return err
+}
+
+// RemoveLogicalVolume deletes logical volume name from vg.
+func RemoveLogicalVolume(vg string, name string) error {
+	_, err := runLvm("lvremove", "-y", vg+"/"+name)
+	return err
+}
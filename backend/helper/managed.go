@@ -0,0 +1,151 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Managed block errors
+var (
+	ErrManagedBlock      = errors.New("managed block markers are missing or out of order")
+	ErrManagedBlockWrite = errors.New("failed to write managed block")
+)
+
+// RenderManagedBlock wraps lines in begin and end marker lines.
+func RenderManagedBlock(begin string, end string, lines []string) string {
+	var b strings.Builder
+	b.WriteString(begin + "\n")
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(end + "\n")
+	return b.String()
+}
+
+// ReplaceManagedBlock returns content with the block between the begin and end
+// markers replaced by block, or with block appended when there are no markers.
+// Everything outside the markers is left untouched.
+func ReplaceManagedBlock(content string, begin string, end string, block string) (string, error) {
+	start := strings.Index(content, begin+"\n")
+	stop := strings.Index(content, end+"\n")
+	switch {
+	case start == -1 && stop == -1:
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return content + block, nil
+	case start == -1 || stop == -1 || stop < start:
+		return "", ErrManagedBlock
+	}
+	return content[:start] + block + content[stop+len(end)+1:], nil
+}
+
+// ReadManagedBlock returns the lines between the begin and end markers of the
+// file at path. A missing file or block has no lines.
+func ReadManagedBlock(path string, begin string, end string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	start := strings.Index(content, begin+"\n")
+	stop := strings.Index(content, end+"\n")
+	switch {
+	case start == -1 && stop == -1:
+		return nil, nil
+	case start == -1 || stop == -1 || stop < start:
+		return nil, ErrManagedBlock
+	}
+	block := strings.TrimSuffix(content[start+len(begin)+1:stop], "\n")
+	if block == "" {
+		return nil, nil
+	}
+	return strings.Split(block, "\n"), nil
+}
+
+// DiffLines returns a line diff from old to new. Removed lines are prefixed
+// with "-", added lines with "+" and unchanged lines with a space.
+func DiffLines(old []string, new []string) []string {
+	// lcs[i][j] is the longest common subsequence of old[i:] and new[j:]
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]string, 0, len(old)+len(new))
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			diff = append(diff, " "+old[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "-"+old[i])
+			i++
+		default:
+			diff = append(diff, "+"+new[j])
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		diff = append(diff, "-"+old[i])
+	}
+	for ; j < len(new); j++ {
+		diff = append(diff, "+"+new[j])
+	}
+	return diff
+}
+
+// WriteManagedBlock replaces the managed block of the file at path with lines.
+// The file is created if missing and replaced atomically, keeping its mode.
+func WriteManagedBlock(path string, begin string, end string, lines []string) error {
+	mode := os.FileMode(0644)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Join(ErrManagedBlockWrite, err)
+	}
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	content, err := ReplaceManagedBlock(string(data), begin, end, RenderManagedBlock(begin, end, lines))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Join(ErrManagedBlockWrite, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return errors.Join(ErrManagedBlockWrite, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Join(ErrManagedBlockWrite, err)
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return errors.Join(ErrManagedBlockWrite, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("%w: %v", ErrManagedBlockWrite, err)
+	}
+	return nil
+}
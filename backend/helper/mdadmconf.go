@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var MdadmConfPath = "/etc/mdadm/mdadm.conf"
+
+const (
+	MdadmConfBegin = "# BEGIN goNAS managed arrays"
+	MdadmConfEnd   = "# END goNAS managed arrays"
+)
+
+var ErrMdadmScan = errors.New("failed to scan md arrays")
+
+// MdadmArray is one ARRAY line of mdadm.conf or `mdadm --detail --scan` output.
+type MdadmArray struct {
+	Device     string   `json:"device"`
+	Attributes []string `json:"attributes"`
+}
+
+// UUID returns the array UUID attribute, or "" when the line has none.
+func (a MdadmArray) UUID() string {
+	for _, attr := range a.Attributes {
+		if value, ok := strings.CutPrefix(attr, "UUID="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// String renders the array as an mdadm.conf line.
+func (a MdadmArray) String() string {
+	return strings.Join(append([]string{"ARRAY", a.Device}, a.Attributes...), " ")
+}
+
+// ParseMdadmArrays returns the ARRAY lines of mdadm.conf style content.
+func ParseMdadmArrays(content string) []MdadmArray {
+	var arrays []MdadmArray
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "ARRAY" {
+			continue
+		}
+		arrays = append(arrays, MdadmArray{Device: fields[1], Attributes: fields[2:]})
+	}
+	return arrays
+}
+
+// ScanMdadmArrays returns the running arrays reported by `mdadm --detail --scan`.
+func ScanMdadmArrays() ([]MdadmArray, error) {
+	out, err := exec.Command("mdadm", "--detail", "--scan").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMdadmScan, err)
+	}
+	return ParseMdadmArrays(string(out)), nil
+}
+
+// MdadmConfLines renders arrays as mdadm.conf lines.
+func MdadmConfLines(arrays []MdadmArray) []string {
+	lines := make([]string, 0, len(arrays))
+	for _, a := range arrays {
+		lines = append(lines, a.String())
+	}
+	return lines
+}
@@ -8,6 +8,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -37,7 +40,11 @@ var (
 	ErrMdadmBuild            = errors.New("mdadm command failed")
 	ErrMountPointCreate      = errors.New("failed to create mount point")
 	ErrMountRaidDevice       = errors.New("failed to mount raid device")
+	ErrUnmountDevice         = errors.New("failed to unmount device")
 	ErrFormatRaidDevice      = errors.New("failed to format raid device")
+	ErrResizeFilesystem      = errors.New("failed to resize filesystem")
+	ErrBtrfsScan             = errors.New("failed to scan btrfs devices")
+	ErrWipeSignatures        = errors.New("failed to wipe device signatures")
 	ErrInvalidRaidName       = errors.New("invalid raid name")
 )
 
@@ -238,19 +245,106 @@ func BuildMdadm(args []string) error {
 }
 
 // CreateMountPoint creates a mount point directory and mounts the given mdDevice there.
-func CreateMountPoint(uuid string, mdDevice string) error {
-	// Create and mount directory
-	mountPoint := fmt.Sprintf("%s/%s", DefaultMountPoint, uuid)
+func CreateMountPoint(uuid string, mdDevice string, options ...string) error {
+	return Mount(mdDevice, fmt.Sprintf("%s/%s", DefaultMountPoint, uuid), options...)
+}
+
+// Mount creates the mount point directory if needed and mounts device on it with options.
+func Mount(device string, mountPoint string, options ...string) error {
 	if err := os.MkdirAll(mountPoint, 0755); err != nil {
 		return fmt.Errorf("%w: %v", ErrMountPointCreate, err)
 	}
 
-	if err := exec.Command("mount", mdDevice, mountPoint).Run(); err != nil {
-		return fmt.Errorf("%w: %v", ErrMountRaidDevice, err)
+	args := []string{device, mountPoint}
+	if len(options) > 0 {
+		args = append([]string{"-o", strings.Join(options, ",")}, args...)
+	}
+	if out, err := exec.Command("mount", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrMountRaidDevice, err, strings.TrimSpace(string(out)))
 	}
 	return nil
 }
 
+// Remount applies options to the filesystem mounted at mountPoint. The mount
+// is made read-write unless options include ro.
+func Remount(mountPoint string, options ...string) error {
+	flags := []string{"remount"}
+	if !slices.Contains(options, "ro") {
+		flags = append(flags, "rw")
+	}
+	flags = append(flags, options...)
+	if out, err := exec.Command("mount", "-o", strings.Join(flags, ","), mountPoint).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrMountRaidDevice, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Unmount unmounts the filesystem at mountPoint.
+func Unmount(mountPoint string) error {
+	if out, err := exec.Command("umount", mountPoint).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrUnmountDevice, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var MountsPath = "/proc/self/mounts"
+var ProcPath = "/proc"
+
+// IsMounted reports whether a filesystem is mounted at mountPoint.
+func IsMounted(mountPoint string) bool {
+	data, err := os.ReadFile(MountsPath)
+	if err != nil {
+		return false
+	}
+	target := filepath.Clean(mountPoint)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == target {
+			return true
+		}
+	}
+	return false
+}
+
+// MountHolders returns the PIDs of processes with open files, a working directory
+// or a root below mountPoint.
+func MountHolders(mountPoint string) ([]int, error) {
+	entries, err := os.ReadDir(ProcPath)
+	if err != nil {
+		return nil, err
+	}
+	target := filepath.Clean(mountPoint)
+	below := func(path string) bool {
+		return path == target || strings.HasPrefix(path, target+"/")
+	}
+
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		procDir := filepath.Join(ProcPath, e.Name())
+		links := []string{
+			filepath.Join(procDir, "cwd"),
+			filepath.Join(procDir, "root"),
+			filepath.Join(procDir, "exe"),
+		}
+		fds, _ := filepath.Glob(filepath.Join(procDir, "fd", "*"))
+		links = append(links, fds...)
+
+		for _, link := range links {
+			path, err := os.Readlink(link)
+			if err == nil && below(path) {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	sort.Ints(pids)
+	return pids, nil
+}
+
 // FormatPool formats the given mdDevice with the specified format command.
 func FormatPool(format string, mdDevice string) error {
 	if err := exec.Command("mkfs."+format, "-F", mdDevice).Run(); err != nil {
@@ -258,3 +352,49 @@ func FormatPool(format string, mdDevice string) error {
 	}
 	return nil
 }
+
+// MkfsBtrfs creates a btrfs filesystem spanning devices, using profile for both data and metadata.
+func MkfsBtrfs(label string, profile string, devices ...string) error {
+	args := append([]string{"-f", "-L", label, "-d", profile, "-m", profile}, devices...)
+	if out, err := exec.Command("mkfs.btrfs", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrFormatRaidDevice, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ScanBtrfsDevices registers btrfs member devices with the kernel so multi-device filesystems can mount.
+func ScanBtrfsDevices() error {
+	if out, err := exec.Command("btrfs", "device", "scan").CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrBtrfsScan, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WipeSignatures erases filesystem, raid and partition table signatures from devices.
+func WipeSignatures(devices ...string) error {
+	args := append([]string{"-a"}, devices...)
+	if out, err := exec.Command("wipefs", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrWipeSignatures, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ResizeFilesystem grows the filesystem on device to fill it, using the tool for format.
+// xfs and btrfs are grown through their mount point.
+func ResizeFilesystem(format string, device string, mountPoint string) error {
+	var cmd *exec.Cmd
+	switch format {
+	case "ext4":
+		cmd = exec.Command("resize2fs", device)
+	case "xfs":
+		cmd = exec.Command("xfs_growfs", mountPoint)
+	case "btrfs":
+		cmd = exec.Command("btrfs", "filesystem", "resize", "max", mountPoint)
+	default:
+		return fmt.Errorf("%w: unsupported format %q", ErrResizeFilesystem, format)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrResizeFilesystem, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package helper
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -43,3 +47,262 @@ func TestSanitizeRaidName(t *testing.T) {
 		})
 	}
 }
+
+func TestLuksKeyValidate(t *testing.T) {
+	valid := []LuksKey{{Passphrase: "hunter2"}, {KeyFile: "/root/pool.key"}}
+	for _, key := range valid {
+		if err := key.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", key, err)
+		}
+	}
+	invalid := []LuksKey{{}, {Passphrase: "hunter2", KeyFile: "/root/pool.key"}}
+	for _, key := range invalid {
+		if err := key.Validate(); !errors.Is(err, ErrLuksKeyRequired) {
+			t.Errorf("expected ErrLuksKeyRequired for %+v, got %v", key, err)
+		}
+	}
+}
+
+func TestReplaceManagedBlock(t *testing.T) {
+	block := RenderManagedBlock(FstabBegin, FstabEnd, []string{"UUID=abc /mnt/pools/a ext4 defaults,nofail 0 2"})
+	original := "/dev/sda1 / ext4 defaults 0 1"
+
+	appended, err := ReplaceManagedBlock(original, FstabBegin, FstabEnd, block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appended != original+"\n"+block {
+		t.Fatalf("expected block appended after existing lines, got %q", appended)
+	}
+
+	replacement := RenderManagedBlock(FstabBegin, FstabEnd, nil)
+	replaced, err := ReplaceManagedBlock(appended+"tmpfs /tmp tmpfs defaults 0 0\n", FstabBegin, FstabEnd, replacement)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := original + "\n" + replacement + "tmpfs /tmp tmpfs defaults 0 0\n"
+	if replaced != want {
+		t.Fatalf("expected only the managed block replaced, got %q", replaced)
+	}
+
+	if _, err = ReplaceManagedBlock(FstabEnd+"\n"+FstabBegin+"\n", FstabBegin, FstabEnd, block); !errors.Is(err, ErrManagedBlock) {
+		t.Fatalf("expected ErrManagedBlock, got %v", err)
+	}
+}
+
+func TestWriteManagedBlockKeepsUnmanagedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fstab")
+	if err := os.WriteFile(path, []byte("/dev/sda1 / ext4 defaults 0 1\n"), 0600); err != nil {
+		t.Fatalf("failed to write fstab: %v", err)
+	}
+	entries := []FstabEntry{
+		{Device: "UUID=abc", MountPoint: "/mnt/pools/a", Format: "btrfs", Options: []string{"noatime", "compress=zstd"}},
+		{Device: "/dev/gonas-vg/media", MountPoint: "/mnt/pools/b/media", Format: "ext4"},
+	}
+	for i := 0; i < 2; i++ {
+		if err := WriteManagedBlock(path, FstabBegin, FstabEnd, FstabLines(entries)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fstab: %v", err)
+	}
+	want := "/dev/sda1 / ext4 defaults 0 1\n" +
+		FstabBegin + "\n" +
+		"UUID=abc /mnt/pools/a btrfs noatime,compress=zstd,nofail 0 0\n" +
+		"/dev/gonas-vg/media /mnt/pools/b/media ext4 defaults,nofail 0 2\n" +
+		FstabEnd + "\n"
+	if string(data) != want {
+		t.Fatalf("unexpected fstab:\n%s\nwant:\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("expected file mode to be kept, got %v", info.Mode().Perm())
+	}
+}
+
+func TestParseFstabEntries(t *testing.T) {
+	entries := []FstabEntry{
+		{Device: "UUID=abc", MountPoint: "/mnt/pools/a", Format: "btrfs", Options: []string{"noatime", "compress=zstd"}},
+		{Device: "/dev/gonas-vg/media", MountPoint: "/mnt/pools/b/media", Format: "ext4"},
+	}
+	lines := append([]string{"# a comment", "broken line"}, FstabLines(entries)...)
+	parsed := ParseFstabEntries(lines)
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", parsed)
+	}
+	for i := range entries {
+		if parsed[i].String() != entries[i].String() {
+			t.Fatalf("expected %q to render unchanged, got %q", entries[i].String(), parsed[i].String())
+		}
+	}
+}
+
+func TestParseMdadmArrays(t *testing.T) {
+	output := "ARRAY /dev/md/0123456789abcdef metadata=1.2 name=nas:media UUID=1111:2222:3333:4444\n" +
+		"# a comment\n" +
+		"DEVICE partitions\n" +
+		"ARRAY /dev/md127 metadata=1.2 UUID=aaaa:bbbb:cccc:dddd\n"
+	arrays := ParseMdadmArrays(output)
+	if len(arrays) != 2 {
+		t.Fatalf("expected 2 arrays, got %+v", arrays)
+	}
+	if arrays[0].Device != "/dev/md/0123456789abcdef" || arrays[0].UUID() != "1111:2222:3333:4444" {
+		t.Fatalf("unexpected first array %+v", arrays[0])
+	}
+	if got := arrays[1].String(); got != "ARRAY /dev/md127 metadata=1.2 UUID=aaaa:bbbb:cccc:dddd" {
+		t.Fatalf("unexpected rendered line %q", got)
+	}
+}
+
+func TestReadManagedBlockAndDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdadm.conf")
+	if lines, err := ReadManagedBlock(path, MdadmConfBegin, MdadmConfEnd); err != nil || lines != nil {
+		t.Fatalf("expected no lines for a missing file, got %v %v", lines, err)
+	}
+
+	current := []string{"ARRAY /dev/md/a UUID=1", "ARRAY /dev/md/b UUID=2"}
+	if err := WriteManagedBlock(path, MdadmConfBegin, MdadmConfEnd, current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines, err := ReadManagedBlock(path, MdadmConfBegin, MdadmConfEnd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 || lines[1] != current[1] {
+		t.Fatalf("expected the written lines back, got %v", lines)
+	}
+
+	desired := []string{"ARRAY /dev/md/a UUID=1", "ARRAY /dev/md/c UUID=3"}
+	diff := DiffLines(lines, desired)
+	want := []string{" ARRAY /dev/md/a UUID=1", "-ARRAY /dev/md/b UUID=2", "+ARRAY /dev/md/c UUID=3"}
+	if strings.Join(diff, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diff %q", diff)
+	}
+}
+
+func TestDeviceMounts(t *testing.T) {
+	mounts := filepath.Join(t.TempDir(), "mounts")
+	data := "/dev/sda1 /boot ext4 rw 0 0\n/dev/sdab /data xfs rw 0 0\n/dev/nvme0n1p2 / ext4 rw 0 0\n/dev/nvme0n10 /scratch xfs rw 0 0\n"
+	if err := os.WriteFile(mounts, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := MountsPath
+	MountsPath = mounts
+	defer func() { MountsPath = previous }()
+
+	if got := DeviceMounts("/dev/sda"); len(got) != 1 || got[0] != "/boot" {
+		t.Fatalf("unexpected sda mounts: %v", got)
+	}
+	if got := DeviceMounts("/dev/nvme0n1"); len(got) != 1 || got[0] != "/" {
+		t.Fatalf("unexpected nvme0n1 mounts: %v", got)
+	}
+	if got := DeviceMounts("/dev/sdc"); len(got) != 0 {
+		t.Fatalf("expected no mounts, got %v", got)
+	}
+}
+
+func TestZeroFill(t *testing.T) {
+	device := filepath.Join(t.TempDir(), "disk")
+	if err := os.WriteFile(device, []byte(strings.Repeat("x", zeroChunk+10)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var reported []uint64
+	if err := ZeroFill(context.Background(), device, zeroChunk+10, func(written uint64) {
+		reported = append(reported, written)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(device)
+	if strings.Trim(string(data), "\x00") != "" {
+		t.Fatalf("device was not zeroed")
+	}
+	if len(reported) != 2 || reported[1] != zeroChunk+10 {
+		t.Fatalf("unexpected progress: %v", reported)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ZeroFill(ctx, device, zeroChunk, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseAtaSecurity(t *testing.T) {
+	out := "ATA device, with non-removable media\n" +
+		"Security: \n" +
+		"\tMaster password revision code = 65534\n" +
+		"\t\tsupported\n" +
+		"\tnot\tenabled\n" +
+		"\tnot\tlocked\n" +
+		"\tnot\tfrozen\n" +
+		"\tnot\texpired: security count\n" +
+		"\t\tsupported: enhanced erase\n" +
+		"Logical Unit WWN Device Identifier: 5000c500a1b2c3d4\n" +
+		"\t\tfrozen\n"
+	sec := ParseAtaSecurity(out)
+	if !sec.Supported || sec.Enabled || sec.Frozen || !sec.Enhanced {
+		t.Fatalf("unexpected security features: %+v", sec)
+	}
+}
+
+func TestParseNvmeFormatSupport(t *testing.T) {
+	supported, err := ParseNvmeFormatSupport([]byte(`{"vid":5197,"oacs":23}`))
+	if err != nil || !supported {
+		t.Fatalf("expected format support, got %v %v", supported, err)
+	}
+	supported, err = ParseNvmeFormatSupport([]byte(`{"oacs":5}`))
+	if err != nil || supported {
+		t.Fatalf("expected no format support, got %v %v", supported, err)
+	}
+	if _, err = ParseNvmeFormatSupport([]byte("not json")); !errors.Is(err, ErrInvalidSecurity) {
+		t.Fatalf("expected ErrInvalidSecurity, got %v", err)
+	}
+}
+
+func TestGptPartitionRange(t *testing.T) {
+	first, last, err := GptPartitionRange(1024*Megabyte, 512, 100*Megabyte)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != 2048 || last != 1890303 {
+		t.Fatalf("unexpected range %d-%d", first, last)
+	}
+	size, _ := GptPartitionSize(1024*Megabyte, 0, 100*Megabyte)
+	if size != 922*Megabyte {
+		t.Fatalf("expected a 922MiB partition, got %d", size)
+	}
+	if first, _, _ = GptPartitionRange(1024*Megabyte, 4096, 0); first != 256 {
+		t.Fatalf("expected a 1MiB aligned start on 4K sectors, got %d", first)
+	}
+	if _, _, err = GptPartitionRange(100*Megabyte, 512, 100*Megabyte); !errors.Is(err, ErrPartitionTooSmall) {
+		t.Fatalf("expected ErrPartitionTooSmall, got %v", err)
+	}
+}
+
+func TestPartitionPath(t *testing.T) {
+	cases := map[string]string{
+		"/dev/sda":     "/dev/sda1",
+		"/dev/nvme0n1": "/dev/nvme0n1p1",
+		"/dev/mmcblk0": "/dev/mmcblk0p1",
+	}
+	for device, want := range cases {
+		if got := PartitionPath(device, 1); got != want {
+			t.Errorf("PartitionPath(%q) = %q, want %q", device, got, want)
+		}
+	}
+}
+
+func TestParseSgdiskPartitionUUID(t *testing.T) {
+	out := "Partition GUID code: A19D880F-05FC-4D3B-A006-743F0F84911E (Linux RAID)\n" +
+		"Partition unique GUID: 5F0C2B9E-8C1A-4E77-9B3D-2A6F1E0D4C11\n" +
+		"First sector: 2048 (at 1024.0 KiB)\n"
+	id, err := ParseSgdiskPartitionUUID(out)
+	if err != nil || id != "5f0c2b9e-8c1a-4e77-9b3d-2a6f1e0d4c11" {
+		t.Fatalf("unexpected uuid %q: %v", id, err)
+	}
+	if _, err = ParseSgdiskPartitionUUID("Partition #1 does not exist.\n"); !errors.Is(err, ErrPartitionUuid) {
+		t.Fatalf("expected ErrPartitionUuid, got %v", err)
+	}
+}
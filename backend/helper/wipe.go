@@ -0,0 +1,214 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Wipe-related errors
+var (
+	ErrZeroFill        = errors.New("failed to zero-fill device")
+	ErrBlkdiscard      = errors.New("failed to discard device blocks")
+	ErrSecureErase     = errors.New("failed to secure erase device")
+	ErrSecurityQuery   = errors.New("failed to read drive security features")
+	ErrInvalidSecurity = errors.New("failed to parse drive security features")
+)
+
+// zeroChunk is the size of each write of a zero-fill.
+const zeroChunk = 4 * 1024 * 1024
+
+// ataErasePassword is the temporary user password ATA secure erase requires. The
+// drive clears it again when the erase completes.
+const ataErasePassword = "gonas"
+
+// DeviceMounts returns the mount points of device and of its partitions.
+func DeviceMounts(device string) []string {
+	data, err := os.ReadFile(MountsPath)
+	if err != nil {
+		return nil
+	}
+	var mounts []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		source := fields[0]
+		if resolved, err := filepath.EvalSymlinks(source); err == nil {
+			source = resolved
+		}
+		if isDeviceOrPartition(source, device) {
+			mounts = append(mounts, fields[1])
+		}
+	}
+	return mounts
+}
+
+// isDeviceOrPartition reports whether source is device or one of its numbered
+// partitions, such as /dev/sda1 or /dev/nvme0n1p1.
+func isDeviceOrPartition(source string, device string) bool {
+	suffix, ok := strings.CutPrefix(source, device)
+	if !ok {
+		return false
+	}
+	if suffix == "" {
+		return true
+	}
+	// Devices whose names end in a digit separate the partition number with a p
+	if last := device[len(device)-1]; last >= '0' && last <= '9' {
+		if suffix, ok = strings.CutPrefix(suffix, "p"); !ok {
+			return false
+		}
+	}
+	return suffix != "" && strings.Trim(suffix, "0123456789") == ""
+}
+
+// ZeroFill overwrites the first size bytes of device with zeros, calling report
+// with the number of bytes written after each chunk. It stops when ctx is canceled.
+func ZeroFill(ctx context.Context, device string, size uint64, report func(written uint64)) error {
+	f, err := os.OpenFile(device, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrZeroFill, err)
+	}
+	defer f.Close()
+
+	zeros := make([]byte, zeroChunk)
+	var written uint64
+	for written < size {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		chunk := zeros
+		if remaining := size - written; remaining < zeroChunk {
+			chunk = zeros[:remaining]
+		}
+		n, err := f.Write(chunk)
+		written += uint64(n)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrZeroFill, err)
+		}
+		if report != nil {
+			report(written)
+		}
+	}
+	if err = f.Sync(); err != nil {
+		return fmt.Errorf("%w: %v", ErrZeroFill, err)
+	}
+	return nil
+}
+
+// Blkdiscard tells the device that every block is unused, which erases SSDs that
+// return zeros for discarded blocks.
+func Blkdiscard(device string) error {
+	if out, err := exec.Command("blkdiscard", device).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrBlkdiscard, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AtaSecurity is the security feature set an ATA drive reports through hdparm -I.
+type AtaSecurity struct {
+	Supported bool
+	Enabled   bool
+	Frozen    bool
+	Enhanced  bool
+}
+
+// ParseAtaSecurity parses the Security section of hdparm -I output.
+func ParseAtaSecurity(out string) AtaSecurity {
+	var sec AtaSecurity
+	inSection := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Security:") {
+			inSection = true
+			continue
+		}
+		// The section ends at the next unindented heading
+		if inSection && line != "" && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " ") {
+			break
+		}
+		if !inSection {
+			continue
+		}
+		fields := strings.Fields(line)
+		negated := len(fields) > 0 && fields[0] == "not"
+		if negated {
+			fields = fields[1:]
+		}
+		text := strings.Join(fields, " ")
+		switch {
+		case text == "supported":
+			sec.Supported = !negated
+		case text == "enabled":
+			sec.Enabled = !negated
+		case text == "frozen":
+			sec.Frozen = !negated
+		case text == "supported: enhanced erase":
+			sec.Enhanced = !negated
+		}
+	}
+	return sec
+}
+
+// ReadAtaSecurity reads the security feature set of an ATA device.
+func ReadAtaSecurity(device string) (AtaSecurity, error) {
+	out, err := exec.Command("hdparm", "-I", device).Output()
+	if err != nil {
+		return AtaSecurity{}, fmt.Errorf("%w: %v", ErrSecurityQuery, err)
+	}
+	return ParseAtaSecurity(string(out)), nil
+}
+
+// AtaSecureErase sets a temporary user password and issues an ATA security
+// erase, enhanced when the drive supports it. The command blocks until the
+// drive finishes, which can take hours.
+func AtaSecureErase(device string, enhanced bool) error {
+	if out, err := exec.Command("hdparm", "--user-master", "u", "--security-set-pass", ataErasePassword, device).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrSecureErase, err, strings.TrimSpace(string(out)))
+	}
+	erase := "--security-erase"
+	if enhanced {
+		erase = "--security-erase-enhanced"
+	}
+	if out, err := exec.Command("hdparm", "--user-master", "u", erase, ataErasePassword, device).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrSecureErase, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// nvmeFormatSupported is the Optional Admin Command Support bit for Format NVM.
+const nvmeFormatSupported = 1 << 1
+
+// ParseNvmeFormatSupport reports whether nvme id-ctrl JSON output advertises the Format NVM command.
+func ParseNvmeFormatSupport(out []byte) (bool, error) {
+	var ctrl struct {
+		Oacs int `json:"oacs"`
+	}
+	if err := json.Unmarshal(out, &ctrl); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidSecurity, err)
+	}
+	return ctrl.Oacs&nvmeFormatSupported != 0, nil
+}
+
+// NvmeFormatSupported reports whether the controller of an NVMe device supports Format NVM.
+func NvmeFormatSupported(device string) (bool, error) {
+	out, err := exec.Command("nvme", "id-ctrl", device, "--output-format=json").Output()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrSecurityQuery, err)
+	}
+	return ParseNvmeFormatSupport(out)
+}
+
+// NvmeSecureErase formats an NVMe namespace with a user data erase.
+func NvmeSecureErase(device string) error {
+	if out, err := exec.Command("nvme", "format", device, "--ses=1", "--force").CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrSecureErase, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job-related errors
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrJobTargetBusy = errors.New("another job is already running for this target")
+	ErrJobPanicked   = errors.New("job panicked")
+)
+
+type State string
+
+var Running State = "running"
+var Succeeded State = "succeeded"
+var Failed State = "failed"
+var Interrupted State = "interrupted"
+var Canceled State = "canceled"
+
+type Job struct {
+	ID        string  `json:"id"`
+	Kind      string  `json:"kind"`
+	Target    string  `json:"target"`
+	State     State   `json:"state"`
+	Phase     string  `json:"phase"`
+	Progress  float64 `json:"progress"`
+	Error     string  `json:"error,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+// Done reports whether the job has reached a terminal state.
+func (j Job) Done() bool { return j.State != Running }
+
+// Store persists job snapshots so they survive restarts.
+type Store interface {
+	SaveJob(ctx context.Context, job Job) error
+}
+
+// Func is the body of a background job.
+type Func func(ctx context.Context, r *Reporter) error
+
+type Manager struct {
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	cancels  map[string]context.CancelFunc
+	canceled map[string]bool
+	store    Store
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewManager creates a job manager persisting through store, which may be nil.
+func NewManager(store Store) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		jobs:     make(map[string]*Job),
+		cancels:  make(map[string]context.CancelFunc),
+		canceled: make(map[string]bool),
+		store:    store,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// now returns the current UTC time in RFC3339Nano format.
+func now() string { return time.Now().UTC().Format(time.RFC3339Nano) }
+
+// Start registers a job for target and runs fn in the background.
+// Only one running job is allowed per target.
+func (m *Manager) Start(kind, target string, fn Func) (Job, error) {
+	m.mu.Lock()
+	if m.busy(target) {
+		m.mu.Unlock()
+		return Job{}, ErrJobTargetBusy
+	}
+	created := now()
+	job := &Job{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Target:    target,
+		State:     Running,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.jobs[job.ID] = job
+	m.cancels[job.ID] = cancel
+	snapshot := *job
+	m.mu.Unlock()
+
+	m.persist(snapshot)
+	go m.run(ctx, job.ID, fn)
+	return snapshot, nil
+}
+
+// call runs fn, turning a panic into an error so one job cannot take down the server.
+func call(ctx context.Context, r *Reporter, fn Func) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanicked, p)
+		}
+	}()
+	return fn(ctx, r)
+}
+
+// run executes fn and records its outcome on the job.
+func (m *Manager) run(ctx context.Context, id string, fn Func) {
+	err := call(ctx, &Reporter{m: m, id: id}, fn)
+
+	m.mu.Lock()
+	job := m.jobs[id]
+	m.cancels[id]()
+	delete(m.cancels, id)
+	canceled := m.canceled[id]
+	delete(m.canceled, id)
+	switch {
+	case err == nil:
+		job.State = Succeeded
+		job.Progress = 100
+	case canceled && errors.Is(err, context.Canceled):
+		job.State = Canceled
+		job.Error = err.Error()
+	case errors.Is(err, context.Canceled):
+		job.State = Interrupted
+		job.Error = err.Error()
+	default:
+		job.State = Failed
+		job.Error = err.Error()
+	}
+	job.UpdatedAt = now()
+	snapshot := *job
+	m.mu.Unlock()
+
+	if err != nil {
+		log.Printf("job %s (%s) for %s ended %s: %v", id, snapshot.Kind, snapshot.Target, snapshot.State, err)
+	}
+	m.persist(snapshot)
+}
+
+// persist writes the job snapshot to the store when one is configured.
+func (m *Manager) persist(job Job) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SaveJob(context.Background(), job); err != nil {
+		log.Printf("failed to persist job %s: %v", job.ID, err)
+	}
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// List returns snapshots of all known jobs.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, *j)
+	}
+	return list
+}
+
+// Running returns a snapshot of the job running for target, if any.
+func (m *Manager) Running(target string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if j.Target == target && !j.Done() {
+			return *j, true
+		}
+	}
+	return Job{}, false
+}
+
+// Cancel cancels the context of a running job.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cancel, ok := m.cancels[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	m.canceled[id] = true
+	cancel()
+	return nil
+}
+
+// Busy reports whether a job is running for target.
+func (m *Manager) Busy(target string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.busy(target)
+}
+
+// busy reports whether a job is running for target; the caller must hold m.mu.
+func (m *Manager) busy(target string) bool {
+	for _, j := range m.jobs {
+		if j.Target == target && !j.Done() {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore loads previously persisted jobs into memory.
+func (m *Manager) Restore(jobs ...Job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range jobs {
+		job := jobs[i]
+		m.jobs[job.ID] = &job
+	}
+}
+
+// Stop cancels the context handed to running jobs.
+func (m *Manager) Stop() {
+	m.cancel()
+}
+
+type Reporter struct {
+	m  *Manager
+	id string
+}
+
+// Update records the current phase and percentage of the job.
+// Progress is only persisted when the phase or whole percentage changes.
+func (r *Reporter) Update(phase string, percent float64) {
+	r.m.mu.Lock()
+	job := r.m.jobs[r.id]
+	changed := job.Phase != phase || math.Floor(job.Progress) != math.Floor(percent)
+	job.Phase = phase
+	job.Progress = percent
+	job.UpdatedAt = now()
+	snapshot := *job
+	r.m.mu.Unlock()
+
+	if changed {
+		r.m.persist(snapshot)
+	}
+}
+
+// Canceled reports whether the job was canceled through Cancel rather than by
+// the manager stopping.
+func (r *Reporter) Canceled() bool {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.m.canceled[r.id]
+}
@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu    sync.Mutex
+	saved map[string]Job
+}
+
+func (s *memoryStore) SaveJob(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved[job.ID] = job
+	return nil
+}
+
+func (s *memoryStore) get(id string) Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saved[id]
+}
+
+// waitDone polls until the job reaches a terminal state.
+func waitDone(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if job.Done() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestManagerRunsAndPersistsJobs(t *testing.T) {
+	store := &memoryStore{saved: make(map[string]Job)}
+	m := NewManager(store)
+
+	release := make(chan struct{})
+	job, err := m.Start("build", "pool-1", func(ctx context.Context, r *Reporter) error {
+		r.Update("resync", 42)
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = m.Start("build", "pool-1", nil); !errors.Is(err, ErrJobTargetBusy) {
+		t.Fatalf("expected ErrJobTargetBusy, got %v", err)
+	}
+
+	close(release)
+	done := waitDone(t, m, job.ID)
+	if done.State != Succeeded || done.Progress != 100 {
+		t.Fatalf("expected succeeded at 100%%, got %s at %.1f", done.State, done.Progress)
+	}
+	if saved := store.get(job.ID); saved.State != Succeeded || saved.Phase != "resync" {
+		t.Fatalf("expected persisted succeeded job in resync phase, got %+v", saved)
+	}
+}
+
+func TestManagerRecordsFailureAndInterruption(t *testing.T) {
+	m := NewManager(nil)
+
+	failed, _ := m.Start("build", "pool-1", func(ctx context.Context, r *Reporter) error {
+		return errors.New("mkfs failed")
+	})
+	if done := waitDone(t, m, failed.ID); done.State != Failed || done.Error != "mkfs failed" {
+		t.Fatalf("expected failed job with error, got %+v", done)
+	}
+
+	panicked, _ := m.Start("build", "pool-3", func(ctx context.Context, r *Reporter) error {
+		var pool *struct{ Name string }
+		_ = pool.Name
+		return nil
+	})
+	if done := waitDone(t, m, panicked.ID); done.State != Failed || !strings.HasPrefix(done.Error, ErrJobPanicked.Error()) {
+		t.Fatalf("expected a panicking job to fail, got %+v", done)
+	}
+
+	interrupted, _ := m.Start("build", "pool-2", func(ctx context.Context, r *Reporter) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Stop()
+	if done := waitDone(t, m, interrupted.ID); done.State != Interrupted {
+		t.Fatalf("expected interrupted job, got %+v", done)
+	}
+}
+
+func TestManagerCancelsSingleJob(t *testing.T) {
+	m := NewManager(nil)
+	canceled := make(chan bool, 2)
+	block := func(ctx context.Context, r *Reporter) error {
+		<-ctx.Done()
+		canceled <- r.Canceled()
+		return ctx.Err()
+	}
+	scrub, _ := m.Start("scrub", "pool-1", block)
+	other, _ := m.Start("rebuild", "pool-2", block)
+
+	running, ok := m.Running("pool-1")
+	if !ok || running.ID != scrub.ID {
+		t.Fatalf("expected scrub to be running for pool-1, got %+v", running)
+	}
+	if err := m.Cancel(scrub.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done := waitDone(t, m, scrub.ID); done.State != Canceled {
+		t.Fatalf("expected canceled, got %q", done.State)
+	}
+	if !<-canceled {
+		t.Fatal("expected the reporter to see the user cancel")
+	}
+	if !m.Busy("pool-2") {
+		t.Fatal("expected other job to keep running")
+	}
+	if err := m.Cancel(scrub.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("expected ErrJobNotFound for finished job, got %v", err)
+	}
+
+	m.Stop()
+	if done := waitDone(t, m, other.ID); done.State != Interrupted {
+		t.Fatalf("expected interrupted, got %q", done.State)
+	}
+	if <-canceled {
+		t.Fatal("expected the reporter not to treat a stop as a user cancel")
+	}
+}
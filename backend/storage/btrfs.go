@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"goNAS/helper"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BtrfsProfiles maps supported btrfs profiles to their minimum device count.
+var BtrfsProfiles = map[string]int{
+	"single":  1,
+	"raid1":   2,
+	"raid1c3": 3,
+	"raid10":  4,
+}
+
+const btrfsTypePrefix = "btrfs-"
+
+// Btrfs builds the pool as a native multi-device btrfs filesystem without md.
+type Btrfs struct {
+	Profile string
+}
+
+// ParseBtrfsType parses a "btrfs-<profile>" pool type value.
+func ParseBtrfsType(value string) (*Btrfs, error) {
+	profile, ok := strings.CutPrefix(value, btrfsTypePrefix)
+	if !ok {
+		return nil, ErrInvalidPoolType
+	}
+	if _, supported := BtrfsProfiles[profile]; !supported {
+		return nil, fmt.Errorf("%w: %s", ErrBtrfsProfile, profile)
+	}
+	return &Btrfs{Profile: profile}, nil
+}
+
+// Value returns the string representation of the btrfs profile.
+func (b *Btrfs) Value() string {
+	return btrfsTypePrefix + b.Profile
+}
+
+// Build creates a btrfs filesystem across the pool drives and mounts it.
+func (b *Btrfs) Build(ctx context.Context, p *Pool, report ProgressFunc) error {
+	if err := b.check(p); err != nil {
+		return err
+	}
+	p.Format = "btrfs"
+	sanitizedName, err := helper.SanitizeRaidName(p.Name)
+	if err != nil {
+		return err
+	}
+	p.Name = sanitizedName
+
+	report.report(PhaseMkfs, 0)
+	if err = helper.MkfsBtrfs(p.Name, b.Profile, p.MemberPaths()...); err != nil {
+		return err
+	}
+	if err = helper.ScanBtrfsDevices(); err != nil {
+		return err
+	}
+	report.report(PhaseMkfs, 100)
+
+	report.report(PhaseMount, 0)
+	if err = helper.CreateMountPoint(p.Uuid, p.FsDevice(), p.MountOptions...); err != nil {
+		return err
+	}
+	report.report(PhaseMount, 100)
+
+	p.MountPoint = fmt.Sprintf("%s/%s", helper.DefaultMountPoint, p.Uuid)
+	p.Status = Healthy
+	p.CalculateTotalCapacity()
+	p.CalculateAvailableCapacity()
+	return nil
+}
+
+// PlanBuild returns the commands Build would run for the pool.
+func (b *Btrfs) PlanBuild(p *Pool) ([]PlanStep, error) {
+	if err := b.check(p); err != nil {
+		return nil, err
+	}
+	name, err := helper.SanitizeRaidName(p.Name)
+	if err != nil {
+		return nil, err
+	}
+	mkfsArgs := append([]string{"-f", "-L", name, "-d", b.Profile, "-m", b.Profile}, p.MemberPaths()...)
+	steps := []PlanStep{
+		{Phase: PhaseMkfs, Command: "mkfs.btrfs", Args: mkfsArgs},
+		{Phase: PhaseMkfs, Command: "btrfs", Args: []string{"device", "scan"}},
+	}
+	return append(steps, planMount(p, p.FsDevice())...), nil
+}
+
+// check verifies the profile, drive count and format before a build.
+func (b *Btrfs) check(p *Pool) error {
+	minimum, ok := BtrfsProfiles[b.Profile]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBtrfsProfile, b.Profile)
+	}
+	if len(p.Members()) < minimum {
+		return fmt.Errorf("%w: btrfs %s requires at least %d drives", ErrInsufficientDrives, b.Profile, minimum)
+	}
+	if p.Format != "" && p.Format != "btrfs" {
+		return fmt.Errorf("%w: btrfs pools must use the btrfs format", ErrUnsupportedFormat)
+	}
+	return nil
+}
+
+// Assemble registers the member devices so the filesystem can be mounted.
+func (b *Btrfs) Assemble(p *Pool) error {
+	return helper.ScanBtrfsDevices()
+}
+
+// Stop has nothing to release once the filesystem is unmounted.
+func (b *Btrfs) Stop(p *Pool) error {
+	return nil
+}
+
+// Wipe erases the btrfs signatures from the member devices.
+func (b *Btrfs) Wipe(p *Pool) error {
+	return helper.WipeSignatures(p.MemberPaths()...)
+}
+
+// CheckHealth derives pool health from the error counters of `btrfs device stats`.
+func (b *Btrfs) CheckHealth(p *Pool) PoolHealth {
+	if !helper.IsMounted(p.MountPoint) {
+		return PoolHealth{Status: Offline, Reason: "filesystem is not mounted", Members: make(map[string]MemberState)}
+	}
+	// device stats exits non-zero when counters are set; the output is still complete
+	out, err := exec.Command("btrfs", "device", "stats", p.MountPoint).Output()
+	if len(out) == 0 && err != nil {
+		return PoolHealth{Status: Offline, Reason: fmt.Sprintf("btrfs device stats failed: %v", err), Members: make(map[string]MemberState)}
+	}
+	stats, err := ParseBtrfsDeviceStats(strings.NewReader(string(out)))
+	if err != nil {
+		return PoolHealth{Status: Offline, Reason: err.Error(), Members: make(map[string]MemberState)}
+	}
+	return EvaluateBtrfs(p, stats)
+}
+
+type BtrfsDeviceStats struct {
+	Device   string            `json:"device"`
+	Counters map[string]uint64 `json:"counters"`
+}
+
+// Errors returns the sum of all error counters of the device.
+func (s *BtrfsDeviceStats) Errors() uint64 {
+	var total uint64
+	for _, v := range s.Counters {
+		total += v
+	}
+	return total
+}
+
+var btrfsStatPattern = regexp.MustCompile(`^\[(.+)\]\.(\w+)\s+(\d+)$`)
+
+// ParseBtrfsDeviceStats parses `btrfs device stats` output keyed by device.
+// Missing devices are reported by btrfs as "devid:N".
+func ParseBtrfsDeviceStats(r io.Reader) (map[string]*BtrfsDeviceStats, error) {
+	stats := make(map[string]*BtrfsDeviceStats)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := btrfsStatPattern.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		device, ok := stats[m[1]]
+		if !ok {
+			device = &BtrfsDeviceStats{Device: m[1], Counters: make(map[string]uint64)}
+			stats[m[1]] = device
+		}
+		value, err := strconv.ParseUint(m[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		device.Counters[m[2]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// EvaluateBtrfs derives pool health from parsed device stats. Member states are
+// keyed by kernel device name; members absent from the stats are missing and
+// members with non-zero error counters are faulty.
+func EvaluateBtrfs(p *Pool, stats map[string]*BtrfsDeviceStats) PoolHealth {
+	health := PoolHealth{Status: Healthy, Members: make(map[string]MemberState)}
+	present := make(map[string]*BtrfsDeviceStats)
+	for device, s := range stats {
+		present[filepath.Base(device)] = s
+	}
+
+	members := p.Members()
+	var missing, faulty []string
+	for _, d := range members {
+		s, ok := present[d.Drive.Name]
+		switch {
+		case !ok:
+			missing = append(missing, d.Drive.Name)
+		case s.Errors() > 0:
+			health.Members[d.Drive.Name] = MemberFaulty
+			faulty = append(faulty, d.Drive.Name)
+		default:
+			health.Members[d.Drive.Name] = MemberInSync
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(faulty)
+
+	switch {
+	case len(missing) > 0:
+		health.Status = Degraded
+		health.Reason = fmt.Sprintf("%d of %d devices present", len(members)-len(missing), len(members))
+	case len(faulty) > 0:
+		health.Status = Degraded
+		health.Reason = fmt.Sprintf("device errors on %s", strings.Join(faulty, ", "))
+	}
+	return health
+}
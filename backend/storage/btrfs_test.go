@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseBtrfsFixture parses captured `btrfs device stats` output from testdata.
+func parseBtrfsFixture(t *testing.T, name string) map[string]*BtrfsDeviceStats {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "btrfs", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	defer f.Close()
+	stats, err := ParseBtrfsDeviceStats(f)
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	return stats
+}
+
+func TestEvaluateBtrfsFixtures(t *testing.T) {
+	pool, err := NewPool("btrfs", &Btrfs{Profile: "raid1"}, "btrfs",
+		&DriveInfo{Name: "sdb", Uuid: "a", Path: "/dev/sdb"},
+		&DriveInfo{Name: "sdc", Uuid: "b", Path: "/dev/sdc"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.MdDevice != "" || pool.FsDevice() != "/dev/sdb" {
+		t.Fatalf("expected no md device and /dev/sdb as fs device, got %q %q", pool.MdDevice, pool.FsDevice())
+	}
+
+	tests := []struct {
+		fixture string
+		status  Status
+		sdc     MemberState
+	}{
+		{fixture: "stats_clean.txt", status: Healthy, sdc: MemberInSync},
+		{fixture: "stats_errors.txt", status: Degraded, sdc: MemberFaulty},
+		{fixture: "stats_missing.txt", status: Degraded, sdc: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			health := EvaluateBtrfs(pool, parseBtrfsFixture(t, tt.fixture))
+			if health.Status != tt.status {
+				t.Fatalf("expected %q, got %q (%s)", tt.status, health.Status, health.Reason)
+			}
+			if health.Members["sdc"] != tt.sdc {
+				t.Fatalf("expected sdc %q, got %q", tt.sdc, health.Members["sdc"])
+			}
+		})
+	}
+
+	stats := parseBtrfsFixture(t, "stats_errors.txt")
+	if stats["/dev/sdc"].Errors() != 16 {
+		t.Fatalf("expected 16 errors on sdc, got %d", stats["/dev/sdc"].Errors())
+	}
+}
+
+func TestParseBtrfsTypeRejectsUnknownProfile(t *testing.T) {
+	if _, err := ParseBtrfsType("btrfs-raid5"); !errors.Is(err, ErrBtrfsProfile) {
+		t.Fatalf("expected ErrBtrfsProfile, got %v", err)
+	}
+	if _, err := ParsePoolType("btrfs-raid5"); !errors.Is(err, ErrInvalidPoolType) {
+		t.Fatalf("expected ErrInvalidPoolType, got %v", err)
+	}
+}
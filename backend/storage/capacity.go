@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"fmt"
+	"goNAS/helper"
+	"sort"
+)
+
+// CapacityPlan breaks the raw size of a set of drives down into the space a
+// pool type makes usable, the space spent on redundancy and the space left
+// unused because the drives differ in size. Filesystem overhead is not included.
+type CapacityPlan struct {
+	Drives         int    `json:"drives"`
+	RawBytes       uint64 `json:"rawBytes"`
+	UsableBytes    uint64 `json:"usableBytes"`
+	ParityBytes    uint64 `json:"parityBytes"`
+	WastedBytes    uint64 `json:"wastedBytes"`
+	FaultTolerance int    `json:"faultTolerance"`
+}
+
+// capacityPlanner is implemented by pool types that can estimate their usable space.
+type capacityPlanner interface {
+	PlanCapacity(sizes []uint64) (CapacityPlan, error)
+}
+
+// PlanCapacity estimates the capacity of a pool of the given type built from drives of the given sizes.
+func PlanCapacity(poolType PoolType, sizes []uint64) (CapacityPlan, error) {
+	planner, ok := poolType.(capacityPlanner)
+	if !ok {
+		return CapacityPlan{}, ErrInvalidPoolType
+	}
+	return planner.PlanCapacity(sizes)
+}
+
+// DriveSizes returns the sizes of drives in bytes.
+func DriveSizes(drives []*DriveInfo) []uint64 {
+	sizes := make([]uint64, 0, len(drives))
+	for _, d := range drives {
+		sizes = append(sizes, d.SizeBytes)
+	}
+	return sizes
+}
+
+// sumSizes returns the total of sizes.
+func sumSizes(sizes []uint64) uint64 {
+	var total uint64
+	for _, s := range sizes {
+		total += s
+	}
+	return total
+}
+
+// concatPlan uses every byte of every drive for data.
+func concatPlan(sizes []uint64) CapacityPlan {
+	raw := sumSizes(sizes)
+	return CapacityPlan{Drives: len(sizes), RawBytes: raw, UsableBytes: raw}
+}
+
+// uniformPlan uses the smallest drive size on every member, dataDrives of
+// which hold data and the rest redundancy.
+func uniformPlan(sizes []uint64, dataDrives int, tolerance int) CapacityPlan {
+	smallest := sizes[0]
+	for _, s := range sizes {
+		smallest = min(smallest, s)
+	}
+	raw := sumSizes(sizes)
+	n := uint64(len(sizes))
+	return CapacityPlan{
+		Drives:         len(sizes),
+		RawBytes:       raw,
+		UsableBytes:    smallest * uint64(dataDrives),
+		ParityBytes:    smallest * (n - uint64(dataDrives)),
+		WastedBytes:    raw - smallest*n,
+		FaultTolerance: tolerance,
+	}
+}
+
+// copiesPlan keeps copies of every chunk on distinct drives, as btrfs does.
+// The usable space is limited either by the total or, when a few drives are
+// much larger than the rest, by what the smaller drives can mirror.
+func copiesPlan(sizes []uint64, copies int) CapacityPlan {
+	sorted := append([]uint64{}, sizes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	raw := sumSizes(sorted)
+
+	usable := raw / uint64(copies)
+	remaining := raw
+	for k := 0; k < copies-1 && k < len(sorted); k++ {
+		remaining -= sorted[k]
+		usable = min(usable, remaining/uint64(copies-k-1))
+	}
+	return CapacityPlan{
+		Drives:         len(sizes),
+		RawBytes:       raw,
+		UsableBytes:    usable,
+		ParityBytes:    usable * uint64(copies-1),
+		WastedBytes:    raw - usable*uint64(copies),
+		FaultTolerance: copies - 1,
+	}
+}
+
+// PlanCapacity estimates the usable space of the raid level across drives of the given sizes.
+func (r *Raid) PlanCapacity(sizes []uint64) (CapacityPlan, error) {
+	if err := helper.CheckRaidLevel(r.Level, len(sizes)); err != nil {
+		return CapacityPlan{}, err
+	}
+	n := len(sizes)
+	switch r.Level {
+	case 0:
+		// md raid0 stripes over zones, so differently sized drives are used in full
+		return concatPlan(sizes), nil
+	case 1:
+		return uniformPlan(sizes, 1, n-1), nil
+	case 5:
+		return uniformPlan(sizes, n-1, 1), nil
+	case 6:
+		return uniformPlan(sizes, n-2, 2), nil
+	default:
+		return uniformPlan(sizes, n/2, 1), nil
+	}
+}
+
+// PlanCapacity estimates the usable space of a linear array, the sum of its drives.
+func (l *Linear) PlanCapacity(sizes []uint64) (CapacityPlan, error) {
+	if len(sizes) == 0 {
+		return CapacityPlan{}, ErrInsufficientDrives
+	}
+	return concatPlan(sizes), nil
+}
+
+// PlanCapacity estimates the usable space of an N-way mirror, its smallest drive.
+func (m *Mirror) PlanCapacity(sizes []uint64) (CapacityPlan, error) {
+	return (&Raid{Level: 1}).PlanCapacity(sizes)
+}
+
+// PlanCapacity estimates the usable space of the btrfs profile across drives of the given sizes.
+func (b *Btrfs) PlanCapacity(sizes []uint64) (CapacityPlan, error) {
+	minimum, ok := BtrfsProfiles[b.Profile]
+	if !ok {
+		return CapacityPlan{}, fmt.Errorf("%w: %s", ErrBtrfsProfile, b.Profile)
+	}
+	if len(sizes) < minimum {
+		return CapacityPlan{}, fmt.Errorf("%w: btrfs %s requires at least %d drives", ErrInsufficientDrives, b.Profile, minimum)
+	}
+	switch b.Profile {
+	case "single":
+		return concatPlan(sizes), nil
+	case "raid1c3":
+		return copiesPlan(sizes, 3), nil
+	default:
+		return copiesPlan(sizes, 2), nil
+	}
+}
+
+// estimateCapacity sets the total capacity of an unbuilt pool from its drives.
+// Built pools read theirs from the device.
+func (p *Pool) estimateCapacity() {
+	if p.IsBuilt() || p.Type == nil {
+		return
+	}
+	members := p.Members()
+	sizes := make([]uint64, 0, len(members))
+	for _, d := range members {
+		sizes = append(sizes, p.memberSize(d.Drive))
+	}
+	plan, err := PlanCapacity(p.Type, sizes)
+	if err != nil {
+		plan = CapacityPlan{}
+	}
+	p.TotalCapacity = plan.UsableBytes
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"testing"
+)
+
+func TestPlanCapacity(t *testing.T) {
+	g := helper.Gigabyte
+	mixed := []uint64{1000 * g, 2000 * g, 2000 * g, 4000 * g}
+	tests := []struct {
+		name      string
+		poolType  PoolType
+		sizes     []uint64
+		usable    uint64
+		parity    uint64
+		wasted    uint64
+		tolerance int
+	}{
+		{name: "standard", poolType: Standard, sizes: mixed, usable: 9000 * g},
+		{name: "mirrored", poolType: Mirrored, sizes: []uint64{1000 * g, 2000 * g}, usable: 1000 * g, parity: 1000 * g, wasted: 1000 * g, tolerance: 1},
+		{name: "raid0", poolType: &Raid{Level: 0}, sizes: mixed, usable: 9000 * g},
+		{name: "raid5", poolType: &Raid{Level: 5}, sizes: mixed, usable: 3000 * g, parity: 1000 * g, wasted: 5000 * g, tolerance: 1},
+		{name: "raid6", poolType: &Raid{Level: 6}, sizes: mixed, usable: 2000 * g, parity: 2000 * g, wasted: 5000 * g, tolerance: 2},
+		{name: "raid10", poolType: &Raid{Level: 10}, sizes: mixed, usable: 2000 * g, parity: 2000 * g, wasted: 5000 * g, tolerance: 1},
+		{name: "btrfs raid1 mixed", poolType: &Btrfs{Profile: "raid1"}, sizes: mixed, usable: 4500 * g, parity: 4500 * g, tolerance: 1},
+		{name: "btrfs raid1 one large drive", poolType: &Btrfs{Profile: "raid1"}, sizes: []uint64{1000 * g, 1000 * g, 4000 * g}, usable: 2000 * g, parity: 2000 * g, wasted: 2000 * g, tolerance: 1},
+		{name: "btrfs raid1c3", poolType: &Btrfs{Profile: "raid1c3"}, sizes: mixed, usable: 2500 * g, parity: 5000 * g, wasted: 1500 * g, tolerance: 2},
+		{name: "btrfs single", poolType: &Btrfs{Profile: "single"}, sizes: mixed, usable: 9000 * g},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanCapacity(tt.poolType, tt.sizes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if plan.UsableBytes != tt.usable || plan.ParityBytes != tt.parity || plan.WastedBytes != tt.wasted {
+				t.Fatalf("unexpected plan %+v", plan)
+			}
+			if plan.FaultTolerance != tt.tolerance {
+				t.Fatalf("expected tolerance %d, got %d", tt.tolerance, plan.FaultTolerance)
+			}
+			if plan.UsableBytes+plan.ParityBytes+plan.WastedBytes != plan.RawBytes {
+				t.Fatalf("plan does not account for every byte: %+v", plan)
+			}
+		})
+	}
+}
+
+func TestPlanCapacityRejectsTooFewDrives(t *testing.T) {
+	if _, err := PlanCapacity(&Raid{Level: 5}, []uint64{helper.Gigabyte, helper.Gigabyte}); !errors.Is(err, helper.ErrRaid5RequiresDrives) {
+		t.Fatalf("expected ErrRaid5RequiresDrives, got %v", err)
+	}
+	if _, err := PlanCapacity(&Btrfs{Profile: "raid1c3"}, []uint64{helper.Gigabyte}); !errors.Is(err, ErrInsufficientDrives) {
+		t.Fatalf("expected ErrInsufficientDrives, got %v", err)
+	}
+	if _, err := PlanCapacity(nil, nil); !errors.Is(err, ErrInvalidPoolType) {
+		t.Fatalf("expected ErrInvalidPoolType, got %v", err)
+	}
+}
+
+func TestNewPoolEstimatesCapacity(t *testing.T) {
+	pool, _ := NewPool("estimate", &Raid{Level: 1}, "ext4",
+		&DriveInfo{Name: "sda", Uuid: "a", SizeBytes: 500 * helper.Gigabyte},
+		&DriveInfo{Name: "sdb", Uuid: "b", SizeBytes: 750 * helper.Gigabyte},
+	)
+	if pool.TotalCapacity != 500*helper.Gigabyte {
+		t.Fatalf("expected 500G usable, got %d", pool.TotalCapacity)
+	}
+	pool.AddDrives(&DriveInfo{Name: "sdc", Uuid: "c", SizeBytes: 250 * helper.Gigabyte})
+	if pool.TotalCapacity != 250*helper.Gigabyte {
+		t.Fatalf("expected 250G usable after adding a smaller drive, got %d", pool.TotalCapacity)
+	}
+}
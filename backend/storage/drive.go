@@ -10,11 +10,20 @@ import (
 // CreationTime returns the current UTC time in RFC3339Nano format.
 func CreationTime() string { return time.Now().UTC().Format(time.RFC3339Nano) }
 
+type DriveRole string
+
+var RoleActive DriveRole = "active"
+var RoleSpare DriveRole = "spare"
+
 type AdoptedDrive struct {
-	Drive     *DriveInfo `json:"drive"`
-	Uuid      string     `json:"uuid"`
-	PoolID    string     `json:"poolID"`
-	CreatedAt string     `json:"createdAt"`
+	Drive     *DriveInfo  `json:"drive"`
+	Uuid      string      `json:"uuid"`
+	PoolID    string      `json:"poolID"`
+	Role      DriveRole   `json:"role"`
+	State     MemberState `json:"state,omitempty"`
+	PartUuid  string      `json:"partUuid,omitempty"`
+	Missing   bool        `json:"missing"`
+	CreatedAt string      `json:"createdAt"`
 }
 
 // NewAdoptedDrive creates an adopted drive with a stable UUID.
@@ -29,6 +38,7 @@ func NewAdoptedDrive(drive *DriveInfo) *AdoptedDrive {
 	return &AdoptedDrive{
 		Drive:     drive,
 		Uuid:      uuid,
+		Role:      RoleActive,
 		CreatedAt: CreationTime(),
 	}
 }
@@ -50,16 +60,40 @@ func (a *AdoptedDrive) GetPoolID() string   { return a.PoolID }
 // SetPoolID associates the adopted drive with a pool UUID.
 func (a *AdoptedDrive) SetPoolID(id string) { a.PoolID = id }
 
+// GetRole returns whether the drive is an active member or a spare.
+func (a *AdoptedDrive) GetRole() DriveRole { return a.Role }
+// SetRole sets whether the drive is an active member or a spare.
+func (a *AdoptedDrive) SetRole(role DriveRole) { a.Role = role }
+// IsSpare reports whether the drive is held as a hot spare.
+func (a *AdoptedDrive) IsSpare() bool { return a.Role == RoleSpare }
+
+// GetState returns the member state reported by the array.
+func (a *AdoptedDrive) GetState() MemberState { return a.State }
+// SetState records the member state reported by the array.
+func (a *AdoptedDrive) SetState(state MemberState) { a.State = state }
+
+// SystemDriveMinSize is the smallest block device treated as a system drive.
+var SystemDriveMinSize = 1 * helper.Gigabyte
+
 // GetSystemDrives returns system drives filtered by name and minimum size.
 func GetSystemDrives(names ...string) []*DriveInfo {
 	drives, _ := GetDrives()
 	drives = FilterFor(DriveFilter{
 		Names:   names,
-		MinSize: 1 * helper.Gigabyte,
+		MinSize: SystemDriveMinSize,
 	}, drives...)
 	return drives
 }
 
+// GetSystemDrive returns the block device name if it qualifies as a system drive.
+func GetSystemDrive(name string) (*DriveInfo, bool) {
+	drive, err := ReadDrive(name)
+	if err != nil {
+		return nil, false
+	}
+	return drive, len(FilterFor(DriveFilter{MinSize: SystemDriveMinSize}, drive)) == 1
+}
+
 // GetSystemDriveMap returns a map of system drives keyed by drive key string.
 func GetSystemDriveMap(names ...string) map[string]*DriveInfo {
 	drives := GetSystemDrives(names...)
@@ -9,29 +9,121 @@ var (
 	ErrDriveNotFoundOrInUse = errors.New("drive not found or already in use")
 	ErrDuplicateDriveKey    = errors.New("duplicate drive key found")
 	ErrNoDrivesToRemove     = errors.New("no drives to remove")
+	ErrDriveNotInPool       = errors.New("drive is not a member of the pool")
+	ErrDriveNotSpare        = errors.New("drive is not a spare")
+	ErrSpareTooSmall        = errors.New("spare is smaller than the pool members")
+	ErrDriveInPool          = errors.New("drive belongs to a pool")
+	ErrDriveMissing         = errors.New("drive is not attached")
 )
 
 // Pool-related errors
 var (
-	ErrInsufficientDrives = errors.New("insufficient drives for the requested pool type")
-	ErrInvalidPoolType    = errors.New("invalid pool type")
-	ErrInvalidStatus      = errors.New("invalid status")
-	ErrInvalidRequestBody = errors.New("invalid request body")
-	ErrPoolAlreadyExists  = errors.New("pool with the same UUID already exists")
-	ErrPoolFormatRequired = errors.New("pool format must be specified")
-	ErrPoolInUse          = errors.New("pool is currently in use")
-	ErrPoolNotFound       = errors.New("pool not found")
-	ErrPoolNotInMemory    = errors.New("pool not found in memory")
-	ErrPoolNotOffline     = errors.New("cannot delete a pool that is not offline")
-	ErrPoolCapacityRead   = errors.New("failed to read pool capacity")
-	ErrPoolCapacityParse  = errors.New("failed to parse pool capacity")
-	ErrPoolDeleteUnmount  = errors.New("failed to unmount pool")
-	ErrPoolDeleteRmdir    = errors.New("failed to remove pool mount directory")
-	ErrPoolDeleteRemove   = errors.New("failed to remove pool md device")
-	ErrPoolDeleteStop     = errors.New("failed to stop pool md device")
-	ErrPoolDeleteZeroSB   = errors.New("failed to clear pool superblocks")
-	ErrUnsupportedFormat  = errors.New("unsupported pool format")
-	ErrUuidTooShort       = errors.New("uuid length is less than requested length")
+	ErrInsufficientDrives   = errors.New("insufficient drives for the requested pool type")
+	ErrInvalidPoolType      = errors.New("invalid pool type")
+	ErrBtrfsProfile         = errors.New("unsupported btrfs profile")
+	ErrInvalidStatus        = errors.New("invalid status")
+	ErrInvalidRequestBody   = errors.New("invalid request body")
+	ErrPoolAlreadyExists    = errors.New("pool with the same UUID already exists")
+	ErrPoolFormatRequired   = errors.New("pool format must be specified")
+	ErrPoolInUse            = errors.New("pool is currently in use")
+	ErrPoolNotFound         = errors.New("pool not found")
+	ErrPoolNotInMemory      = errors.New("pool not found in memory")
+	ErrPoolNotOffline       = errors.New("cannot delete a pool that is not offline")
+	ErrPoolNotBuilt         = errors.New("pool has not been built")
+	ErrPoolAlreadyBuilt     = errors.New("pool has already been built")
+	ErrPoolAssemble         = errors.New("failed to assemble pool md device")
+	ErrNoRedundancy         = errors.New("pool has no redundancy; a replaced drive cannot be rebuilt")
+	ErrDriveReplace         = errors.New("failed to replace pool drive")
+	ErrGrowUnsupported      = errors.New("pool type does not support adding drives")
+	ErrPoolGrow             = errors.New("failed to grow pool md device")
+	ErrMigrationUnsupported = errors.New("unsupported raid level migration")
+	ErrMigrationPartial     = errors.New("raid level migration stopped part way")
+	ErrPoolMigrate          = errors.New("failed to migrate pool raid level")
+	ErrPoolSpare            = errors.New("failed to change pool spares")
+	ErrScrubUnsupported     = errors.New("pool has no redundancy to scrub")
+	ErrInvalidScrubAction   = errors.New("scrub action must be check or repair")
+	ErrInvalidScrubSchedule = errors.New("scrub schedule must be off or a duration of at least 1h")
+	ErrNoScrubRunning       = errors.New("no scrub is running for the pool")
+	ErrPoolSyncing          = errors.New("pool array is already syncing")
+	ErrPoolScrub            = errors.New("failed to change pool sync action")
+	ErrInvalidFsckMode      = errors.New("fsck mode must be check or repair")
+	ErrFsckUnsupported      = errors.New("filesystem check is not supported for this pool")
+	ErrPoolMounted          = errors.New("pool must be unmounted or offline")
+	ErrFsckFailed           = errors.New("filesystem check failed to run")
+	ErrLvmUnsupported       = errors.New("pool is not an lvm volume group")
+	ErrPoolVolume           = errors.New("failed to change pool volume")
+	ErrPoolHasVolumes       = errors.New("pool still has volumes; delete them first")
+	ErrPoolCapacityRead     = errors.New("failed to read pool capacity")
+	ErrPoolCapacityParse    = errors.New("failed to parse pool capacity")
+	ErrPoolDeleteUnmount    = errors.New("failed to unmount pool")
+	ErrPoolDeleteRmdir      = errors.New("failed to remove pool mount directory")
+	ErrPoolDeleteStop       = errors.New("failed to stop pool md device")
+	ErrPoolDeleteZeroSB     = errors.New("failed to clear pool superblocks")
+	ErrUnsupportedFormat    = errors.New("unsupported pool format")
+	ErrPartitionUnsupported = errors.New("pool type does not support partitioned members")
+	ErrUuidTooShort         = errors.New("uuid length is less than requested length")
+)
+
+// Volume-related errors
+var (
+	ErrVolumeNotFound = errors.New("volume not found")
+	ErrVolumeExists   = errors.New("volume with the same name already exists")
+	ErrVolumeSize     = errors.New("volume size must be greater than zero")
+	ErrVolumeShrink   = errors.New("volumes can only grow")
+)
+
+// Subvolume-related errors
+var (
+	ErrSubvolumeUnsupported  = errors.New("pool filesystem does not support subvolumes")
+	ErrSubvolumeNotFound     = errors.New("subvolume not found")
+	ErrSubvolumeExists       = errors.New("subvolume with the same name already exists")
+	ErrSnapshotNotFound      = errors.New("snapshot not found")
+	ErrSnapshotCreate        = errors.New("failed to create snapshot")
+	ErrInvalidSnapshotPolicy = errors.New("snapshot retention counts must not be negative")
+)
+
+// Encryption-related errors
+var (
+	ErrEncryptionUnsupported = errors.New("pool type does not support encryption")
+	ErrPoolNotEncrypted      = errors.New("pool is not encrypted")
+	ErrPoolLocked            = errors.New("pool is locked")
+	ErrPoolEncrypt           = errors.New("failed to set up pool encryption")
+)
+
+// Mount-related errors
+var (
+	ErrInvalidMountOption      = errors.New("invalid mount option")
+	ErrMountOptionsUnsupported = errors.New("mount options apply to volumes, not lvm pools")
+)
+
+// Wipe-related errors
+var (
+	ErrInvalidWipeMode  = errors.New("wipe mode must be signatures, zero, discard or secure-erase")
+	ErrWipeUnsupported  = errors.New("drive does not support the wipe mode")
+	ErrDriveMounted     = errors.New("drive or one of its partitions is mounted")
+	ErrDriveHeld        = errors.New("drive is in use by another block device")
+	ErrWipeTokenInvalid = errors.New("wipe confirmation token does not match a prepared wipe")
+	ErrWipeTokenExpired = errors.New("wipe confirmation token has expired")
+)
+
+// SMART-related errors
+var (
+	ErrSmartRead        = errors.New("failed to read smart data")
+	ErrSmartParse       = errors.New("failed to parse smartctl output")
+	ErrSmartUnavailable = errors.New("drive does not report smart health")
+)
+
+// I/O statistics errors
+var (
+	ErrDiskStatParse = errors.New("failed to parse block device stat")
+)
+
+// Hotplug-related errors
+var (
+	ErrUeventParse       = errors.New("failed to parse uevent")
+	ErrUeventSocket      = errors.New("failed to open uevent socket")
+	ErrUeventRead        = errors.New("failed to read uevent")
+	ErrUeventUnsupported = errors.New("uevents are not supported on this platform")
 )
 
 // Generic errors
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goNAS/helper"
+	"os/exec"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var PhaseFsck Phase = "fsck"
+
+type FsckMode string
+
+var FsckCheck FsckMode = "check"
+var FsckRepair FsckMode = "repair"
+
+type FsckResult string
+
+var FsckRunning FsckResult = "running"
+var FsckClean FsckResult = "clean"
+var FsckFixed FsckResult = "fixed"
+var FsckErrors FsckResult = "errors"
+var FsckCanceled FsckResult = "canceled"
+var FsckFailed FsckResult = "failed"
+var FsckInterrupted FsckResult = "interrupted"
+
+type Fsck struct {
+	ID          string     `json:"id"`
+	PoolID      string     `json:"poolID"`
+	Mode        FsckMode   `json:"mode"`
+	Tool        string     `json:"tool"`
+	StartedAt   string     `json:"startedAt"`
+	EndedAt     string     `json:"endedAt,omitempty"`
+	ExitCode    int        `json:"exitCode"`
+	Meaning     string     `json:"meaning,omitempty"`
+	ErrorsFound int        `json:"errorsFound"`
+	ErrorsFixed int        `json:"errorsFixed"`
+	Result      FsckResult `json:"result"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// FsckReport is the parsed outcome of a filesystem check tool run.
+type FsckReport struct {
+	ExitCode    int
+	Meaning     string
+	ErrorsFound int
+	ErrorsFixed int
+	// Uncorrected is set when errors remain on the filesystem.
+	Uncorrected bool
+	// Failed is set when the tool could not complete the check.
+	Failed bool
+}
+
+// NewFsck records the start of a filesystem check of the pool.
+func NewFsck(poolID string, mode FsckMode, tool string) *Fsck {
+	return &Fsck{
+		ID:        uuid.New().String(),
+		PoolID:    poolID,
+		Mode:      mode,
+		Tool:      tool,
+		StartedAt: CreationTime(),
+		Result:    FsckRunning,
+	}
+}
+
+// Finish records the end of the check, its parsed report and outcome. A
+// context cancellation is recorded as canceled only when the user canceled it,
+// otherwise the server stopped and it is interrupted.
+func (f *Fsck) Finish(report FsckReport, err error, canceled bool) {
+	f.EndedAt = CreationTime()
+	f.ExitCode = report.ExitCode
+	f.Meaning = report.Meaning
+	f.ErrorsFound = report.ErrorsFound
+	f.ErrorsFixed = report.ErrorsFixed
+	switch {
+	case canceled && errors.Is(err, context.Canceled):
+		f.Result = FsckCanceled
+	case errors.Is(err, context.Canceled):
+		f.Result = FsckInterrupted
+	case err != nil:
+		f.Result = FsckFailed
+		f.Error = err.Error()
+	case report.Uncorrected:
+		f.Result = FsckErrors
+	case report.ErrorsFixed > 0:
+		f.Result = FsckFixed
+	default:
+		f.Result = FsckClean
+	}
+}
+
+// ParseFsckMode parses a fsck mode, defaulting to check.
+func ParseFsckMode(value string) (FsckMode, error) {
+	switch FsckMode(value) {
+	case "", FsckCheck:
+		return FsckCheck, nil
+	case FsckRepair:
+		return FsckRepair, nil
+	default:
+		return "", ErrInvalidFsckMode
+	}
+}
+
+// FsckCommand returns the tool and arguments that check or repair a filesystem
+// of the given format on device. Checks never modify the filesystem.
+func FsckCommand(format string, mode FsckMode, device string) (string, []string, error) {
+	switch {
+	case format == "ext4" && mode == FsckCheck:
+		return "e2fsck", []string{"-n", "-f", device}, nil
+	case format == "ext4":
+		return "e2fsck", []string{"-p", "-f", device}, nil
+	case format == "xfs" && mode == FsckCheck:
+		return "xfs_repair", []string{"-n", device}, nil
+	case format == "xfs":
+		return "xfs_repair", []string{device}, nil
+	case format == "btrfs" && mode == FsckCheck:
+		return "btrfs", []string{"check", "--readonly", device}, nil
+	case format == "btrfs":
+		// btrfs check --repair can make damage worse; mounted scrubs repair btrfs instead
+		return "", nil, fmt.Errorf("%w: btrfs pools only support check", ErrFsckUnsupported)
+	default:
+		return "", nil, fmt.Errorf("%w: %s", ErrFsckUnsupported, format)
+	}
+}
+
+// e2fsck exit status bits, see e2fsck(8).
+var e2fsckExitBits = []struct {
+	bit     int
+	meaning string
+}{
+	{1, "errors corrected"},
+	{2, "errors corrected, reboot required"},
+	{4, "errors left uncorrected"},
+	{8, "operational error"},
+	{16, "usage or syntax error"},
+	{32, "canceled by user request"},
+	{128, "shared library error"},
+}
+
+// ParseFsckOutput counts the errors a tool reported and maps its exit code to a meaning.
+func ParseFsckOutput(format string, mode FsckMode, exitCode int, output string) FsckReport {
+	report := FsckReport{ExitCode: exitCode}
+	lines := strings.Split(output, "\n")
+	switch format {
+	case "ext4":
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasSuffix(line, "? no"):
+				report.ErrorsFound++
+			case strings.HasSuffix(line, "FIXED.") || strings.HasSuffix(line, "CLEARED.") || strings.HasSuffix(line, "SALVAGED."):
+				report.ErrorsFixed++
+			}
+		}
+		var meanings []string
+		for _, b := range e2fsckExitBits {
+			if exitCode&b.bit != 0 {
+				meanings = append(meanings, b.meaning)
+			}
+		}
+		report.Meaning = "no errors"
+		if len(meanings) > 0 {
+			report.Meaning = strings.Join(meanings, "; ")
+		}
+		report.Uncorrected = exitCode&4 != 0
+		report.Failed = exitCode >= 8
+	case "xfs":
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "would "), strings.Contains(line, ", would "):
+				report.ErrorsFound++
+			case strings.HasPrefix(line, "fixing "), strings.HasPrefix(line, "clearing "),
+				strings.HasPrefix(line, "correcting "), strings.HasPrefix(line, "resetting "),
+				strings.HasPrefix(line, "junking "):
+				report.ErrorsFixed++
+			}
+		}
+		switch {
+		case exitCode == 0:
+			report.Meaning = "no errors"
+		case exitCode == 1 && mode == FsckCheck:
+			report.Meaning = "corruption detected"
+			report.Uncorrected = true
+		case exitCode == 2:
+			report.Meaning = "dirty log, mount the filesystem to replay it"
+			report.Failed = true
+		default:
+			report.Meaning = "operational error"
+			report.Failed = true
+		}
+	case "btrfs":
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "ERROR:") {
+				report.ErrorsFound++
+			}
+		}
+		switch exitCode {
+		case 0:
+			report.Meaning = "no errors"
+		case 1:
+			report.Meaning = "errors found"
+			report.Uncorrected = true
+		default:
+			report.Meaning = "operational error"
+			report.Failed = true
+		}
+	}
+	// Some errors are only visible in the exit code
+	if report.Uncorrected && report.ErrorsFound == 0 {
+		report.ErrorsFound = 1
+	}
+	return report
+}
+
+// CheckFsck returns an error unless the pool filesystem can be checked in mode.
+// The pool must be built and unmounted.
+func (p *Pool) CheckFsck(mode FsckMode) error {
+	if !p.IsBuilt() {
+		return ErrPoolNotBuilt
+	}
+	if p.IsLvm() {
+		return fmt.Errorf("%w: lvm pools keep their filesystems on volumes", ErrFsckUnsupported)
+	}
+	if _, _, err := FsckCommand(p.Format, mode, p.FsDevice()); err != nil {
+		return err
+	}
+	if helper.IsMounted(p.MountPoint) {
+		return ErrPoolMounted
+	}
+	return nil
+}
+
+// RunFsck checks or repairs the unmounted pool filesystem. Offline pools are
+// assembled for the check and stopped again afterwards; locked encrypted pools
+// are opened with key, which may be nil for other pools.
+func (p *Pool) RunFsck(ctx context.Context, mode FsckMode, key *helper.LuksKey, report ProgressFunc) (result FsckReport, err error) {
+	if err = p.CheckFsck(mode); err != nil {
+		return result, err
+	}
+
+	wasAssembled := p.MdDevice != "" && p.IsAssembled()
+	if err = p.assemble(); err != nil {
+		return result, err
+	}
+	if !wasAssembled {
+		defer func() {
+			if stopErr := p.stop(); stopErr != nil && err == nil {
+				err = stopErr
+			}
+		}()
+	}
+	if p.IsEncrypted() && !p.IsUnlocked() {
+		if key == nil {
+			return result, ErrPoolLocked
+		}
+		if err = helper.LuksOpen(p.MdDevice, p.MapperName(), *key); err != nil {
+			return result, err
+		}
+		// stop closes the LUKS device; an array that stays up needs it closed here
+		if wasAssembled {
+			defer func() {
+				if closeErr := p.closeLuks(); closeErr != nil && err == nil {
+					err = closeErr
+				}
+			}()
+		}
+	}
+
+	name, args, err := FsckCommand(p.Format, mode, p.FsDevice())
+	if err != nil {
+		return result, err
+	}
+	report.report(PhaseFsck, 0)
+	out, runErr := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return result, errors.Join(ErrFsckFailed, runErr)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	result = ParseFsckOutput(p.Format, mode, exitCode, string(out))
+	report.report(PhaseFsck, 100)
+	if result.Failed {
+		return result, fmt.Errorf("%w: %s exited %d: %s", ErrFsckFailed, name, exitCode, result.Meaning)
+	}
+	return result, nil
+}
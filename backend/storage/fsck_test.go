@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readFsckFixture returns captured fsck tool output from testdata.
+func readFsckFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "fsck", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseFsckOutputFixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		format      string
+		mode        FsckMode
+		exitCode    int
+		found       int
+		fixed       int
+		uncorrected bool
+		meaning     string
+		result      FsckResult
+	}{
+		{fixture: "e2fsck_check.txt", format: "ext4", mode: FsckCheck, exitCode: 4, found: 3, uncorrected: true, meaning: "errors left uncorrected", result: FsckErrors},
+		{fixture: "e2fsck_repair.txt", format: "ext4", mode: FsckRepair, exitCode: 1, fixed: 2, meaning: "errors corrected", result: FsckFixed},
+		{fixture: "xfs_check.txt", format: "xfs", mode: FsckCheck, exitCode: 1, found: 3, uncorrected: true, meaning: "corruption detected", result: FsckErrors},
+		{fixture: "btrfs_check.txt", format: "btrfs", mode: FsckCheck, exitCode: 1, found: 2, uncorrected: true, meaning: "errors found", result: FsckErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			report := ParseFsckOutput(tt.format, tt.mode, tt.exitCode, readFsckFixture(t, tt.fixture))
+			if report.ErrorsFound != tt.found || report.ErrorsFixed != tt.fixed {
+				t.Errorf("expected %d found and %d fixed, got %d and %d", tt.found, tt.fixed, report.ErrorsFound, report.ErrorsFixed)
+			}
+			if report.Uncorrected != tt.uncorrected || report.Failed || report.Meaning != tt.meaning {
+				t.Errorf("unexpected report %+v", report)
+			}
+			fsck := NewFsck("pool", tt.mode, tt.format)
+			fsck.Finish(report, nil, false)
+			if fsck.Result != tt.result || fsck.ExitCode != tt.exitCode {
+				t.Errorf("expected result %s, got %+v", tt.result, fsck)
+			}
+		})
+	}
+}
+
+func TestFsckExitCodeMeanings(t *testing.T) {
+	report := ParseFsckOutput("ext4", FsckRepair, 12, "")
+	if !report.Failed || !report.Uncorrected || report.Meaning != "errors left uncorrected; operational error" {
+		t.Fatalf("unexpected e2fsck report %+v", report)
+	}
+	if report = ParseFsckOutput("xfs", FsckRepair, 2, ""); !report.Failed {
+		t.Fatalf("expected a dirty xfs log to fail the check, got %+v", report)
+	}
+	if report = ParseFsckOutput("btrfs", FsckCheck, 0, ""); report.Meaning != "no errors" || report.ErrorsFound != 0 {
+		t.Fatalf("unexpected clean btrfs report %+v", report)
+	}
+
+	fsck := NewFsck("pool", FsckCheck, "e2fsck")
+	fsck.Finish(FsckReport{}, context.Canceled, true)
+	if fsck.Result != FsckCanceled {
+		t.Fatalf("expected canceled fsck, got %s", fsck.Result)
+	}
+	fsck.Finish(FsckReport{}, context.Canceled, false)
+	if fsck.Result != FsckInterrupted {
+		t.Fatalf("expected fsck stopped by shutdown to be interrupted, got %s", fsck.Result)
+	}
+}
+
+func TestCheckFsck(t *testing.T) {
+	if _, err := ParseFsckMode("destroy"); !errors.Is(err, ErrInvalidFsckMode) {
+		t.Fatalf("expected ErrInvalidFsckMode, got %v", err)
+	}
+
+	unbuilt, _ := NewPool("unbuilt", &Raid{Level: 1}, "ext4")
+	if err := unbuilt.CheckFsck(FsckCheck); !errors.Is(err, ErrPoolNotBuilt) {
+		t.Fatalf("expected ErrPoolNotBuilt, got %v", err)
+	}
+
+	native, _ := NewPool("native", &Btrfs{Profile: "raid1"}, "btrfs")
+	native.MountPoint = filepath.Join(t.TempDir(), "native")
+	if err := native.CheckFsck(FsckRepair); !errors.Is(err, ErrFsckUnsupported) {
+		t.Fatalf("expected ErrFsckUnsupported for btrfs repair, got %v", err)
+	}
+	if err := native.CheckFsck(FsckCheck); err != nil {
+		t.Fatalf("unexpected error for an unmounted pool: %v", err)
+	}
+
+	lvm, _ := NewPool("lvm", &Raid{Level: 1}, "ext4")
+	if err := lvm.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lvm.MountPoint = "/mnt/pools/" + lvm.Uuid
+	if err := lvm.CheckFsck(FsckCheck); !errors.Is(err, ErrFsckUnsupported) {
+		t.Fatalf("expected ErrFsckUnsupported for lvm, got %v", err)
+	}
+}
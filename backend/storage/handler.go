@@ -40,6 +40,8 @@ type DriveInfo struct {
 	Partitions        []*Partition `json:"partitions"`
 	FsType            string       `json:"fstype"`
 	FsAvail           uint64       `json:"fsavail"`
+	Health            DriveHealth  `json:"health"`
+	Io                *IoSample    `json:"io,omitempty"`
 }
 
 // GetUuid returns the drive UUID.
@@ -148,8 +150,7 @@ func FilterFor(f DriveFilter, d ...*DriveInfo) []*DriveInfo {
 
 // GetDrives enumerates block devices and returns populated drive metadata.
 func GetDrives() ([]*DriveInfo, error) {
-	basePath := "/sys/block"
-	entries, err := os.ReadDir(basePath)
+	entries, err := os.ReadDir(SysBlockPath)
 	if err != nil {
 		return nil, err
 	}
@@ -158,69 +159,82 @@ func GetDrives() ([]*DriveInfo, error) {
 	var drives []*DriveInfo
 
 	for _, e := range entries {
-		name := e.Name()
-		path := filepath.Join(DevFolder, name)
+		drives = append(drives, readDrive(e.Name(), partitions))
+	}
 
-		blockDir := filepath.Join(basePath, name, "queue")
+	return drives, nil
+}
 
-		sizeSectors := readUint(filepath.Join(basePath, name, "size"))
-		logicalBlockSize := readUint(filepath.Join(blockDir, "logical_block_size"))
-		physicalBlockSize := readUint(filepath.Join(blockDir, "physical_block_size"))
+// ReadDrive returns the metadata of a single block device by kernel name.
+func ReadDrive(name string) (*DriveInfo, error) {
+	if _, err := os.Stat(filepath.Join(SysBlockPath, name)); err != nil {
+		return nil, ErrDriveNotFound
+	}
+	return readDrive(name, parsePartitions("/proc/mounts")), nil
+}
 
-		if logicalBlockSize == 0 {
-			logicalBlockSize = 512
-		}
-		if physicalBlockSize == 0 {
-			physicalBlockSize = logicalBlockSize
-		}
+// readDrive reads the sysfs attributes of the block device name.
+func readDrive(name string, partitions map[string][]*Partition) *DriveInfo {
+	path := filepath.Join(DevFolder, name)
 
-		sizeBytes := sizeSectors * logicalBlockSize
-
-		isRotational := readUint(filepath.Join(blockDir, "rotational")) == 1
-		model := readString(filepath.Join(basePath, name, "device/model"))
-		vendor := readString(filepath.Join(basePath, name, "device/vendor"))
-		serial := readString(filepath.Join(basePath, name, "device/serial"))
-		devType := readString(filepath.Join(basePath, name, "device/type"))
-		byIDs, _ := symlinksPointingToDev("/dev/disk/by-[id]", name)
-		wwid := readString(filepath.Join(basePath, name, "device/wwid"))
-		if len(devType) == 0 || devType == "0" {
-			devType = "disk"
-		} else {
-			devType = "ssd"
-		}
-		drive := &DriveInfo{
-			ByIds:             byIDs,
-			DriveKey:          DriveKey{},
-			Wwid:              wwid,
-			Name:              name,
-			Path:              path,
-			SizeSectors:       sizeSectors,
-			LogicalBlockSize:  logicalBlockSize,
-			PhysicalBlockSize: physicalBlockSize,
-			SizeBytes:         sizeBytes,
-			IsRotational:      isRotational,
-			Model:             model,
-			Vendor:            vendor,
-			Serial:            serial,
-			Type:              devType,
-		}
-		drive.generateDriveKey()
-
-		// Check for p info
-		for devPath, p := range partitions {
-			if strings.HasPrefix(devPath, path) {
-				drive.MountPoint = p[0].MountPoint
-				drive.FsType = p[0].FsType
-				drive.Partitions = p
-				drive.FsAvail = getFsAvailable(p[0].MountPoint)
-				break
-			}
-		}
+	blockDir := filepath.Join(SysBlockPath, name, "queue")
+
+	sizeSectors := readUint(filepath.Join(SysBlockPath, name, "size"))
+	logicalBlockSize := readUint(filepath.Join(blockDir, "logical_block_size"))
+	physicalBlockSize := readUint(filepath.Join(blockDir, "physical_block_size"))
 
-		drives = append(drives, drive)
+	if logicalBlockSize == 0 {
+		logicalBlockSize = 512
+	}
+	if physicalBlockSize == 0 {
+		physicalBlockSize = logicalBlockSize
 	}
 
-	return drives, nil
+	sizeBytes := sizeSectors * logicalBlockSize
+
+	isRotational := readUint(filepath.Join(blockDir, "rotational")) == 1
+	model := readString(filepath.Join(SysBlockPath, name, "device/model"))
+	vendor := readString(filepath.Join(SysBlockPath, name, "device/vendor"))
+	serial := readString(filepath.Join(SysBlockPath, name, "device/serial"))
+	devType := readString(filepath.Join(SysBlockPath, name, "device/type"))
+	byIDs, _ := symlinksPointingToDev("/dev/disk/by-[id]", name)
+	wwid := readString(filepath.Join(SysBlockPath, name, "device/wwid"))
+	if len(devType) == 0 || devType == "0" {
+		devType = "disk"
+	} else {
+		devType = "ssd"
+	}
+	drive := &DriveInfo{
+		ByIds:             byIDs,
+		DriveKey:          DriveKey{},
+		Wwid:              wwid,
+		Name:              name,
+		Path:              path,
+		SizeSectors:       sizeSectors,
+		LogicalBlockSize:  logicalBlockSize,
+		PhysicalBlockSize: physicalBlockSize,
+		SizeBytes:         sizeBytes,
+		IsRotational:      isRotational,
+		Model:             model,
+		Vendor:            vendor,
+		Serial:            serial,
+		Type:              devType,
+		Health:            DriveHealthUnknown,
+	}
+	drive.generateDriveKey()
+
+	// Check for p info
+	for devPath, p := range partitions {
+		if strings.HasPrefix(devPath, path) {
+			drive.MountPoint = p[0].MountPoint
+			drive.FsType = p[0].FsType
+			drive.Partitions = p
+			drive.FsAvail = getFsAvailable(p[0].MountPoint)
+			break
+		}
+	}
+
+	return drive
 }
 
 // symlinksPointingToDev finds /dev/disk/by-* entries pointing at a device name.
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var SysBlockPath = "/sys/block"
+
+type MemberState string
+
+var MemberInSync MemberState = "in_sync"
+var MemberFaulty MemberState = "faulty"
+var MemberSpare MemberState = "spare"
+var MemberRebuilding MemberState = "rebuilding"
+var MemberMissing MemberState = "missing"
+
+type MdSysfs struct {
+	ArrayState   string            `json:"arrayState"`
+	Degraded     uint64            `json:"degraded"`
+	SyncAction   string            `json:"syncAction"`
+	MemberStates map[string]string `json:"memberStates"`
+}
+
+// ReadMdSysfs reads array and member state from /sys/block/<name>/md.
+func ReadMdSysfs(name string) (*MdSysfs, error) {
+	mdDir := filepath.Join(SysBlockPath, name, "md")
+	if _, err := os.Stat(mdDir); err != nil {
+		return nil, err
+	}
+	sys := &MdSysfs{
+		ArrayState:   readString(filepath.Join(mdDir, "array_state")),
+		Degraded:     readUint(filepath.Join(mdDir, "degraded")),
+		SyncAction:   readString(filepath.Join(mdDir, "sync_action")),
+		MemberStates: make(map[string]string),
+	}
+	devDirs, err := filepath.Glob(filepath.Join(mdDir, "dev-*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range devDirs {
+		member := strings.TrimPrefix(filepath.Base(dir), "dev-")
+		sys.MemberStates[member] = readString(filepath.Join(dir, "state"))
+	}
+	return sys, nil
+}
+
+type PoolHealth struct {
+	Status  Status                 `json:"status"`
+	Reason  string                 `json:"reason"`
+	Members map[string]MemberState `json:"members"`
+}
+
+// EvaluateArray derives pool health from parsed mdstat and, when available, sysfs state.
+// Member states are keyed by kernel device name.
+func EvaluateArray(array *MdArray, sys *MdSysfs) PoolHealth {
+	health := PoolHealth{Status: Offline, Members: make(map[string]MemberState)}
+	if array == nil {
+		health.Reason = "array is not assembled"
+		return health
+	}
+	if !array.Active || (sys != nil && (sys.ArrayState == "clear" || sys.ArrayState == "inactive")) {
+		health.Reason = "array is inactive"
+		return health
+	}
+
+	for _, m := range array.Members {
+		health.Members[m.Name] = memberState(array, m, sys)
+	}
+
+	health.Status = Healthy
+	if array.Degraded() || (sys != nil && sys.Degraded > 0) {
+		health.Status = Degraded
+		health.Reason = fmt.Sprintf("%d of %d members active", array.ActiveDisks, array.RaidDisks)
+	}
+	return health
+}
+
+// memberState combines mdstat flags with the sysfs dev-*/state of a member.
+func memberState(array *MdArray, m MdMember, sys *MdSysfs) MemberState {
+	if m.Faulty {
+		return MemberFaulty
+	}
+	state := ""
+	if sys != nil {
+		state = sys.MemberStates[m.Name]
+	}
+	switch {
+	case strings.Contains(state, "faulty"):
+		return MemberFaulty
+	case strings.Contains(state, "in_sync"):
+		return MemberInSync
+	case m.Spare:
+		return MemberSpare
+	case strings.Contains(state, "spare") && array.SyncAction == "recovery":
+		return MemberRebuilding
+	case strings.Contains(state, "spare"):
+		return MemberSpare
+	default:
+		return MemberInSync
+	}
+}
+
+// healthChecker is implemented by pool types whose health does not come from md.
+type healthChecker interface {
+	CheckHealth(p *Pool) PoolHealth
+}
+
+// CheckHealth inspects the pool array using parsed mdstat arrays and sysfs,
+// or asks the pool type when it is not backed by md.
+func (p *Pool) CheckHealth(arrays map[string]*MdArray) PoolHealth {
+	if hc, ok := p.Type.(healthChecker); ok {
+		return hc.CheckHealth(p)
+	}
+	if p.IsEncrypted() && !p.IsUnlocked() {
+		return PoolHealth{Status: Offline, Reason: ReasonLocked, Members: make(map[string]MemberState)}
+	}
+	name, err := p.KernelDevice()
+	if err != nil {
+		return PoolHealth{Status: Offline, Reason: "md device not found", Members: make(map[string]MemberState)}
+	}
+	sys, err := ReadMdSysfs(name)
+	if err != nil {
+		sys = nil
+	}
+	return EvaluateArray(arrays[name], sys)
+}
+
+// ApplyHealth updates the pool status, reason and member states and reports
+// whether the status or reason changed.
+func (p *Pool) ApplyHealth(health PoolHealth) bool {
+	changed := p.Status != health.Status || p.StatusReason != health.Reason
+	p.Status = health.Status
+	p.StatusReason = health.Reason
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	for _, d := range p.AdoptedDrives {
+		state, ok := health.Members[p.memberName(d.Drive)]
+		switch {
+		case health.Status == Offline:
+			state = ""
+		case !ok:
+			state = MemberMissing
+		}
+		d.SetState(state)
+	}
+	for _, d := range p.Spares {
+		state, ok := health.Members[p.memberName(d.Drive)]
+		switch {
+		case health.Status == Offline:
+			state = ""
+		case !ok:
+			state = MemberMissing
+		}
+		d.SetState(state)
+	}
+	return changed
+}
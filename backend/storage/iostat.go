@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statSectorSize is the unit of the sector counters in /sys/block/<name>/stat,
+// which is 512 bytes regardless of the drive's logical block size.
+const statSectorSize = 512
+
+// DiskStat holds the cumulative counters of /sys/block/<name>/stat.
+type DiskStat struct {
+	ReadIOs      uint64
+	ReadSectors  uint64
+	ReadTicks    uint64
+	WriteIOs     uint64
+	WriteSectors uint64
+	WriteTicks   uint64
+	InFlight     uint64
+	IoTicks      uint64
+}
+
+// IoSample is the I/O activity of a drive between two readings of its counters.
+type IoSample struct {
+	Time             string  `json:"time"`
+	ReadIOPS         float64 `json:"readIops"`
+	WriteIOPS        float64 `json:"writeIops"`
+	ReadBytesPerSec  float64 `json:"readBytesPerSec"`
+	WriteBytesPerSec float64 `json:"writeBytesPerSec"`
+	Utilization      float64 `json:"utilization"`
+	ReadLatencyMs    float64 `json:"readLatencyMs"`
+	WriteLatencyMs   float64 `json:"writeLatencyMs"`
+	InFlight         uint64  `json:"inFlight"`
+}
+
+// ParseDiskStat parses the contents of a /sys/block/<name>/stat file.
+func ParseDiskStat(data string) (DiskStat, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 11 {
+		return DiskStat{}, fmt.Errorf("%w: expected at least 11 fields, got %d", ErrDiskStatParse, len(fields))
+	}
+	values := make([]uint64, 11)
+	for i := range values {
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return DiskStat{}, fmt.Errorf("%w: %v", ErrDiskStatParse, err)
+		}
+		values[i] = v
+	}
+	return DiskStat{
+		ReadIOs:      values[0],
+		ReadSectors:  values[2],
+		ReadTicks:    values[3],
+		WriteIOs:     values[4],
+		WriteSectors: values[6],
+		WriteTicks:   values[7],
+		InFlight:     values[8],
+		IoTicks:      values[9],
+	}, nil
+}
+
+// ReadDiskStat reads the I/O counters of the block device name.
+func ReadDiskStat(name string) (DiskStat, error) {
+	data, err := os.ReadFile(filepath.Join(SysBlockPath, name, "stat"))
+	if err != nil {
+		return DiskStat{}, err
+	}
+	return ParseDiskStat(string(data))
+}
+
+// after reports whether every cumulative counter of s is at least that of prev.
+// Counters go backwards when a device is removed and re-added under the same name.
+func (s DiskStat) after(prev DiskStat) bool {
+	return s.ReadIOs >= prev.ReadIOs && s.ReadSectors >= prev.ReadSectors && s.ReadTicks >= prev.ReadTicks &&
+		s.WriteIOs >= prev.WriteIOs && s.WriteSectors >= prev.WriteSectors && s.WriteTicks >= prev.WriteTicks &&
+		s.IoTicks >= prev.IoTicks
+}
+
+// ComputeIoSample turns the counter changes between prev and cur, read elapsed
+// apart, into rates, utilization and average latencies.
+func ComputeIoSample(prev, cur DiskStat, elapsed time.Duration) IoSample {
+	seconds := elapsed.Seconds()
+	reads := float64(cur.ReadIOs - prev.ReadIOs)
+	writes := float64(cur.WriteIOs - prev.WriteIOs)
+	sample := IoSample{
+		ReadIOPS:         reads / seconds,
+		WriteIOPS:        writes / seconds,
+		ReadBytesPerSec:  float64((cur.ReadSectors-prev.ReadSectors)*statSectorSize) / seconds,
+		WriteBytesPerSec: float64((cur.WriteSectors-prev.WriteSectors)*statSectorSize) / seconds,
+		// io_ticks counts the milliseconds the device had requests in flight
+		Utilization: min(100, float64(cur.IoTicks-prev.IoTicks)/(seconds*1000)*100),
+		InFlight:    cur.InFlight,
+	}
+	if reads > 0 {
+		sample.ReadLatencyMs = float64(cur.ReadTicks-prev.ReadTicks) / reads
+	}
+	if writes > 0 {
+		sample.WriteLatencyMs = float64(cur.WriteTicks-prev.WriteTicks) / writes
+	}
+	return sample
+}
+
+// IoRing keeps the most recent I/O samples of a drive, overwriting the oldest.
+type IoRing struct {
+	samples []IoSample
+	next    int
+	full    bool
+}
+
+// NewIoRing creates a ring buffer holding up to size samples.
+func NewIoRing(size int) *IoRing {
+	return &IoRing{samples: make([]IoSample, size)}
+}
+
+// Add stores sample, replacing the oldest one when the ring is full.
+func (r *IoRing) Add(sample IoSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Samples returns the stored samples, oldest first.
+func (r *IoRing) Samples() []IoSample {
+	if !r.full {
+		return append([]IoSample{}, r.samples[:r.next]...)
+	}
+	return append(append([]IoSample{}, r.samples[r.next:]...), r.samples[:r.next]...)
+}
+
+// Latest returns the most recent sample, if any.
+func (r *IoRing) Latest() (IoSample, bool) {
+	if !r.full && r.next == 0 {
+		return IoSample{}, false
+	}
+	return r.samples[(r.next-1+len(r.samples))%len(r.samples)], true
+}
+
+// ioTrack is the sampling state of one drive.
+type ioTrack struct {
+	stat DiskStat
+	at   time.Time
+	ring *IoRing
+}
+
+// IoSampler samples the I/O counters of drives and keeps their recent history.
+type IoSampler struct {
+	mu     sync.Mutex
+	size   int
+	tracks map[string]*ioTrack
+}
+
+// NewIoSampler creates a sampler keeping up to size samples per drive.
+func NewIoSampler(size int) *IoSampler {
+	return &IoSampler{size: size, tracks: make(map[string]*ioTrack)}
+}
+
+// Sample reads the counters of the named drives and records a sample for each
+// drive read before. Drives that are no longer named are forgotten.
+func (s *IoSampler) Sample(names []string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+		stat, err := ReadDiskStat(name)
+		if err != nil {
+			continue
+		}
+		track, ok := s.tracks[name]
+		if !ok {
+			s.tracks[name] = &ioTrack{stat: stat, at: now, ring: NewIoRing(s.size)}
+			continue
+		}
+		if now.After(track.at) && stat.after(track.stat) {
+			sample := ComputeIoSample(track.stat, stat, now.Sub(track.at))
+			sample.Time = now.UTC().Format(time.RFC3339)
+			track.ring.Add(sample)
+		}
+		track.stat = stat
+		track.at = now
+	}
+	for name := range s.tracks {
+		if !seen[name] {
+			delete(s.tracks, name)
+		}
+	}
+}
+
+// History returns the recent samples of the named drive, oldest first.
+func (s *IoSampler) History(name string) []IoSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	track, ok := s.tracks[name]
+	if !ok {
+		return []IoSample{}
+	}
+	return track.ring.Samples()
+}
+
+// Latest returns the most recent sample of the named drive, if any.
+func (s *IoSampler) Latest(name string) (IoSample, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	track, ok := s.tracks[name]
+	if !ok {
+		return IoSample{}, false
+	}
+	return track.ring.Latest()
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDiskStat(t *testing.T) {
+	stat, err := ParseDiskStat("  181346    35174 12638846    71842   412307   201813 22790232  1208722        2   633960  1310348        0        0        0        0    12345     1200\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DiskStat{ReadIOs: 181346, ReadSectors: 12638846, ReadTicks: 71842, WriteIOs: 412307, WriteSectors: 22790232, WriteTicks: 1208722, InFlight: 2, IoTicks: 633960}
+	if stat != want {
+		t.Fatalf("expected %+v, got %+v", want, stat)
+	}
+	if _, err = ParseDiskStat("1 2 3"); !errors.Is(err, ErrDiskStatParse) {
+		t.Fatalf("expected ErrDiskStatParse, got %v", err)
+	}
+}
+
+func TestComputeIoSample(t *testing.T) {
+	prev := DiskStat{ReadIOs: 100, ReadSectors: 1000, ReadTicks: 50, WriteIOs: 200, WriteSectors: 4000, WriteTicks: 300, IoTicks: 1000}
+	cur := DiskStat{ReadIOs: 300, ReadSectors: 21480, ReadTicks: 450, WriteIOs: 250, WriteSectors: 6048, WriteTicks: 800, InFlight: 1, IoTicks: 2500}
+	sample := ComputeIoSample(prev, cur, 2*time.Second)
+	want := IoSample{
+		ReadIOPS:         100,
+		WriteIOPS:        25,
+		ReadBytesPerSec:  20480 * statSectorSize / 2,
+		WriteBytesPerSec: 2048 * statSectorSize / 2,
+		Utilization:      75,
+		ReadLatencyMs:    2,
+		WriteLatencyMs:   10,
+		InFlight:         1,
+	}
+	if sample != want {
+		t.Fatalf("expected %+v, got %+v", want, sample)
+	}
+
+	idle := ComputeIoSample(prev, prev, time.Second)
+	if idle.ReadLatencyMs != 0 || idle.Utilization != 0 {
+		t.Fatalf("expected an idle sample, got %+v", idle)
+	}
+}
+
+func TestIoRing(t *testing.T) {
+	ring := NewIoRing(3)
+	if _, ok := ring.Latest(); ok {
+		t.Fatalf("expected an empty ring")
+	}
+	for i := 1; i <= 4; i++ {
+		ring.Add(IoSample{ReadIOPS: float64(i)})
+	}
+	samples := ring.Samples()
+	if len(samples) != 3 || samples[0].ReadIOPS != 2 || samples[2].ReadIOPS != 4 {
+		t.Fatalf("expected the three newest samples oldest first, got %+v", samples)
+	}
+	if latest, _ := ring.Latest(); latest.ReadIOPS != 4 {
+		t.Fatalf("expected the newest sample, got %+v", latest)
+	}
+}
+
+func TestIoSampler(t *testing.T) {
+	root := t.TempDir()
+	previous := SysBlockPath
+	SysBlockPath = root
+	defer func() { SysBlockPath = previous }()
+	writeStat := func(reads int) {
+		dir := filepath.Join(root, "sda")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		line := fmt.Sprintf("%d 0 0 0 0 0 0 0 0 %d 0\n", reads, reads)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sampler := NewIoSampler(10)
+	start := time.Now()
+	writeStat(100)
+	sampler.Sample([]string{"sda", "sdz"}, start)
+	if history := sampler.History("sda"); len(history) != 0 {
+		t.Fatalf("expected no samples after the first reading, got %+v", history)
+	}
+	writeStat(150)
+	sampler.Sample([]string{"sda"}, start.Add(5*time.Second))
+	latest, ok := sampler.Latest("sda")
+	if !ok || latest.ReadIOPS != 10 || latest.Utilization != 1 {
+		t.Fatalf("unexpected sample %+v", latest)
+	}
+
+	// A counter reset is skipped rather than reported as a huge rate
+	writeStat(10)
+	sampler.Sample([]string{"sda"}, start.Add(10*time.Second))
+	if history := sampler.History("sda"); len(history) != 1 {
+		t.Fatalf("expected the reset to be skipped, got %+v", history)
+	}
+	sampler.Sample(nil, start.Add(15*time.Second))
+	if _, ok = sampler.Latest("sda"); ok {
+		t.Fatalf("expected a removed drive to be forgotten")
+	}
+}
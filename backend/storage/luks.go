@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+)
+
+var PhaseEncrypt Phase = "luks-format"
+
+// ReasonLocked marks encrypted pools whose LUKS device has not been unlocked.
+// Encrypted pools come up locked after a restart.
+var ReasonLocked = "locked: unlock with a passphrase or keyfile"
+
+// IsEncrypted reports whether the pool filesystem sits on a LUKS device.
+func (p *Pool) IsEncrypted() bool {
+	return p.Encrypted
+}
+
+// MapperName returns the device-mapper name the pool LUKS device is opened as.
+func (p *Pool) MapperName() string {
+	shortID, err := ShortUuid(SHORTUUIDLEN, p.Uuid)
+	if err != nil {
+		shortID = p.Uuid
+	}
+	return "gonas-luks-" + shortID
+}
+
+// IsUnlocked reports whether the pool LUKS device is currently open.
+func (p *Pool) IsUnlocked() bool {
+	return helper.IsLuksOpen(p.MapperName())
+}
+
+// EnableEncryption makes an unbuilt md pool encrypted. The key is held in
+// memory only until the build has formatted the LUKS header.
+func (p *Pool) EnableEncryption(key helper.LuksKey) error {
+	if p.MdDevice == "" {
+		return ErrEncryptionUnsupported
+	}
+	if p.IsBuilt() {
+		return ErrPoolAlreadyBuilt
+	}
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	p.Encrypted = true
+	p.luksKey = &key
+	return nil
+}
+
+// HasBuildKey reports whether the pool can be built, which an encrypted pool
+// only can while its build key is held. The key is lost on restart.
+func (p *Pool) HasBuildKey() bool {
+	return !p.IsEncrypted() || p.luksKey != nil
+}
+
+// encrypt formats the md device as LUKS with the build key, records the header
+// UUID and opens it. The build key is dropped afterwards.
+func (p *Pool) encrypt(report ProgressFunc) error {
+	if p.luksKey == nil {
+		return helper.ErrLuksKeyRequired
+	}
+	report.report(PhaseEncrypt, 0)
+	if err := helper.LuksFormat(p.MdDevice, *p.luksKey); err != nil {
+		return errors.Join(ErrPoolEncrypt, err)
+	}
+	luksUuid, err := helper.LuksUUID(p.MdDevice)
+	if err != nil {
+		return errors.Join(ErrPoolEncrypt, err)
+	}
+	p.LuksUuid = luksUuid
+	if err = helper.LuksOpen(p.MdDevice, p.MapperName(), *p.luksKey); err != nil {
+		return errors.Join(ErrPoolEncrypt, err)
+	}
+	p.luksKey = nil
+	report.report(PhaseEncrypt, 100)
+	return nil
+}
+
+// closeLuks locks the pool LUKS device if it is open.
+func (p *Pool) closeLuks() error {
+	if !p.IsEncrypted() || !p.IsUnlocked() {
+		return nil
+	}
+	if err := helper.LuksClose(p.MapperName()); err != nil {
+		return errors.Join(ErrPoolDeleteStop, err)
+	}
+	return nil
+}
+
+// Unlock assembles the pool array, opens its LUKS device with key and brings the pool online.
+func (p *Pool) Unlock(key helper.LuksKey) error {
+	if !p.IsEncrypted() {
+		return ErrPoolNotEncrypted
+	}
+	if !p.IsBuilt() {
+		return ErrPoolNotBuilt
+	}
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	if err := p.assemble(); err != nil {
+		return err
+	}
+	if !p.IsUnlocked() {
+		if err := helper.LuksOpen(p.MdDevice, p.MapperName(), key); err != nil {
+			return err
+		}
+	}
+	return p.Online()
+}
+
+// Lock takes the pool offline, which closes its LUKS device, and marks it locked.
+func (p *Pool) Lock() error {
+	if !p.IsEncrypted() {
+		return ErrPoolNotEncrypted
+	}
+	if err := p.Offline(); err != nil {
+		return err
+	}
+	p.ApplyHealth(PoolHealth{Status: Offline, Reason: ReasonLocked})
+	return nil
+}
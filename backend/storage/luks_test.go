@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"strings"
+	"testing"
+)
+
+func TestEnableEncryption(t *testing.T) {
+	pool, err := NewPool("secret", &Raid{Level: 1}, "ext4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = pool.EnableEncryption(helper.LuksKey{}); !errors.Is(err, helper.ErrLuksKeyRequired) {
+		t.Fatalf("expected ErrLuksKeyRequired, got %v", err)
+	}
+	if err = pool.EnableEncryption(helper.LuksKey{Passphrase: "hunter2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pool.IsEncrypted() || pool.LuksUuid != "" {
+		t.Fatalf("expected encrypted pool without a LUKS header yet, got %+v", pool)
+	}
+	if device := pool.FsDevice(); !strings.HasPrefix(device, "/dev/mapper/gonas-luks-") {
+		t.Fatalf("expected filesystem on the LUKS mapping, got %q", device)
+	}
+
+	native, _ := NewPool("native", &Btrfs{Profile: "raid1"}, "btrfs")
+	if err = native.EnableEncryption(helper.LuksKey{Passphrase: "hunter2"}); !errors.Is(err, ErrEncryptionUnsupported) {
+		t.Fatalf("expected ErrEncryptionUnsupported, got %v", err)
+	}
+
+	built, _ := NewPool("built", &Raid{Level: 1}, "ext4")
+	built.MountPoint = "/mnt/pools/built"
+	if err = built.EnableEncryption(helper.LuksKey{KeyFile: "/root/pool.key"}); !errors.Is(err, ErrPoolAlreadyBuilt) {
+		t.Fatalf("expected ErrPoolAlreadyBuilt, got %v", err)
+	}
+	if err = built.Lock(); !errors.Is(err, ErrPoolNotEncrypted) {
+		t.Fatalf("expected ErrPoolNotEncrypted, got %v", err)
+	}
+}
+
+func TestReconcileLeavesEncryptedPoolsLocked(t *testing.T) {
+	pool := &Pool{
+		Uuid:          "0123456789abcdef-locked",
+		MountPoint:    "/mnt/pools/test",
+		MdDevice:      "/dev/md/does-not-exist",
+		Status:        Healthy,
+		Encrypted:     true,
+		LuksUuid:      "6f1c3c43-5c1f-4d8e-9f55-2b1a3c9b7e10",
+		AdoptedDrives: make(map[string]*AdoptedDrive),
+	}
+	if err := pool.Reconcile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.Status != Offline || pool.StatusReason != ReasonLocked {
+		t.Fatalf("expected pool to stay locked, got %q (%s)", pool.Status, pool.StatusReason)
+	}
+	if health := pool.CheckHealth(nil); health.Reason != ReasonLocked {
+		t.Fatalf("expected health check to report the pool locked, got %+v", health)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"goNAS/helper"
+	"sort"
+)
+
+// MdadmConfArrays returns the managed mdadm.conf arrays of the md pools. Each
+// scanned array is recorded under its pool md device so the /dev/md/<shortuuid>
+// path survives a reboot. Pools missing from scanned, such as offline ones,
+// keep their line from current. resolve maps device paths to kernel names.
+func MdadmConfArrays(pools []*Pool, scanned []helper.MdadmArray, current []helper.MdadmArray, resolve func(string) (string, error)) []helper.MdadmArray {
+	byKernel := make(map[string]helper.MdadmArray)
+	for _, a := range scanned {
+		if name, err := resolve(a.Device); err == nil {
+			byKernel[name] = a
+		}
+	}
+	byDevice := make(map[string]helper.MdadmArray)
+	for _, a := range current {
+		byDevice[a.Device] = a
+	}
+
+	var arrays []helper.MdadmArray
+	for _, p := range pools {
+		if p.MdDevice == "" {
+			continue
+		}
+		if name, err := resolve(p.MdDevice); err == nil {
+			if a, ok := byKernel[name]; ok {
+				arrays = append(arrays, helper.MdadmArray{Device: p.MdDevice, Attributes: a.Attributes})
+				continue
+			}
+		}
+		if a, ok := byDevice[p.MdDevice]; ok {
+			arrays = append(arrays, a)
+		}
+	}
+	sort.Slice(arrays, func(i, j int) bool {
+		return arrays[i].Device < arrays[j].Device
+	})
+	return arrays
+}
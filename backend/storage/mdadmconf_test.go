@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"path/filepath"
+	"testing"
+)
+
+func TestMdadmConfArrays(t *testing.T) {
+	kernel := map[string]string{
+		"/dev/md/0123456789abcdef": "md127",
+		"/dev/md/media":            "md127",
+		"/dev/md126":               "md126",
+	}
+	resolve := func(device string) (string, error) {
+		if name, ok := kernel[device]; ok {
+			return name, nil
+		}
+		return "", errors.New("no such device")
+	}
+
+	online := &Pool{MdDevice: "/dev/md/0123456789abcdef"}
+	offline := &Pool{MdDevice: "/dev/md/fedcba9876543210"}
+	native := &Pool{}
+	scanned := []helper.MdadmArray{
+		{Device: "/dev/md/media", Attributes: []string{"metadata=1.2", "name=nas:media", "UUID=1111"}},
+		// Arrays goNAS does not own are left out of the managed section
+		{Device: "/dev/md126", Attributes: []string{"metadata=1.2", "UUID=9999"}},
+	}
+	current := []helper.MdadmArray{
+		{Device: "/dev/md/fedcba9876543210", Attributes: []string{"metadata=1.2", "UUID=2222"}},
+		{Device: "/dev/md/deleted", Attributes: []string{"metadata=1.2", "UUID=3333"}},
+	}
+
+	arrays := MdadmConfArrays([]*Pool{offline, native, online}, scanned, current, resolve)
+	lines := helper.MdadmConfLines(arrays)
+	want := []string{
+		"ARRAY /dev/md/0123456789abcdef metadata=1.2 name=nas:media UUID=1111",
+		"ARRAY /dev/md/fedcba9876543210 metadata=1.2 UUID=2222",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+
+	// The generated section round-trips through a config file
+	path := filepath.Join(t.TempDir(), "mdadm.conf")
+	if err := helper.WriteManagedBlock(path, helper.MdadmConfBegin, helper.MdadmConfEnd, lines); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written, err := helper.ReadManagedBlock(path, helper.MdadmConfBegin, helper.MdadmConfEnd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, line := range helper.DiffLines(written, lines) {
+		if line[0] != ' ' {
+			t.Errorf("expected no pending changes after writing, got %q", line)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var MdstatPath = "/proc/mdstat"
+
+// SyncPollInterval controls how often /proc/mdstat is polled while waiting on a sync.
+var SyncPollInterval = 2 * time.Second
+
+var (
+	mdHeaderPattern     = regexp.MustCompile(`^(md\S+)\s*:\s*(.*)$`)
+	mdMemberPattern     = regexp.MustCompile(`^(\S+)\[(\d+)\]((?:\([A-Z]\))*)$`)
+	mdDiskCountPattern  = regexp.MustCompile(`\[(\d+)/(\d+)\]\s+\[([U_]+)\]`)
+	syncProgressPattern = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*([0-9.]+)%`)
+	syncPendingPattern  = regexp.MustCompile(`(resync|recovery|reshape|check|repair)\s*=\s*(DELAYED|PENDING)`)
+)
+
+type MdMember struct {
+	Name        string `json:"name"`
+	Slot        int    `json:"slot"`
+	Faulty      bool   `json:"faulty"`
+	Spare       bool   `json:"spare"`
+	Replacement bool   `json:"replacement"`
+}
+
+type MdArray struct {
+	Name         string     `json:"name"`
+	Active       bool       `json:"active"`
+	ReadOnly     bool       `json:"readOnly"`
+	Level        string     `json:"level"`
+	Members      []MdMember `json:"members"`
+	RaidDisks    int        `json:"raidDisks"`
+	ActiveDisks  int        `json:"activeDisks"`
+	MemberStatus string     `json:"memberStatus"`
+	SyncAction   string     `json:"syncAction"`
+	SyncProgress float64    `json:"syncProgress"`
+	SyncPending  bool       `json:"syncPending"`
+}
+
+// Syncing reports whether the array has a resync, recovery, reshape or check running or queued.
+func (a *MdArray) Syncing() bool { return a.SyncAction != "" }
+
+// Degraded reports whether the array is missing or has failed members.
+func (a *MdArray) Degraded() bool {
+	if a.ActiveDisks < a.RaidDisks {
+		return true
+	}
+	for _, m := range a.Members {
+		if m.Faulty {
+			return true
+		}
+	}
+	return false
+}
+
+// Member returns the array member with the given kernel device name.
+func (a *MdArray) Member(name string) (MdMember, bool) {
+	for _, m := range a.Members {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return MdMember{}, false
+}
+
+// ParseMdstat parses /proc/mdstat content into arrays keyed by kernel name.
+func ParseMdstat(r io.Reader) (map[string]*MdArray, error) {
+	arrays := make(map[string]*MdArray)
+	var current *MdArray
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := mdHeaderPattern.FindStringSubmatch(line); m != nil {
+			current = parseMdHeader(m[1], m[2])
+			arrays[current.Name] = current
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			current = nil
+			continue
+		}
+		if m := mdDiskCountPattern.FindStringSubmatch(line); m != nil {
+			current.RaidDisks, _ = strconv.Atoi(m[1])
+			current.ActiveDisks, _ = strconv.Atoi(m[2])
+			current.MemberStatus = m[3]
+			continue
+		}
+		if m := syncProgressPattern.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			current.SyncProgress, _ = strconv.ParseFloat(m[2], 64)
+			continue
+		}
+		if m := syncPendingPattern.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			current.SyncPending = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Arrays without a [n/m] status line (raid0, linear) count every non-spare member.
+	for _, a := range arrays {
+		if a.RaidDisks != 0 {
+			continue
+		}
+		for _, m := range a.Members {
+			if m.Spare {
+				continue
+			}
+			a.RaidDisks++
+			if !m.Faulty {
+				a.ActiveDisks++
+			}
+		}
+	}
+	return arrays, nil
+}
+
+// parseMdHeader parses the "mdX : active raid5 sdb[0] ..." line of an array.
+func parseMdHeader(name string, rest string) *MdArray {
+	array := &MdArray{Name: name}
+	for _, field := range strings.Fields(rest) {
+		switch {
+		case field == "active":
+			array.Active = true
+		case field == "inactive":
+			array.Active = false
+		case strings.HasPrefix(field, "(") && strings.Contains(field, "read-only"):
+			array.ReadOnly = true
+		case strings.HasPrefix(field, "raid") || field == "linear" || field == "multipath":
+			array.Level = field
+		default:
+			m := mdMemberPattern.FindStringSubmatch(field)
+			if m == nil {
+				continue
+			}
+			slot, _ := strconv.Atoi(m[2])
+			array.Members = append(array.Members, MdMember{
+				Name:        m[1],
+				Slot:        slot,
+				Faulty:      strings.Contains(m[3], "(F)"),
+				Spare:       strings.Contains(m[3], "(S)"),
+				Replacement: strings.Contains(m[3], "(R)"),
+			})
+		}
+	}
+	return array
+}
+
+// ReadMdstat parses the system mdstat file.
+func ReadMdstat() (map[string]*MdArray, error) {
+	f, err := os.Open(MdstatPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMdstat(f)
+}
+
+// KernelDevice resolves the pool md device to its kernel name, e.g. md127.
+func (p *Pool) KernelDevice() (string, error) {
+	return ResolveKernelDevice(p.MdDevice)
+}
+
+// ResolveKernelDevice resolves a device path or symlink to its kernel name.
+func ResolveKernelDevice(device string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(resolved), nil
+}
+
+// WaitForSync blocks until the pool array has no running resync, recovery or reshape,
+// reporting progress under the given phase.
+func (p *Pool) WaitForSync(ctx context.Context, phase Phase, report ProgressFunc) error {
+	name, err := p.KernelDevice()
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(SyncPollInterval)
+	defer ticker.Stop()
+	for {
+		arrays, err := ReadMdstat()
+		if err != nil {
+			return err
+		}
+		array, ok := arrays[name]
+		if !ok || !array.Syncing() {
+			report.report(phase, 100)
+			return nil
+		}
+		report.report(phase, array.SyncProgress)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseFixture parses a captured mdstat file from testdata.
+func parseFixture(t *testing.T, name string) map[string]*MdArray {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "mdstat", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	defer f.Close()
+	arrays, err := ParseMdstat(f)
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	return arrays
+}
+
+func TestParseMdstatFixtures(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		level       string
+		members     int
+		raidDisks   int
+		activeDisks int
+		syncAction  string
+		progress    float64
+		status      Status
+	}{
+		{fixture: "raid0_clean.txt", level: "raid0", members: 2, raidDisks: 2, activeDisks: 2, status: Healthy},
+		{fixture: "raid1_clean.txt", level: "raid1", members: 2, raidDisks: 2, activeDisks: 2, status: Healthy},
+		{fixture: "raid1_degraded.txt", level: "raid1", members: 2, raidDisks: 2, activeDisks: 1, status: Degraded},
+		{fixture: "raid1_resyncing.txt", level: "raid1", members: 2, raidDisks: 2, activeDisks: 2, syncAction: "resync", progress: 23.4, status: Healthy},
+		{fixture: "raid1_recovering.txt", level: "raid1", members: 2, raidDisks: 2, activeDisks: 1, syncAction: "recovery", progress: 8.9, status: Degraded},
+		{fixture: "raid5_clean.txt", level: "raid5", members: 3, raidDisks: 3, activeDisks: 3, status: Healthy},
+		{fixture: "raid5_degraded.txt", level: "raid5", members: 2, raidDisks: 3, activeDisks: 2, status: Degraded},
+		{fixture: "raid5_resyncing.txt", level: "raid5", members: 3, raidDisks: 3, activeDisks: 3, syncAction: "resync", progress: 17.3, status: Healthy},
+		{fixture: "raid5_recovering.txt", level: "raid5", members: 3, raidDisks: 3, activeDisks: 2, syncAction: "recovery", progress: 41.7, status: Degraded},
+		{fixture: "raid6_clean.txt", level: "raid6", members: 4, raidDisks: 4, activeDisks: 4, status: Healthy},
+		{fixture: "raid6_degraded.txt", level: "raid6", members: 4, raidDisks: 4, activeDisks: 2, status: Degraded},
+		{fixture: "raid6_resyncing.txt", level: "raid6", members: 4, raidDisks: 4, activeDisks: 4, syncAction: "resync", progress: 52.0, status: Healthy},
+		{fixture: "raid6_recovering.txt", level: "raid6", members: 4, raidDisks: 4, activeDisks: 3, syncAction: "recovery", progress: 14.2, status: Degraded},
+		{fixture: "raid10_clean.txt", level: "raid10", members: 4, raidDisks: 4, activeDisks: 4, status: Healthy},
+		{fixture: "raid10_degraded.txt", level: "raid10", members: 3, raidDisks: 4, activeDisks: 3, status: Degraded},
+		{fixture: "raid10_resyncing.txt", level: "raid10", members: 4, raidDisks: 4, activeDisks: 4, syncAction: "resync", progress: 0.9, status: Healthy},
+		{fixture: "raid10_recovering.txt", level: "raid10", members: 4, raidDisks: 4, activeDisks: 3, syncAction: "recovery", progress: 27.5, status: Degraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			arrays := parseFixture(t, tt.fixture)
+			array, ok := arrays["md127"]
+			if !ok {
+				t.Fatalf("expected md127 in %v", arrays)
+			}
+			if !array.Active {
+				t.Fatal("expected array to be active")
+			}
+			if array.Level != tt.level {
+				t.Fatalf("expected level %q, got %q", tt.level, array.Level)
+			}
+			if len(array.Members) != tt.members {
+				t.Fatalf("expected %d members, got %d", tt.members, len(array.Members))
+			}
+			if array.RaidDisks != tt.raidDisks || array.ActiveDisks != tt.activeDisks {
+				t.Fatalf("expected [%d/%d], got [%d/%d]", tt.raidDisks, tt.activeDisks, array.RaidDisks, array.ActiveDisks)
+			}
+			if array.SyncAction != tt.syncAction {
+				t.Fatalf("expected sync action %q, got %q", tt.syncAction, array.SyncAction)
+			}
+			if array.SyncProgress != tt.progress {
+				t.Fatalf("expected progress %.1f, got %.1f", tt.progress, array.SyncProgress)
+			}
+			if health := EvaluateArray(array, nil); health.Status != tt.status {
+				t.Fatalf("expected status %q, got %q", tt.status, health.Status)
+			}
+		})
+	}
+}
+
+func TestParseMdstatFlagsAndStates(t *testing.T) {
+	arrays := parseFixture(t, "mixed.txt")
+	if len(arrays) != 3 {
+		t.Fatalf("expected 3 arrays, got %d", len(arrays))
+	}
+
+	pending := arrays["md125"]
+	if !pending.ReadOnly || !pending.SyncPending || pending.SyncAction != "resync" {
+		t.Fatalf("expected read-only array with pending resync, got %+v", pending)
+	}
+
+	inactive := arrays["md126"]
+	if inactive.Active {
+		t.Fatal("expected md126 to be inactive")
+	}
+	if health := EvaluateArray(inactive, nil); health.Status != Offline {
+		t.Fatalf("expected inactive array to be offline, got %q", health.Status)
+	}
+
+	withSpare := arrays["md127"]
+	spare, ok := withSpare.Member("sdd")
+	if !ok || !spare.Spare || spare.Slot != 3 {
+		t.Fatalf("expected sdd to be a spare in slot 3, got %+v", spare)
+	}
+	if health := EvaluateArray(withSpare, nil); health.Members["sdd"] != MemberSpare || health.Status != Healthy {
+		t.Fatalf("expected healthy array with spare sdd, got %+v", health)
+	}
+
+	faulty, _ := parseFixture(t, "raid6_degraded.txt")["md127"].Member("sdc")
+	if !faulty.Faulty {
+		t.Fatalf("expected sdc to be faulty, got %+v", faulty)
+	}
+}
+
+// writeSysfs writes a fake /sys/block/<name>/md tree below root.
+func writeSysfs(t *testing.T, root string, name string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, name, "md", rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create sysfs dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write sysfs file: %v", err)
+		}
+	}
+}
+
+func TestEvaluateArrayWithSysfs(t *testing.T) {
+	root := t.TempDir()
+	previous := SysBlockPath
+	SysBlockPath = root
+	defer func() { SysBlockPath = previous }()
+
+	writeSysfs(t, root, "md127", map[string]string{
+		"array_state":   "active",
+		"degraded":      "1",
+		"sync_action":   "recover",
+		"dev-sdb/state": "in_sync",
+		"dev-sdc/state": "in_sync",
+		"dev-sde/state": "spare",
+		"mismatch_cnt":  "0",
+	})
+
+	sys, err := ReadMdSysfs("md127")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sys.ArrayState != "active" || sys.Degraded != 1 || sys.SyncAction != "recover" {
+		t.Fatalf("unexpected sysfs state: %+v", sys)
+	}
+
+	array := parseFixture(t, "raid5_recovering.txt")["md127"]
+	health := EvaluateArray(array, sys)
+	if health.Status != Degraded {
+		t.Fatalf("expected degraded, got %q", health.Status)
+	}
+	want := map[string]MemberState{"sdb": MemberInSync, "sdc": MemberInSync, "sde": MemberRebuilding}
+	for name, state := range want {
+		if health.Members[name] != state {
+			t.Fatalf("expected %s to be %q, got %q", name, state, health.Members[name])
+		}
+	}
+
+	pool := &Pool{AdoptedDrives: map[string]*AdoptedDrive{
+		"a": {Drive: &DriveInfo{Name: "sdb"}},
+		"b": {Drive: &DriveInfo{Name: "sdd"}},
+	}, Status: Healthy}
+	if changed := pool.ApplyHealth(health); !changed || pool.Status != Degraded {
+		t.Fatalf("expected status change to degraded, got %q", pool.Status)
+	}
+	if pool.AdoptedDrives["a"].GetState() != MemberInSync || pool.AdoptedDrives["b"].GetState() != MemberMissing {
+		t.Fatalf("unexpected member states: %q %q", pool.AdoptedDrives["a"].GetState(), pool.AdoptedDrives["b"].GetState())
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goNAS/helper"
+	"strconv"
+	"strings"
+)
+
+// raidMigrations lists the level conversions mdadm can perform in place.
+var raidMigrations = map[int][]int{
+	0: {5},
+	1: {5},
+	5: {6},
+}
+
+// migrationArgs returns the mdadm invocations that convert the array from the
+// current level to level across total members, adding drives where needed.
+func (r *Raid) migrationArgs(p *Pool, level int, drives []*DriveInfo) ([][]string, error) {
+	allowed := false
+	for _, l := range raidMigrations[r.Level] {
+		if l == level {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: raid%d to raid%d", ErrMigrationUnsupported, r.Level, level)
+	}
+
+	current := len(p.Members())
+	total := current + len(drives)
+	if err := helper.CheckRaidLevel(level, total); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(drives))
+	for _, d := range drives {
+		paths = append(paths, p.memberDevice(d.Path))
+	}
+
+	switch r.Level {
+	case 0:
+		// raid0 converts to a raid5 missing its parity member, which the new drive fills.
+		if total != current+1 {
+			return nil, fmt.Errorf("%w: raid0 to raid5 needs exactly one new drive", ErrMigrationUnsupported)
+		}
+		return [][]string{
+			{"--grow", p.MdDevice, fmt.Sprintf("--level=%d", level)},
+			append([]string{"--manage", p.MdDevice, "--add"}, paths...),
+		}, nil
+	case 1:
+		// mdadm only converts a two device raid1 to raid5 in place; the new drives are then reshaped in.
+		if current != 2 {
+			return nil, fmt.Errorf("%w: raid1 to raid5 needs a 2 drive mirror, pool has %d", ErrMigrationUnsupported, current)
+		}
+		return [][]string{
+			{"--grow", p.MdDevice, fmt.Sprintf("--level=%d", level)},
+			append([]string{"--grow", p.MdDevice, fmt.Sprintf("--raid-devices=%d", total), "--add"}, paths...),
+		}, nil
+	default:
+		args := []string{"--grow", p.MdDevice, fmt.Sprintf("--level=%d", level), fmt.Sprintf("--raid-devices=%d", total)}
+		if len(paths) > 0 {
+			args = append(append(args, "--add"), paths...)
+		}
+		return [][]string{args}, nil
+	}
+}
+
+// Migrate starts converting the array to level, adding drives as part of the reshape.
+// When a later step fails the array may already have changed level, so the
+// pool type is updated to the level the array reports.
+func (r *Raid) Migrate(p *Pool, level int, drives []*DriveInfo) error {
+	plan, err := r.migrationArgs(p, level, drives)
+	if err != nil {
+		return err
+	}
+	for i, args := range plan {
+		if err = helper.BuildMdadm(args); err != nil {
+			err = errors.Join(ErrPoolMigrate, err)
+			if i > 0 {
+				return p.partialMigration(err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// partialMigration records the level of an array whose migration failed after
+// its first step and returns an ErrMigrationPartial naming that level.
+func (p *Pool) partialMigration(err error) error {
+	level, readErr := p.arrayLevel()
+	if readErr != nil {
+		return fmt.Errorf("%w: array level unknown: %v: %w", ErrMigrationPartial, readErr, err)
+	}
+	p.SetType(&Raid{Level: level})
+	return fmt.Errorf("%w: array is now raid%d: %w", ErrMigrationPartial, level, err)
+}
+
+// arrayLevel reads the raid level of the pool array from mdstat.
+func (p *Pool) arrayLevel() (int, error) {
+	name, err := p.KernelDevice()
+	if err != nil {
+		return 0, err
+	}
+	arrays, err := ReadMdstat()
+	if err != nil {
+		return 0, err
+	}
+	array, ok := arrays[name]
+	if !ok {
+		return 0, fmt.Errorf("%s is not in mdstat", name)
+	}
+	return strconv.Atoi(strings.TrimPrefix(array.Level, "raid"))
+}
+
+// MigrateLevel starts converting a built raid pool to level, adding drives first.
+// The pool type only changes once FinishMigration sees the reshape complete.
+func (p *Pool) MigrateLevel(level int, drives ...*DriveInfo) error {
+	r, ok := p.Type.(*Raid)
+	if !ok {
+		return ErrMigrationUnsupported
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return ErrPoolNotBuilt
+	}
+	// Reject conversions mdadm cannot do before the new drives are partitioned
+	if _, err := r.migrationArgs(p, level, drives); err != nil {
+		return err
+	}
+	parts, err := p.partitionDrives(drives)
+	if err != nil {
+		return errors.Join(ErrPoolMigrate, err)
+	}
+	if err = r.Migrate(p, level, drives); err != nil {
+		return err
+	}
+	p.AddDrives(drives...)
+	p.recordPartitions(parts)
+	return nil
+}
+
+// FinishMigration waits for the reshape started by MigrateLevel and then
+// switches the pool type to the new level.
+func (p *Pool) FinishMigration(ctx context.Context, level int, report ProgressFunc) error {
+	if err := p.WaitForSync(ctx, PhaseReshape, report); err != nil {
+		return err
+	}
+	p.SetType(&Raid{Level: level})
+	return nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// poolWithMembers returns a pool with count adopted member drives.
+func poolWithMembers(count int) *Pool {
+	pool := &Pool{MdDevice: "/dev/md/test", AdoptedDrives: make(map[string]*AdoptedDrive)}
+	for i := 0; i < count; i++ {
+		drive := &DriveInfo{Name: string(rune('b' + i))}
+		pool.AdoptedDrives[drive.Name] = NewAdoptedDrive(drive)
+	}
+	return pool
+}
+
+func TestMigrationArgs(t *testing.T) {
+	newDrive := []*DriveInfo{{Name: "sdz", Path: "/dev/sdz"}}
+
+	tests := []struct {
+		name    string
+		from    int
+		to      int
+		members int
+		drives  []*DriveInfo
+		want    [][]string
+		wantErr error
+	}{
+		{name: "raid1 to raid5 without new drive", from: 1, to: 5, members: 2, wantErr: helper.ErrRaid5RequiresDrives},
+		{
+			name: "raid1 to raid5 with new drive", from: 1, to: 5, members: 2, drives: newDrive,
+			want: [][]string{
+				{"--grow", "/dev/md/test", "--level=5"},
+				{"--grow", "/dev/md/test", "--raid-devices=3", "--add", "/dev/sdz"},
+			},
+		},
+		{
+			name: "raid5 to raid6", from: 5, to: 6, members: 3, drives: newDrive,
+			want: [][]string{{"--grow", "/dev/md/test", "--level=6", "--raid-devices=4", "--add", "/dev/sdz"}},
+		},
+		{
+			name: "raid0 to raid5", from: 0, to: 5, members: 2, drives: newDrive,
+			want: [][]string{
+				{"--grow", "/dev/md/test", "--level=5"},
+				{"--manage", "/dev/md/test", "--add", "/dev/sdz"},
+			},
+		},
+		{name: "raid5 to raid6 without enough drives", from: 5, to: 6, members: 3, wantErr: helper.ErrRaid6RequiresDrives},
+		{name: "raid0 to raid5 without parity drive", from: 0, to: 5, members: 3, wantErr: ErrMigrationUnsupported},
+		{name: "raid1 to raid5 from a three way mirror", from: 1, to: 5, members: 3, drives: newDrive, wantErr: ErrMigrationUnsupported},
+		{name: "raid6 to raid1", from: 6, to: 1, members: 4, wantErr: ErrMigrationUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raid := &Raid{Level: tt.from}
+			got, err := raid.migrationArgs(poolWithMembers(tt.members), tt.to, tt.drives)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPartialMigrationRecordsArrayLevel(t *testing.T) {
+	device := filepath.Join(t.TempDir(), "md127")
+	if err := os.WriteFile(device, nil, 0644); err != nil {
+		t.Fatalf("failed to create fake md device: %v", err)
+	}
+	previous := MdstatPath
+	MdstatPath = filepath.Join("testdata", "mdstat", "raid5_clean.txt")
+	defer func() { MdstatPath = previous }()
+
+	// The level change went through but adding the drives did not
+	pool := poolWithMembers(2)
+	pool.MdDevice = device
+	pool.Type = &Raid{Level: 1}
+	err := pool.partialMigration(errors.Join(ErrPoolMigrate, errors.New("mdadm: add failed")))
+	if !errors.Is(err, ErrMigrationPartial) || !errors.Is(err, ErrPoolMigrate) {
+		t.Fatalf("expected ErrMigrationPartial wrapping ErrPoolMigrate, got %v", err)
+	}
+	if r, ok := pool.Type.(*Raid); !ok || r.Level != 5 {
+		t.Fatalf("expected the pool type to follow the array to raid5, got %v", pool.Type)
+	}
+
+	MdstatPath = filepath.Join(t.TempDir(), "missing")
+	pool.Type = &Raid{Level: 1}
+	if err = pool.partialMigration(ErrPoolMigrate); !errors.Is(err, ErrMigrationPartial) || pool.Type.(*Raid).Level != 1 {
+		t.Fatalf("expected a mixed state error and an unchanged type when the level is unknown, got %v %v", err, pool.Type)
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"fmt"
+	"goNAS/helper"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// mountOptions lists the mount options pools accept on any filesystem.
+var mountOptions = []string{"noatime", "relatime", "nodiratime", "discard", "ro", "nodev", "nosuid", "noexec"}
+
+// btrfsMountOption matches the additional options accepted on btrfs pools.
+var btrfsMountOption = regexp.MustCompile(`^(compress(-force)?=(zstd(:([1-9]|1[0-5]))?|lzo|zlib(:[1-9])?|no)|autodefrag|ssd|space_cache=v2)$`)
+
+// mountNegations maps mount options, keyed by name without any value, to the
+// option that turns them off on remount. Options left out, such as relatime,
+// are kernel defaults or cannot be turned off, and ro is cleared by Remount.
+var mountNegations = map[string]string{
+	"noatime":        "atime",
+	"nodiratime":     "diratime",
+	"discard":        "nodiscard",
+	"nodev":          "dev",
+	"nosuid":         "suid",
+	"noexec":         "exec",
+	"compress":       "compress=no",
+	"compress-force": "compress=no",
+	"autodefrag":     "noautodefrag",
+	"ssd":            "nossd",
+}
+
+// mountOptionName returns the option name without its value, treating
+// compress-force as compress since either replaces the other.
+func mountOptionName(option string) string {
+	name, _, _ := strings.Cut(option, "=")
+	if name == "compress-force" {
+		return "compress"
+	}
+	return name
+}
+
+// remountOptions returns options followed by the negation of every previous
+// option they drop, since a remount keeps flags it is not told to clear.
+func remountOptions(previous []string, options []string) []string {
+	kept := make(map[string]bool, len(options))
+	for _, option := range options {
+		kept[mountOptionName(option)] = true
+	}
+	result := append([]string{}, options...)
+	for _, option := range previous {
+		name, _, _ := strings.Cut(option, "=")
+		negation, ok := mountNegations[name]
+		if !ok || kept[mountOptionName(option)] || slices.Contains(result, negation) {
+			continue
+		}
+		result = append(result, negation)
+	}
+	return result
+}
+
+// ValidateMountOptions returns an error if an option is unknown or does not
+// apply to the filesystem format.
+func ValidateMountOptions(format string, options []string) error {
+	seen := make(map[string]bool)
+	for _, option := range options {
+		if seen[option] {
+			return fmt.Errorf("%w: %s given twice", ErrInvalidMountOption, option)
+		}
+		seen[option] = true
+		if slices.Contains(mountOptions, option) {
+			continue
+		}
+		if format == "btrfs" && btrfsMountOption.MatchString(option) {
+			continue
+		}
+		return fmt.Errorf("%w: %s on %s", ErrInvalidMountOption, option, format)
+	}
+	return nil
+}
+
+// SetMountOptions validates options for the pool filesystem and records them
+// for the next mount.
+func (p *Pool) SetMountOptions(options []string) error {
+	if p.IsLvm() {
+		return ErrMountOptionsUnsupported
+	}
+	if err := ValidateMountOptions(p.Format, options); err != nil {
+		return err
+	}
+	p.MountOptions = options
+	return nil
+}
+
+// Remount validates options for the pool filesystem and applies them to the
+// mounted pool, turning off options dropped from the recorded ones. Unmounted
+// pools pick them up on their next mount.
+func (p *Pool) Remount(options []string) error {
+	if p.IsLvm() {
+		return ErrMountOptionsUnsupported
+	}
+	if err := ValidateMountOptions(p.Format, options); err != nil {
+		return err
+	}
+	if !p.IsBuilt() || !helper.IsMounted(p.MountPoint) {
+		return nil
+	}
+	return helper.Remount(p.MountPoint, remountOptions(p.MountOptions, options)...)
+}
+
+// FstabEntries returns the fstab lines that mount the pool at boot. Encrypted
+// pools are left out since their keys are never stored.
+func (p *Pool) FstabEntries() ([]helper.FstabEntry, error) {
+	if !p.IsBuilt() || p.IsEncrypted() {
+		return nil, nil
+	}
+	if p.IsLvm() {
+		volumes := p.ListVolumes()
+		entries := make([]helper.FstabEntry, 0, len(volumes))
+		for _, v := range volumes {
+			entries = append(entries, helper.FstabEntry{
+				Device:     p.VolumeDevice(v),
+				MountPoint: v.MountPoint,
+				Format:     v.Format,
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].MountPoint < entries[j].MountPoint
+		})
+		return entries, nil
+	}
+
+	uuid, err := helper.FilesystemUUID(p.FsDevice())
+	if err != nil {
+		return nil, err
+	}
+	return []helper.FstabEntry{{
+		Device:     "UUID=" + uuid,
+		MountPoint: p.MountPoint,
+		Format:     p.Format,
+		Options:    p.MountOptions,
+	}}, nil
+}
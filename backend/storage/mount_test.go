@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateMountOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		options []string
+		wantErr bool
+	}{
+		{name: "generic options", format: "ext4", options: []string{"noatime", "discard", "ro"}},
+		{name: "btrfs compression", format: "btrfs", options: []string{"noatime", "compress=zstd:3"}},
+		{name: "compression on ext4", format: "ext4", options: []string{"compress=zstd"}, wantErr: true},
+		{name: "unknown option", format: "xfs", options: []string{"exec,suid"}, wantErr: true},
+		{name: "duplicate option", format: "xfs", options: []string{"noatime", "noatime"}, wantErr: true},
+		{name: "bad compression level", format: "btrfs", options: []string{"compress=zstd:99"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMountOptions(tt.format, tt.options)
+			if tt.wantErr && !errors.Is(err, ErrInvalidMountOption) {
+				t.Fatalf("expected ErrInvalidMountOption, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetMountOptions(t *testing.T) {
+	pool, _ := NewPool("mounted", &Raid{Level: 1}, "btrfs")
+	if err := pool.SetMountOptions([]string{"noatime", "compress=zstd"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pool.MountOptions) != 2 {
+		t.Fatalf("expected mount options to be recorded, got %v", pool.MountOptions)
+	}
+	// Unbuilt pools only record the options for their first mount
+	if err := pool.Remount([]string{"ro"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lvm, _ := NewPool("lvm", &Raid{Level: 1}, "ext4")
+	if err := lvm.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := lvm.SetMountOptions([]string{"noatime"}); !errors.Is(err, ErrMountOptionsUnsupported) {
+		t.Fatalf("expected ErrMountOptionsUnsupported, got %v", err)
+	}
+	if entries, err := lvm.FstabEntries(); err != nil || entries != nil {
+		t.Fatalf("expected no fstab entries for an unbuilt pool, got %v %v", entries, err)
+	}
+}
+
+func TestRemountOptionsTurnOffDroppedOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		options  []string
+		want     []string
+	}{
+		{name: "dropped flags", previous: []string{"noatime", "noexec", "ro"}, options: []string{"nodev"}, want: []string{"nodev", "atime", "exec"}},
+		{name: "kept flags", previous: []string{"noatime"}, options: []string{"noatime", "discard"}, want: []string{"noatime", "discard"}},
+		{name: "default flags", previous: []string{"relatime", "space_cache=v2"}, options: nil, want: []string{}},
+		{name: "dropped compression", previous: []string{"compress-force=zstd", "ssd"}, options: nil, want: []string{"compress=no", "nossd"}},
+		{name: "changed compression", previous: []string{"compress=zstd"}, options: []string{"compress-force=lzo"}, want: []string{"compress-force=lzo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remountOptions(tt.previous, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"goNAS/helper"
+	"path/filepath"
+	"sort"
+)
+
+var PhasePartition Phase = "partition"
+
+// DefaultPartitionReserve is the space left unused at the end of each drive of a
+// partitioned pool, so a replacement of nominally the same size still fits.
+var DefaultPartitionReserve = 100 * helper.Megabyte
+
+// EnablePartitioning makes the pool build its array on a single GPT partition
+// of each drive, leaving reserve bytes unused at the end of the drive. A
+// reserve of zero uses DefaultPartitionReserve.
+func (p *Pool) EnablePartitioning(reserve uint64) error {
+	if _, native := p.Type.(assembler); native || p.MdDevice == "" {
+		return ErrPartitionUnsupported
+	}
+	if p.IsBuilt() {
+		return ErrPoolAlreadyBuilt
+	}
+	if reserve == 0 {
+		reserve = DefaultPartitionReserve
+	}
+	p.Partitioned = true
+	p.PartitionReserve = reserve
+	p.estimateCapacity()
+	return nil
+}
+
+// memberDevice returns the device md uses for the drive at path: the drive
+// itself or, in partitioned pools, its first partition.
+func (p *Pool) memberDevice(path string) string {
+	if !p.Partitioned || path == "" {
+		return path
+	}
+	return helper.PartitionPath(path, 1)
+}
+
+// memberName returns the kernel name md reports for the drive.
+func (p *Pool) memberName(d *DriveInfo) string {
+	return filepath.Base(p.memberDevice(DevFolder + d.Name))
+}
+
+// memberSize returns the bytes of the drive md can use.
+func (p *Pool) memberSize(d *DriveInfo) uint64 {
+	if !p.Partitioned {
+		return d.SizeBytes
+	}
+	size, err := helper.GptPartitionSize(d.SizeBytes, d.LogicalBlockSize, p.PartitionReserve)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// partitionDrives gives each drive of a partitioned pool a fresh GPT with a
+// single Linux RAID partition and returns the partition UUIDs keyed by drive
+// UUID. Drives of other pools are left untouched.
+func (p *Pool) partitionDrives(drives []*DriveInfo) (map[string]string, error) {
+	parts := make(map[string]string, len(drives))
+	if !p.Partitioned {
+		return parts, nil
+	}
+	for _, d := range drives {
+		partUuid, err := helper.CreateGptPartition(d.Path, d.SizeBytes, d.LogicalBlockSize, p.PartitionReserve, p.Name)
+		if err != nil {
+			return nil, err
+		}
+		parts[d.Uuid] = partUuid
+	}
+	return parts, nil
+}
+
+// recordPartitions stores partition UUIDs on the matching pool members and spares.
+func (p *Pool) recordPartitions(parts map[string]string) {
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	for id, partUuid := range parts {
+		if d, ok := p.AdoptedDrives[id]; ok {
+			d.PartUuid = partUuid
+		}
+		if d, ok := p.Spares[id]; ok {
+			d.PartUuid = partUuid
+		}
+	}
+}
+
+// planPartitions returns the sgdisk commands partitionDrives runs for the pool members.
+func (p *Pool) planPartitions() ([]PlanStep, error) {
+	if !p.Partitioned {
+		return nil, nil
+	}
+	members := p.Members()
+	drives := make([]*DriveInfo, 0, len(members))
+	for _, d := range members {
+		drives = append(drives, d.Drive)
+	}
+	sort.Slice(drives, func(i, j int) bool { return drives[i].Name < drives[j].Name })
+
+	var steps []PlanStep
+	for _, d := range drives {
+		first, last, err := helper.GptPartitionRange(d.SizeBytes, d.LogicalBlockSize, p.PartitionReserve)
+		if err != nil {
+			return nil, err
+		}
+		for _, args := range helper.GptPartitionArgs(DevFolder+d.Name, first, last, p.Name) {
+			steps = append(steps, PlanStep{Phase: PhasePartition, Command: "sgdisk", Args: args})
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"strings"
+	"testing"
+)
+
+func TestEnablePartitioning(t *testing.T) {
+	pool, _ := NewPool("media", &Raid{Level: 1}, "ext4",
+		&DriveInfo{Name: "sda", Uuid: "a", SizeBytes: helper.Gigabyte},
+		&DriveInfo{Name: "nvme0n1", Uuid: "b", SizeBytes: helper.Gigabyte},
+	)
+	if err := pool.EnablePartitioning(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pool.Partitioned || pool.PartitionReserve != DefaultPartitionReserve {
+		t.Fatalf("expected the default reserve, got %d", pool.PartitionReserve)
+	}
+	if pool.TotalCapacity != 922*helper.Megabyte {
+		t.Fatalf("expected capacity of one partition, got %d", pool.TotalCapacity)
+	}
+	if got := pool.memberName(pool.AdoptedDrives["b"].Drive); got != "nvme0n1p1" {
+		t.Fatalf("unexpected member name %q", got)
+	}
+	if got := pool.memberDevice("/dev/sda"); got != "/dev/sda1" {
+		t.Fatalf("unexpected member device %q", got)
+	}
+
+	btrfs, _ := NewPool("bulk", &Btrfs{Profile: "raid1"}, "btrfs")
+	if err := btrfs.EnablePartitioning(0); !errors.Is(err, ErrPartitionUnsupported) {
+		t.Fatalf("expected ErrPartitionUnsupported, got %v", err)
+	}
+}
+
+func TestPlanBuildPartitioned(t *testing.T) {
+	pool, _ := NewPool("media", &Raid{Level: 1}, "ext4",
+		&DriveInfo{Name: "sdb", Uuid: "b", SizeBytes: helper.Gigabyte},
+		&DriveInfo{Name: "sda", Uuid: "a", SizeBytes: helper.Gigabyte},
+	)
+	_ = pool.EnablePartitioning(100 * helper.Megabyte)
+	got := planCommands(t, pool)
+	want := []string{
+		"sgdisk --zap-all /dev/sda",
+		"sgdisk --new=1:2048:1890303 --typecode=1:FD00 --change-name=1:media /dev/sda",
+		"sgdisk --zap-all /dev/sdb",
+		"sgdisk --new=1:2048:1890303 --typecode=1:FD00 --change-name=1:media /dev/sdb",
+		"mdadm --create --verbose " + pool.MdDevice + " --level=1 --raid-devices=2 --name=media /dev/sda1 /dev/sdb1",
+	}
+	if strings.Join(got[:len(want)], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestApplyHealthPartitioned(t *testing.T) {
+	pool, _ := NewPool("media", &Raid{Level: 1}, "ext4", &DriveInfo{Name: "sda", Uuid: "a", SizeBytes: helper.Gigabyte})
+	_ = pool.EnablePartitioning(0)
+	pool.ApplyHealth(PoolHealth{Status: Degraded, Members: map[string]MemberState{"sda1": MemberFaulty}})
+	if state := pool.AdoptedDrives["a"].GetState(); state != MemberFaulty {
+		t.Fatalf("expected the partition state on the drive, got %q", state)
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+	"goNAS/helper"
+	"strings"
+)
+
+// PlanStep is one system command a pool build would run.
+type PlanStep struct {
+	Phase   Phase    `json:"phase"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// String returns the step as a shell command line.
+func (s PlanStep) String() string {
+	return strings.TrimSpace(s.Command + " " + strings.Join(s.Args, " "))
+}
+
+// buildPlanner is implemented by pool types that can describe their build without running it.
+type buildPlanner interface {
+	PlanBuild(p *Pool) ([]PlanStep, error)
+}
+
+// PlanBuild runs the checks of a build and returns, in order, the commands it
+// would run against the pool drives. Neither the pool nor the disks are changed.
+func (p *Pool) PlanBuild() ([]PlanStep, error) {
+	if p.IsBuilt() {
+		return nil, ErrPoolAlreadyBuilt
+	}
+	planner, ok := p.Type.(buildPlanner)
+	if !ok {
+		return nil, ErrInvalidPoolType
+	}
+	return planner.PlanBuild(p)
+}
+
+// planMd returns the commands buildMd runs for the pool with the given level arguments.
+func planMd(p *Pool, levelArgs ...string) ([]PlanStep, error) {
+	if p.Format == "" {
+		return nil, ErrPoolFormatRequired
+	}
+	if err := ValidatePoolFormat(p.Format); err != nil {
+		return nil, err
+	}
+	name, err := helper.SanitizeRaidName(p.Name)
+	if err != nil {
+		return nil, err
+	}
+	planned := *p
+	planned.Name = name
+
+	steps, err := planned.planPartitions()
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, PlanStep{Phase: PhaseMdadmCreate, Command: "mdadm", Args: mdCreateArgs(&planned, levelArgs)})
+	if p.IsEncrypted() {
+		if p.luksKey == nil {
+			return nil, helper.ErrLuksKeyRequired
+		}
+		keyArgs := []string{"--key-file", "-"}
+		if p.luksKey.KeyFile != "" {
+			keyArgs = []string{"--key-file", p.luksKey.KeyFile}
+		}
+		steps = append(steps,
+			PlanStep{Phase: PhaseEncrypt, Command: "cryptsetup", Args: append(keyArgs, "luksFormat", "--type", "luks2", "--batch-mode", p.MdDevice)},
+			PlanStep{Phase: PhaseEncrypt, Command: "cryptsetup", Args: append(keyArgs, "open", "--type", "luks", "--disable-keyring", p.MdDevice, p.MapperName())},
+		)
+	}
+
+	if p.IsLvm() {
+		return append(steps,
+			PlanStep{Phase: PhaseVolumeGroup, Command: "pvcreate", Args: []string{"-y", p.FsDevice()}},
+			PlanStep{Phase: PhaseVolumeGroup, Command: "vgcreate", Args: []string{p.VolumeGroup, p.FsDevice()}},
+			PlanStep{Phase: PhaseVolumeGroup, Command: "mkdir", Args: []string{"-p", p.plannedMountPoint()}},
+		), nil
+	}
+	steps = append(steps, PlanStep{Phase: PhaseMkfs, Command: "mkfs." + p.Format, Args: []string{"-F", p.FsDevice()}})
+	return append(steps, planMount(p, p.FsDevice())...), nil
+}
+
+// planMount returns the commands that create the pool mount point and mount device on it.
+func planMount(p *Pool, device string) []PlanStep {
+	mountArgs := []string{device, p.plannedMountPoint()}
+	if len(p.MountOptions) > 0 {
+		mountArgs = append([]string{"-o", strings.Join(p.MountOptions, ",")}, mountArgs...)
+	}
+	return []PlanStep{
+		{Phase: PhaseMount, Command: "mkdir", Args: []string{"-p", p.plannedMountPoint()}},
+		{Phase: PhaseMount, Command: "mount", Args: mountArgs},
+	}
+}
+
+// plannedMountPoint returns the mount point a build gives the pool.
+func (p *Pool) plannedMountPoint() string {
+	return fmt.Sprintf("%s/%s", helper.DefaultMountPoint, p.Uuid)
+}
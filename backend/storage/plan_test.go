@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"strings"
+	"testing"
+)
+
+func planCommands(t *testing.T, pool *Pool) []string {
+	t.Helper()
+	steps, err := pool.PlanBuild()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := make([]string, 0, len(steps))
+	for _, step := range steps {
+		commands = append(commands, step.String())
+	}
+	return commands
+}
+
+func TestPlanBuildRaid(t *testing.T) {
+	pool, _ := NewPool("media", &Raid{Level: 5}, "ext4",
+		&DriveInfo{Name: "sdc", Uuid: "c"},
+		&DriveInfo{Name: "sda", Uuid: "a"},
+		&DriveInfo{Name: "sdb", Uuid: "b"},
+	)
+	_ = pool.SetMountOptions([]string{"noatime"})
+	mountPoint := helper.DefaultMountPoint + "/" + pool.Uuid
+	want := []string{
+		"mdadm --create --verbose " + pool.MdDevice + " --level=5 --raid-devices=3 --name=media /dev/sda /dev/sdb /dev/sdc",
+		"mkfs.ext4 -F " + pool.MdDevice,
+		"mkdir -p " + mountPoint,
+		"mount -o noatime " + pool.MdDevice + " " + mountPoint,
+	}
+	got := planCommands(t, pool)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(got, "\n"))
+	}
+	if pool.IsBuilt() || pool.Status != Offline {
+		t.Fatalf("planning must not change the pool")
+	}
+}
+
+func TestPlanBuildEncryptedLvm(t *testing.T) {
+	pool, _ := NewPool("vault", Mirrored, "xfs", &DriveInfo{Name: "sda", Uuid: "a"}, &DriveInfo{Name: "sdb", Uuid: "b"})
+	_ = pool.EnableLvm()
+	if err := pool.EnableEncryption(helper.LuksKey{Passphrase: "secret"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := planCommands(t, pool)
+	if len(got) != 6 {
+		t.Fatalf("expected 6 steps, got %v", got)
+	}
+	if !strings.HasPrefix(got[1], "cryptsetup --key-file - luksFormat") || strings.Contains(strings.Join(got, " "), "secret") {
+		t.Fatalf("unexpected encryption steps: %v", got)
+	}
+	if got[3] != "pvcreate -y "+helper.MapperDevice(pool.MapperName()) {
+		t.Fatalf("expected the volume group on the LUKS mapping, got %q", got[3])
+	}
+}
+
+func TestPlanBuildBtrfs(t *testing.T) {
+	pool, _ := NewPool("tank", &Btrfs{Profile: "raid1"}, "",
+		&DriveInfo{Name: "sdb", Uuid: "b", Path: "/dev/sdb"},
+		&DriveInfo{Name: "sda", Uuid: "a", Path: "/dev/sda"},
+	)
+	got := planCommands(t, pool)
+	if got[0] != "mkfs.btrfs -f -L tank -d raid1 -m raid1 /dev/sda /dev/sdb" || got[1] != "btrfs device scan" {
+		t.Fatalf("unexpected plan: %v", got)
+	}
+	if pool.Format != "" {
+		t.Fatalf("planning must not set the pool format")
+	}
+}
+
+func TestPlanBuildValidates(t *testing.T) {
+	raid, _ := NewPool("small", &Raid{Level: 6}, "ext4", &DriveInfo{Name: "sda", Uuid: "a"})
+	if _, err := raid.PlanBuild(); !errors.Is(err, helper.ErrRaid6RequiresDrives) {
+		t.Fatalf("expected ErrRaid6RequiresDrives, got %v", err)
+	}
+	format, _ := NewPool("fat", Standard, "vfat", &DriveInfo{Name: "sda", Uuid: "a"})
+	if _, err := format.PlanBuild(); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	built, _ := NewPool("built", Standard, "ext4", &DriveInfo{Name: "sda", Uuid: "a"})
+	built.MountPoint = "/mnt/pools/built"
+	if _, err := built.PlanBuild(); !errors.Is(err, ErrPoolAlreadyBuilt) {
+		t.Fatalf("expected ErrPoolAlreadyBuilt, got %v", err)
+	}
+}
@@ -1,39 +1,109 @@
 package storage
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"goNAS/helper"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 )
 
-var Mirrored PoolType
-var Standard PoolType
+var Mirrored PoolType = &Mirror{}
+var Standard PoolType = &Linear{}
 
 type PoolType interface {
-	Build(*Pool) error
+	Build(context.Context, *Pool, ProgressFunc) error
 	Value() string
 }
 
+type Phase string
+
+var PhaseMdadmCreate Phase = "mdadm-create"
+var PhaseResync Phase = "resync"
+var PhaseMkfs Phase = "mkfs"
+var PhaseMount Phase = "mount"
+var PhaseRebuild Phase = "rebuild"
+var PhaseReshape Phase = "reshape"
+var PhaseResize Phase = "resize"
+
+// ProgressFunc receives the current phase and percentage of a long-running pool operation.
+type ProgressFunc func(phase Phase, percent float64)
+
+// report calls the progress function when one is set.
+func (f ProgressFunc) report(phase Phase, percent float64) {
+	if f != nil {
+		f(phase, percent)
+	}
+}
+
 type Raid struct {
 	Level int
 }
 
+// Redundant reports whether the RAID level can rebuild a lost member.
+func (r *Raid) Redundant() bool {
+	return r.Level != 0
+}
+
+// Grow adds drives to the array and starts a reshape across all members.
+func (r *Raid) Grow(p *Pool, drives []*DriveInfo) error {
+	total := len(p.Members()) + len(drives)
+	if err := helper.CheckRaidLevel(r.Level, total); err != nil {
+		return err
+	}
+	args := []string{"--grow", p.MdDevice, fmt.Sprintf("--raid-devices=%d", total), "--add"}
+	for _, d := range drives {
+		args = append(args, p.memberDevice(d.Path))
+	}
+	if err := helper.BuildMdadm(args); err != nil {
+		return errors.Join(ErrPoolGrow, err)
+	}
+	return nil
+}
+
 // Value returns the string representation of the RAID level.
 func (r *Raid) Value() string {
 	return fmt.Sprintf("raid%d", r.Level)
 }
 
 // Build creates and formats a RAID pool for the provided Pool.
-func (r *Raid) Build(p *Pool) error {
-	if err := helper.CheckRaidLevel(r.Level, len(p.AdoptedDrives)); err != nil {
+// It waits for the initial resync to finish before formatting.
+func (r *Raid) Build(ctx context.Context, p *Pool, report ProgressFunc) error {
+	levelArgs, err := r.levelArgs(p)
+	if err != nil {
 		return err
 	}
+	return buildMd(ctx, p, report, levelArgs...)
+}
+
+// PlanBuild returns the commands Build would run for the pool.
+func (r *Raid) PlanBuild(p *Pool) ([]PlanStep, error) {
+	levelArgs, err := r.levelArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	return planMd(p, levelArgs...)
+}
+
+// levelArgs checks the drive count for the level and returns its mdadm arguments.
+func (r *Raid) levelArgs(p *Pool) ([]string, error) {
+	if err := helper.CheckRaidLevel(r.Level, len(p.Members())); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("--level=%d", r.Level)}, nil
+}
+
+// buildMd creates the pool md device with the given level arguments, waits for
+// any initial resync, then formats and mounts it.
+func buildMd(ctx context.Context, p *Pool, report ProgressFunc, levelArgs ...string) error {
 	if p.Format == "" {
 		return ErrPoolFormatRequired
 	}
@@ -43,36 +113,68 @@ func (r *Raid) Build(p *Pool) error {
 	}
 	p.Name = sanitizedName
 
-	drives := make([]string, 0, len(p.AdoptedDrives))
-	for _, d := range p.AdoptedDrives {
-		drives = append(drives, DevFolder+d.Drive.Name)
-	}
-	args := append(
-		[]string{
-			"--create",
-			"--verbose",
-			p.MdDevice,
-			fmt.Sprintf("--level=%d", r.Level),
-			fmt.Sprintf("--raid-devices=%d", len(p.AdoptedDrives)),
-			fmt.Sprintf("--name=%s", p.Name),
-		},
-		drives...,
-	)
+	if p.Partitioned {
+		report.report(PhasePartition, 0)
+		members := p.Members()
+		drives := make([]*DriveInfo, 0, len(members))
+		for _, d := range members {
+			drives = append(drives, d.Drive)
+		}
+		parts, err := p.partitionDrives(drives)
+		if err != nil {
+			return err
+		}
+		p.recordPartitions(parts)
+		report.report(PhasePartition, 100)
+	}
 
-	err = helper.BuildMdadm(args)
+	report.report(PhaseMdadmCreate, 0)
+	err = helper.BuildMdadm(mdCreateArgs(p, levelArgs))
 	if err != nil {
 		return err
 	}
+	report.report(PhaseMdadmCreate, 100)
+
+	if err = p.WaitForSync(ctx, PhaseResync, report); err != nil {
+		return err
+	}
+
+	if p.IsEncrypted() {
+		if err = p.encrypt(report); err != nil {
+			return err
+		}
+	}
+
+	if p.IsLvm() {
+		// Volumes bring their own filesystems; the pool mount point only holds their mount points
+		report.report(PhaseVolumeGroup, 0)
+		if err = helper.CreateVolumeGroup(p.VolumeGroup, p.FsDevice()); err != nil {
+			return err
+		}
+		p.MountPoint = fmt.Sprintf("%s/%s", helper.DefaultMountPoint, p.Uuid)
+		if err = os.MkdirAll(p.MountPoint, 0755); err != nil {
+			return fmt.Errorf("%w: %v", helper.ErrMountPointCreate, err)
+		}
+		report.report(PhaseVolumeGroup, 100)
+		p.Status = Healthy
+		p.CalculateTotalCapacity()
+		p.CalculateAvailableCapacity()
+		return nil
+	}
 
 	// Format the RAID device
-	if err = helper.FormatPool(p.Format, p.MdDevice); err != nil {
+	report.report(PhaseMkfs, 0)
+	if err = helper.FormatPool(p.Format, p.FsDevice()); err != nil {
 		return err
 	}
+	report.report(PhaseMkfs, 100)
 
 	// Create and mount the mount point
-	if err = helper.CreateMountPoint(p.Uuid, p.MdDevice); err != nil {
+	report.report(PhaseMount, 0)
+	if err = helper.CreateMountPoint(p.Uuid, p.FsDevice(), p.MountOptions...); err != nil {
 		return err
 	}
+	report.report(PhaseMount, 100)
 
 	p.MountPoint = fmt.Sprintf("%s/%s", helper.DefaultMountPoint, p.Uuid)
 	p.Status = Healthy
@@ -81,6 +183,24 @@ func (r *Raid) Build(p *Pool) error {
 	return nil
 }
 
+// mdCreateArgs returns the mdadm arguments that create the pool md device from its drives.
+func mdCreateArgs(p *Pool, levelArgs []string) []string {
+	members := p.Members()
+	drives := make([]string, 0, len(members))
+	for _, d := range members {
+		drives = append(drives, p.memberDevice(DevFolder+d.Drive.Name))
+	}
+	// A stable member order keeps the create command identical to its plan
+	sort.Strings(drives)
+	args := []string{"--create", "--verbose", p.MdDevice}
+	args = append(args, levelArgs...)
+	args = append(args,
+		fmt.Sprintf("--raid-devices=%d", len(drives)),
+		fmt.Sprintf("--name=%s", p.Name),
+	)
+	return append(args, drives...)
+}
+
 // ParsePoolType parses a pool type string into a PoolType implementation.
 func ParsePoolType(value string) (PoolType, error) {
 	switch value {
@@ -98,6 +218,8 @@ func ParsePoolType(value string) (PoolType, error) {
 		return &Raid{Level: 6}, nil
 	case "raid10":
 		return &Raid{Level: 10}, nil
+	case "btrfs-single", "btrfs-raid1", "btrfs-raid1c3", "btrfs-raid10":
+		return ParseBtrfsType(value)
 	default:
 		return nil, ErrInvalidPoolType
 	}
@@ -109,6 +231,20 @@ var Healthy Status = "healthy"
 var Degraded Status = "degraded"
 var Offline Status = "offline"
 
+// ReasonTakenOffline marks pools deliberately taken offline so they are not
+// brought back up at start.
+var ReasonTakenOffline = "taken offline by request"
+
+// ReasonBuildFailed prefixes the status reason of pools whose build failed.
+// They stay offline until a build succeeds.
+var ReasonBuildFailed = "build failed"
+
+// HeldOffline reports whether the pool was taken offline or left locked on
+// purpose, so health checks must not overwrite its status.
+func (p *Pool) HeldOffline() bool {
+	return p.Status == Offline && (p.StatusReason == ReasonTakenOffline || p.StatusReason == ReasonLocked)
+}
+
 func (s Status) ToLower() Status {
 	return Status(strings.ToLower(string(s)))
 }
@@ -127,14 +263,26 @@ type Pool struct {
 	Name              string   `json:"name"`
 	Uuid              string   `json:"uuid"`
 	Status            Status   `json:"status"`
+	StatusReason      string   `json:"statusReason,omitempty"`
 	MountPoint        string   `json:"mountPoint"`
 	MdDevice          string   `json:"mdDevice"`
 	Type              PoolType `json:"type"`
 	TotalCapacity     uint64   `json:"totalCapacity"`
 	AvailableCapacity uint64   `json:"availableCapacity"`
 	Format            string   `json:"format"`
+	MountOptions      []string `json:"mountOptions,omitempty"`
+	ScrubSchedule     string   `json:"scrubSchedule,omitempty"`
+	VolumeGroup       string   `json:"volumeGroup,omitempty"`
+	Encrypted         bool     `json:"encrypted"`
+	LuksUuid          string   `json:"luksUuid,omitempty"`
+	Partitioned       bool     `json:"partitioned"`
+	PartitionReserve  uint64   `json:"partitionReserve,omitempty"`
 	CreatedAt         string   `json:"createdAt"`
 	AdoptedDrives     map[string]*AdoptedDrive
+	Spares            map[string]*AdoptedDrive `json:"spares"`
+	Volumes           map[string]*Volume       `json:"volumes,omitempty"`
+	Subvolumes        map[string]*Subvolume    `json:"subvolumes,omitempty"`
+	luksKey           *helper.LuksKey
 }
 
 // ShortUuid returns the first length characters of a UUID string.
@@ -170,11 +318,16 @@ func NewPool(name string, poolType PoolType, format string, drives ...*DriveInfo
 		Uuid:          poolId,
 		Status:        Offline,
 		AdoptedDrives: poolMap,
-		MdDevice:      "/dev/md/" + shortID,
+		Spares:        make(map[string]*AdoptedDrive),
 		Type:          poolType,
 		Format:        format,
 		CreatedAt:     CreationTime(),
 	}
+	// Pool types that manage their own devices have no md layer
+	if _, native := poolType.(assembler); !native {
+		pool.MdDevice = "/dev/md/" + shortID
+	}
+	pool.estimateCapacity()
 	return &pool, nil
 }
 
@@ -184,17 +337,88 @@ func (p *Pool) Clone() *Pool {
 		Name:              p.Name,
 		Uuid:              p.Uuid,
 		Status:            p.Status,
+		StatusReason:      p.StatusReason,
 		AdoptedDrives:     p.AdoptedDrives,
+		Spares:            p.Spares,
 		MountPoint:        p.MountPoint,
 		MdDevice:          p.MdDevice,
 		Type:              p.Type,
 		TotalCapacity:     p.TotalCapacity,
 		AvailableCapacity: p.AvailableCapacity,
 		Format:            p.Format,
+		MountOptions:      p.MountOptions,
+		ScrubSchedule:     p.ScrubSchedule,
+		VolumeGroup:       p.VolumeGroup,
+		Encrypted:         p.Encrypted,
+		LuksUuid:          p.LuksUuid,
+		luksKey:           p.luksKey,
+		Partitioned:       p.Partitioned,
+		PartitionReserve:  p.PartitionReserve,
+		Volumes:           p.Volumes,
+		Subvolumes:        p.Subvolumes,
 		CreatedAt:         p.CreatedAt,
 	}
 }
 
+// poolLocks holds one lock per pool UUID. It guards the AdoptedDrives,
+// Spares, Volumes and Subvolumes maps and the member states, roles and
+// partitions within them. Clones share those maps, so the lock follows the
+// pool UUID rather than the Pool value.
+var poolLocks sync.Map
+
+// lock returns the lock guarding the pool maps.
+func (p *Pool) lock() *sync.RWMutex {
+	mu, _ := poolLocks.LoadOrStore(p.Uuid, &sync.RWMutex{})
+	return mu.(*sync.RWMutex)
+}
+
+// Members returns the active pool members. The slice is a snapshot that is
+// safe to range over while drives join or leave the pool.
+func (p *Pool) Members() []*AdoptedDrive {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	members := make([]*AdoptedDrive, 0, len(p.AdoptedDrives))
+	for _, d := range p.AdoptedDrives {
+		members = append(members, d)
+	}
+	return members
+}
+
+// DedicatedSpares returns a snapshot of the pool hot spares.
+func (p *Pool) DedicatedSpares() []*AdoptedDrive {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	spares := make([]*AdoptedDrive, 0, len(p.Spares))
+	for _, d := range p.Spares {
+		spares = append(spares, d)
+	}
+	return spares
+}
+
+// Member returns the pool member or dedicated spare with the given UUID.
+func (p *Pool) Member(uuid string) (*AdoptedDrive, bool) {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	if d, ok := p.AdoptedDrives[uuid]; ok {
+		return d, true
+	}
+	d, ok := p.Spares[uuid]
+	return d, ok
+}
+
+// MarshalJSON encodes the pool while holding its lock, so the drive, spare,
+// volume and subvolume maps are not changed during encoding.
+func (p *Pool) MarshalJSON() ([]byte, error) {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	type pool Pool
+	return json.Marshal((*pool)(p))
+}
+
 type Pools map[string]*Pool
 
 func (p *Pools) GetPool(uuid string) (*Pool, error) {
@@ -249,29 +473,49 @@ func (p *Pools) DeletePool(uuid string) error {
 	return nil
 }
 
-// Build constructs the pool using its configured PoolType.
-func (p *Pool) Build() error {
-	return p.Type.Build(p)
+// Build constructs the pool using its configured PoolType. A failed build
+// leaves the pool offline with the failure as its status reason.
+// report may be nil when progress is not needed.
+func (p *Pool) Build(ctx context.Context, report ProgressFunc) error {
+	if p.Type == nil {
+		return ErrInvalidPoolType
+	}
+	for _, d := range p.Members() {
+		if d.Missing {
+			return fmt.Errorf("%w: %s", ErrDriveMissing, d.Key())
+		}
+	}
+	if err := p.Type.Build(ctx, p, report); err != nil {
+		p.Status = Offline
+		p.StatusReason = fmt.Sprintf("%s: %v", ReasonBuildFailed, err)
+		return err
+	}
+	p.StatusReason = ""
+	return nil
 }
 
 // AddDrives adopts and adds drives to the pool.
 func (p *Pool) AddDrives(drive ...*DriveInfo) {
+	mu := p.lock()
+	mu.Lock()
 	for i := range drive {
 		adoptedDrive := NewAdoptedDrive(drive[i])
 		adoptedDrive.SetPoolID(p.Uuid)
 		p.AdoptedDrives[adoptedDrive.GetUuid()] = adoptedDrive
 	}
+	mu.Unlock()
+	p.estimateCapacity()
 }
 
 // GetDrives returns drives in the pool matching the provided UUIDs.
 func (p *Pool) GetDrives(uuids ...string) []*DriveInfo {
 	var drives = make([]*DriveInfo, 0)
-	for i := range p.AdoptedDrives {
+	for _, d := range p.Members() {
 		for _, id := range uuids {
-			if p.AdoptedDrives[i].Drive.Uuid != id {
+			if d.Drive.Uuid != id {
 				continue
 			}
-			drives = append(drives, p.AdoptedDrives[i].Drive)
+			drives = append(drives, d.Drive)
 		}
 	}
 	return drives
@@ -288,50 +532,348 @@ func (p *Pool) RemoveDrives(uuids ...string) error {
 		return ErrNoDrivesToRemove
 	}
 
+	mu := p.lock()
+	mu.Lock()
 	for name, d := range p.AdoptedDrives {
 		if toRemove[d.Drive.Uuid] {
 			delete(p.AdoptedDrives, name)
 		}
 	}
+	mu.Unlock()
+	p.estimateCapacity()
 	return nil
 }
 
-// UnmountDrive unmounts and removes the pool mount point directory.
-func (p *Pool) UnmountDrive() error {
-	if err := exec.Command("sudo", "umount", p.MountPoint).Run(); err != nil {
-		return errors.Join(ErrPoolDeleteUnmount, err)
+// IsBuilt reports whether the pool array has been created and given a mount point.
+func (p *Pool) IsBuilt() bool {
+	return p.MountPoint != ""
+}
+
+// IsAssembled reports whether the pool md device currently exists.
+func (p *Pool) IsAssembled() bool {
+	_, err := p.KernelDevice()
+	return err == nil
+}
+
+// MemberPaths returns the device paths of the attached pool members and dedicated spares.
+func (p *Pool) MemberPaths() []string {
+	members := append(p.Members(), p.DedicatedSpares()...)
+	paths := make([]string, 0, len(members))
+	for _, d := range members {
+		if !d.Missing {
+			paths = append(paths, p.memberDevice(d.Drive.Path))
+		}
 	}
-	if err := exec.Command("sudo", "rmdir", p.MountPoint).Run(); err != nil {
-		return errors.Join(ErrPoolDeleteRmdir, err)
+	sort.Strings(paths)
+	return paths
+}
+
+// assembler is implemented by pool types that bring up their own devices instead of an md array.
+type assembler interface {
+	Assemble(p *Pool) error
+	Stop(p *Pool) error
+	Wipe(p *Pool) error
+}
+
+// FsDevice returns the block device the pool filesystem is created on and mounted from.
+func (p *Pool) FsDevice() string {
+	if p.IsEncrypted() {
+		return helper.MapperDevice(p.MapperName())
+	}
+	if p.MdDevice != "" {
+		return p.MdDevice
+	}
+	members := p.Members()
+	paths := make([]string, 0, len(members))
+	for _, d := range members {
+		paths = append(paths, d.Drive.Path)
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	sort.Strings(paths)
+	return paths[0]
+}
+
+// assemble brings up the pool devices: the pool type's own assembly or the md array.
+func (p *Pool) assemble() error {
+	if a, ok := p.Type.(assembler); ok {
+		if err := a.Assemble(p); err != nil {
+			return errors.Join(ErrPoolAssemble, err)
+		}
+		return nil
+	}
+	if p.IsAssembled() {
+		return nil
+	}
+	members := p.MemberPaths()
+	if len(members) == 0 {
+		return fmt.Errorf("%w: no member drives present", ErrInsufficientDrives)
+	}
+	args := append([]string{"--assemble", "--run", p.MdDevice}, members...)
+	if err := helper.BuildMdadm(args); err != nil {
+		return errors.Join(ErrPoolAssemble, err)
+	}
+	return nil
+}
+
+// stop releases the pool devices once the filesystem is unmounted.
+func (p *Pool) stop() error {
+	if a, ok := p.Type.(assembler); ok {
+		if err := a.Stop(p); err != nil {
+			return errors.Join(ErrPoolDeleteStop, err)
+		}
+		return nil
+	}
+	if err := p.closeLuks(); err != nil {
+		return err
+	}
+	if !p.IsAssembled() {
+		return nil
+	}
+	if err := helper.BuildMdadm([]string{"--stop", p.MdDevice}); err != nil {
+		return errors.Join(ErrPoolDeleteStop, err)
+	}
+	return nil
+}
+
+// Offline unmounts the pool and stops its md array.
+// It refuses when a process still holds files below the mount point.
+func (p *Pool) Offline() error {
+	if !p.IsBuilt() {
+		p.ApplyHealth(PoolHealth{Status: Offline, Reason: ReasonTakenOffline})
+		return nil
+	}
+
+	if p.IsLvm() {
+		if err := p.deactivateVolumes(); err != nil {
+			return err
+		}
+	} else if helper.IsMounted(p.MountPoint) {
+		holders, err := helper.MountHolders(p.MountPoint)
+		if err != nil {
+			return err
+		}
+		if len(holders) > 0 {
+			return fmt.Errorf("%w: held open by pids %v", ErrPoolInUse, holders)
+		}
+		if err = helper.Unmount(p.MountPoint); err != nil {
+			return errors.Join(ErrPoolDeleteUnmount, err)
+		}
+	}
+
+	if err := p.stop(); err != nil {
+		return err
+	}
+
+	p.ApplyHealth(PoolHealth{Status: Offline, Reason: ReasonTakenOffline})
+	return nil
+}
+
+// Online assembles the pool array from its recorded members and mounts it.
+// The status is taken from the array once it is running.
+func (p *Pool) Online() error {
+	if !p.IsBuilt() {
+		return ErrPoolNotBuilt
+	}
+
+	if err := p.assemble(); err != nil {
+		return err
+	}
+	if p.IsEncrypted() && !p.IsUnlocked() {
+		return ErrPoolLocked
+	}
+
+	if p.IsLvm() {
+		if err := p.activateVolumes(); err != nil {
+			return err
+		}
+	} else if !helper.IsMounted(p.MountPoint) {
+		if err := helper.Mount(p.FsDevice(), p.MountPoint, p.MountOptions...); err != nil {
+			return err
+		}
+	}
+
+	arrays, err := ReadMdstat()
+	if err != nil {
+		return err
+	}
+	p.ApplyHealth(p.CheckHealth(arrays))
+	p.CalculateTotalCapacity()
+	p.CalculateAvailableCapacity()
+	return nil
+}
+
+// Reconcile brings a persisted pool back up after a restart. Failures leave the
+// pool offline with the error recorded as the reason.
+func (p *Pool) Reconcile() error {
+	if !p.IsBuilt() {
+		return nil
+	}
+	if p.Status == Offline && p.StatusReason == ReasonTakenOffline {
+		return nil
+	}
+	// Keys are never stored, so encrypted pools wait to be unlocked
+	if p.IsEncrypted() && !p.IsUnlocked() {
+		p.ApplyHealth(PoolHealth{Status: Offline, Reason: ReasonLocked})
+		return nil
+	}
+	if err := p.Online(); err != nil {
+		p.ApplyHealth(PoolHealth{Status: Offline, Reason: err.Error()})
+		return err
+	}
+	return nil
+}
+
+// redundant is implemented by pool types that can rebuild a lost member.
+type redundant interface {
+	Redundant() bool
+}
+
+// IsRedundant reports whether the pool type can rebuild a replaced member.
+func (p *Pool) IsRedundant() bool {
+	r, ok := p.Type.(redundant)
+	return ok && r.Redundant()
+}
+
+// ReplaceDrive fails and removes the member with the given UUID, when it is
+// still part of the array, and adds replacement so md rebuilds onto it.
+// It returns the removed member.
+func (p *Pool) ReplaceDrive(failedUuid string, replacement *DriveInfo) (*AdoptedDrive, error) {
+	if !p.IsRedundant() {
+		return nil, ErrNoRedundancy
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return nil, ErrPoolNotBuilt
+	}
+	p.lock().RLock()
+	failed, ok := p.AdoptedDrives[failedUuid]
+	p.lock().RUnlock()
+	if !ok {
+		return nil, ErrDriveNotInPool
+	}
+
+	name, err := p.KernelDevice()
+	if err != nil {
+		return nil, err
+	}
+	arrays, err := ReadMdstat()
+	if err != nil {
+		return nil, err
+	}
+	// A member that was absent at startup has no kernel name and is not in the array
+	if array, ok := arrays[name]; ok && failed.Drive.Name != "" {
+		if _, isMember := array.Member(p.memberName(failed.Drive)); isMember {
+			member := p.memberDevice(failed.Drive.Path)
+			if failed.Missing {
+				// The device node is gone; mdadm still drops it by state
+				member = "detached"
+			}
+			args := []string{"--manage", p.MdDevice, "--fail", member, "--remove", member}
+			if err = helper.BuildMdadm(args); err != nil {
+				return nil, errors.Join(ErrDriveReplace, err)
+			}
+		}
+	}
+
+	parts, err := p.partitionDrives([]*DriveInfo{replacement})
+	if err != nil {
+		return nil, errors.Join(ErrDriveReplace, err)
+	}
+	if err = helper.BuildMdadm([]string{"--manage", p.MdDevice, "--add", p.memberDevice(replacement.Path)}); err != nil {
+		return nil, errors.Join(ErrDriveReplace, err)
+	}
+
+	p.lock().Lock()
+	delete(p.AdoptedDrives, failedUuid)
+	p.lock().Unlock()
+	p.AddDrives(replacement)
+	p.recordPartitions(parts)
+	return failed, nil
+}
+
+// grower is implemented by pool types whose arrays can take additional drives.
+type grower interface {
+	Grow(p *Pool, drives []*DriveInfo) error
+}
+
+// Grow adds drives to a built pool and starts reshaping the array onto them.
+// FinishGrow completes the operation once the reshape is running.
+func (p *Pool) Grow(drives ...*DriveInfo) error {
+	g, ok := p.Type.(grower)
+	if !ok {
+		return ErrGrowUnsupported
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return ErrPoolNotBuilt
+	}
+	if len(drives) == 0 {
+		return ErrInsufficientDrives
+	}
+	parts, err := p.partitionDrives(drives)
+	if err != nil {
+		return errors.Join(ErrPoolGrow, err)
+	}
+	if err = g.Grow(p, drives); err != nil {
+		return err
+	}
+	p.AddDrives(drives...)
+	p.recordPartitions(parts)
+	return nil
+}
+
+// FinishGrow waits for the reshape started by Grow, grows the filesystem and
+// refreshes the pool capacity.
+func (p *Pool) FinishGrow(ctx context.Context, report ProgressFunc) error {
+	if err := p.WaitForSync(ctx, PhaseReshape, report); err != nil {
+		return err
+	}
+	return p.GrowFilesystem(report)
+}
+
+// GrowFilesystem grows the pool filesystem to fill its device and refreshes the pool capacity.
+func (p *Pool) GrowFilesystem(report ProgressFunc) error {
+	report.report(PhaseResize, 0)
+	if p.IsEncrypted() {
+		if err := helper.LuksResize(p.MapperName()); err != nil {
+			return errors.Join(helper.ErrResizeFilesystem, err)
+		}
 	}
+	if p.IsLvm() {
+		// The new space goes to the volume group; volumes are grown individually
+		if err := helper.ResizePhysicalVolume(p.FsDevice()); err != nil {
+			return errors.Join(helper.ErrResizeFilesystem, err)
+		}
+	} else if err := helper.ResizeFilesystem(p.Format, p.FsDevice(), p.MountPoint); err != nil {
+		return err
+	}
+	report.report(PhaseResize, 100)
+	p.CalculateTotalCapacity()
+	p.CalculateAvailableCapacity()
 	return nil
 }
 
-// Delete tears down the RAID device and clears superblocks from member drives.
+// Delete removes the mount directory and clears superblocks from member drives.
+// The pool must be taken offline first so its array is already stopped.
 func (p *Pool) Delete() error {
 	if p.Status != Offline {
 		return ErrPoolNotOffline
 	}
-	if err := p.UnmountDrive(); err != nil {
+	if err := p.CheckNoVolumes(); err != nil {
 		return err
 	}
-
-	args := []string{"mdadm", "--remove", p.MdDevice}
-	mdamRemove := exec.Command("sudo", args...)
-	if err := mdamRemove.Run(); err != nil {
-		return errors.Join(ErrPoolDeleteRemove, err)
-	}
-	args = []string{"mdadm", "--stop", p.MdDevice}
-	mdamStop := exec.Command("sudo", args...)
-	if err := mdamStop.Run(); err != nil {
-		return errors.Join(ErrPoolDeleteStop, err)
+	if err := os.Remove(p.MountPoint); err != nil && !os.IsNotExist(err) {
+		return errors.Join(ErrPoolDeleteRmdir, err)
 	}
 
-	var drivePaths []string
-	for _, d := range p.AdoptedDrives {
-		drivePaths = append(drivePaths, d.Drive.Path)
+	if a, ok := p.Type.(assembler); ok {
+		if err := a.Wipe(p); err != nil {
+			return errors.Join(ErrPoolDeleteZeroSB, err)
+		}
+		return nil
 	}
-	args = append([]string{"mdadm", "--zero-superblock"}, drivePaths...)
+
+	args := append([]string{"mdadm", "--zero-superblock"}, p.MemberPaths()...)
 	zeroOut := exec.Command("sudo", args...)
 	zeroOut.Stderr = os.Stderr
 	if err := zeroOut.Run(); err != nil {
@@ -350,6 +892,11 @@ func (p *Pool) SetStatus(status Status) {
 	p.Status = status
 }
 
+// SetType updates the pool type.
+func (p *Pool) SetType(poolType PoolType) {
+	p.Type = poolType
+}
+
 // SetFormat updates the pool filesystem format.
 func (p *Pool) SetFormat(format string) {
 	p.Format = format
@@ -392,7 +939,15 @@ func GetPoolCapacity(device string) (total uint64, avail uint64, err error) {
 
 // CalculateTotalCapacity updates TotalCapacity from the pool device.
 func (p *Pool) CalculateTotalCapacity() {
-	total, _, err := GetPoolCapacity(p.MdDevice)
+	if p.IsLvm() {
+		total, _, err := helper.VolumeGroupCapacity(p.VolumeGroup)
+		if err != nil {
+			total = 0
+		}
+		p.TotalCapacity = total
+		return
+	}
+	total, _, err := GetPoolCapacity(p.FsDevice())
 	if err != nil {
 		p.TotalCapacity = 0
 		return
@@ -402,7 +957,15 @@ func (p *Pool) CalculateTotalCapacity() {
 
 // CalculateAvailableCapacity updates AvailableCapacity from the pool device.
 func (p *Pool) CalculateAvailableCapacity() {
-	_, avail, err := GetPoolCapacity(p.MdDevice)
+	if p.IsLvm() {
+		_, free, err := helper.VolumeGroupCapacity(p.VolumeGroup)
+		if err != nil {
+			free = 0
+		}
+		p.AvailableCapacity = free
+		return
+	}
+	_, avail, err := GetPoolCapacity(p.FsDevice())
 	if err != nil {
 		p.AvailableCapacity = 0
 		return
@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"goNAS/helper"
 	"testing"
 )
@@ -27,3 +29,159 @@ func TestNewPoolRejectsInvalidName(t *testing.T) {
 		t.Fatalf("expected ErrInvalidRaidName, got %v", err)
 	}
 }
+
+func TestReconcileSkipsPoolsTakenOffline(t *testing.T) {
+	pool := &Pool{
+		MountPoint:   "/mnt/pools/test",
+		Status:       Offline,
+		StatusReason: ReasonTakenOffline,
+	}
+	if err := pool.Reconcile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.Status != Offline || pool.StatusReason != ReasonTakenOffline {
+		t.Fatalf("expected pool to stay offline by request, got %q (%s)", pool.Status, pool.StatusReason)
+	}
+}
+
+func TestReconcileRecordsFailureReason(t *testing.T) {
+	pool := &Pool{
+		MountPoint:    "/mnt/pools/test",
+		MdDevice:      "/dev/md/does-not-exist",
+		Status:        Healthy,
+		AdoptedDrives: make(map[string]*AdoptedDrive),
+	}
+	err := pool.Reconcile()
+	if !errors.Is(err, ErrInsufficientDrives) {
+		t.Fatalf("expected ErrInsufficientDrives, got %v", err)
+	}
+	if pool.Status != Offline || pool.StatusReason == "" {
+		t.Fatalf("expected offline pool with reason, got %q (%s)", pool.Status, pool.StatusReason)
+	}
+}
+
+func TestReplaceDriveRefusesRaid0(t *testing.T) {
+	pool, err := NewPool("stripe", &Raid{Level: 0}, "ext4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = pool.ReplaceDrive("failed", &DriveInfo{Name: "sdz"})
+	if !errors.Is(err, ErrNoRedundancy) {
+		t.Fatalf("expected ErrNoRedundancy, got %v", err)
+	}
+}
+
+func TestPromoteSparesMovesRebuildingSpares(t *testing.T) {
+	pool, err := NewPool("mirror", &Raid{Level: 1}, "ext4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool.AddDrives(&DriveInfo{Name: "sdb", Uuid: "a", SizeBytes: 100})
+	pool.AddSpares(&DriveInfo{Name: "sdc", Uuid: "b", SizeBytes: 100})
+
+	pool.ApplyHealth(PoolHealth{Status: Degraded, Members: map[string]MemberState{"sdb": MemberInSync, "sdc": MemberSpare}})
+	if !pool.HasIdleSpare() || pool.NeedsSpare() {
+		t.Fatal("expected idle dedicated spare to cover the degraded pool")
+	}
+	if promoted := pool.PromoteSpares(); len(promoted) != 0 {
+		t.Fatalf("expected idle spare to stay a spare, got %d promoted", len(promoted))
+	}
+
+	pool.ApplyHealth(PoolHealth{Status: Degraded, Members: map[string]MemberState{"sdb": MemberInSync, "sdc": MemberRebuilding}})
+	promoted := pool.PromoteSpares()
+	if len(promoted) != 1 || promoted[0].GetRole() != RoleActive {
+		t.Fatalf("expected rebuilding spare to be promoted, got %+v", promoted)
+	}
+	if _, ok := pool.AdoptedDrives["b"]; !ok || len(pool.Spares) != 0 {
+		t.Fatal("expected promoted spare to move to the active members")
+	}
+	if pool.NeedsSpare() {
+		t.Fatal("expected rebuilding pool not to need another spare")
+	}
+	if pool.SpareFits(&DriveInfo{SizeBytes: 50}) {
+		t.Fatal("expected smaller drive not to fit as a spare")
+	}
+}
+
+func TestSpareFitsSmallestMember(t *testing.T) {
+	g := helper.Gigabyte
+	pool, _ := NewPool("mixed", &Raid{Level: 1}, "ext4",
+		&DriveInfo{Name: "sda", Uuid: "a", SizeBytes: 2 * g},
+		&DriveInfo{Name: "sdb", Uuid: "b", SizeBytes: 4 * g},
+	)
+	if !pool.SpareFits(&DriveInfo{SizeBytes: 3 * g}) {
+		t.Fatal("expected a spare larger than the smallest member to fit")
+	}
+
+	// Partitioned pools compare the partitions md uses, not the raw drives
+	_ = pool.EnablePartitioning(0)
+	if !pool.SpareFits(&DriveInfo{SizeBytes: 2 * g}) {
+		t.Fatal("expected a spare the size of the smallest member to fit")
+	}
+	if !pool.SpareFits(&DriveInfo{SizeBytes: 2*g - 100*1024}) {
+		t.Fatal("expected a slightly smaller drive with the same partition to fit")
+	}
+	if pool.SpareFits(&DriveInfo{SizeBytes: 2*g - 10*helper.Megabyte}) {
+		t.Fatal("expected a spare with a smaller partition not to fit")
+	}
+}
+
+func TestParsePoolTypeRoundTrip(t *testing.T) {
+	for _, value := range []string{"standard", "mirrored", "raid0", "raid1", "raid5", "raid6", "raid10", "btrfs-single", "btrfs-raid1", "btrfs-raid1c3", "btrfs-raid10"} {
+		poolType, err := ParsePoolType(value)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", value, err)
+		}
+		if poolType == nil || poolType.Value() != value {
+			t.Fatalf("expected %q to round trip, got %v", value, poolType)
+		}
+	}
+}
+
+func TestStandardAndMirroredValidateDrives(t *testing.T) {
+	standard, _ := NewPool("jbod", Standard, "ext4")
+	if err := standard.Build(context.Background(), nil); !errors.Is(err, ErrInsufficientDrives) {
+		t.Fatalf("expected ErrInsufficientDrives, got %v", err)
+	}
+	if standard.IsRedundant() {
+		t.Fatal("expected standard pool to have no redundancy")
+	}
+
+	mirrored, _ := NewPool("mirror", Mirrored, "ext4", &DriveInfo{Name: "sdb", Uuid: "a"})
+	if err := mirrored.Build(context.Background(), nil); !errors.Is(err, helper.ErrRaid1RequiresDrives) {
+		t.Fatalf("expected ErrRaid1RequiresDrives, got %v", err)
+	}
+	if !mirrored.IsRedundant() {
+		t.Fatal("expected mirrored pool to be redundant")
+	}
+}
+
+func TestPromoteSparesDuringReads(t *testing.T) {
+	pool, _ := NewPool("media", &Raid{Level: 1}, "ext4", &DriveInfo{Name: "sda"}, &DriveInfo{Name: "sdb"})
+	clone := pool.Clone()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			pool.AddSpares(&DriveInfo{Name: fmt.Sprintf("sd%d", i)})
+			for _, s := range pool.DedicatedSpares() {
+				s.SetState(MemberRebuilding)
+			}
+			pool.PromoteSpares()
+		}
+	}()
+	// A clone shares the pool maps, so it must read them under the same lock
+	for i := 0; i < 100; i++ {
+		_ = clone.MemberPaths()
+		_ = clone.SpareFits(&DriveInfo{})
+	}
+	<-done
+
+	if members := len(pool.Members()); members != 102 {
+		t.Fatalf("expected every spare to be promoted, got %d members", members)
+	}
+	if spares := pool.DedicatedSpares(); len(spares) != 0 {
+		t.Fatalf("expected no spares left, got %d", len(spares))
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"goNAS/helper"
+)
+
+// Linear concatenates drives of any size into a single md device without redundancy.
+type Linear struct{}
+
+// Value returns the string representation of the standard pool type.
+func (l *Linear) Value() string {
+	return "standard"
+}
+
+// Redundant reports false; a lost member takes the whole concatenation with it.
+func (l *Linear) Redundant() bool {
+	return false
+}
+
+// Build creates a linear md device across the pool drives and formats it.
+func (l *Linear) Build(ctx context.Context, p *Pool, report ProgressFunc) error {
+	levelArgs, err := l.levelArgs(p)
+	if err != nil {
+		return err
+	}
+	return buildMd(ctx, p, report, levelArgs...)
+}
+
+// PlanBuild returns the commands Build would run for the pool.
+func (l *Linear) PlanBuild(p *Pool) ([]PlanStep, error) {
+	levelArgs, err := l.levelArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	return planMd(p, levelArgs...)
+}
+
+// levelArgs checks the pool has drives and returns the linear mdadm arguments.
+func (l *Linear) levelArgs(p *Pool) ([]string, error) {
+	members := len(p.Members())
+	if members == 0 {
+		return nil, ErrInsufficientDrives
+	}
+	levelArgs := []string{"--level=linear"}
+	// mdadm refuses a single-member array unless forced
+	if members == 1 {
+		levelArgs = append(levelArgs, "--force")
+	}
+	return levelArgs, nil
+}
+
+// Grow appends drives to the end of the linear array one at a time.
+func (l *Linear) Grow(p *Pool, drives []*DriveInfo) error {
+	for _, d := range drives {
+		if err := helper.BuildMdadm([]string{"--grow", p.MdDevice, "--add", p.memberDevice(d.Path)}); err != nil {
+			return errors.Join(ErrPoolGrow, err)
+		}
+	}
+	return nil
+}
+
+// Mirror keeps a full copy of the data on every drive in the pool.
+type Mirror struct{}
+
+// Value returns the string representation of the mirrored pool type.
+func (m *Mirror) Value() string {
+	return "mirrored"
+}
+
+// Redundant reports true; any single surviving member holds all data.
+func (m *Mirror) Redundant() bool {
+	return true
+}
+
+// Build creates an N-way raid1 md device across all pool drives and formats it.
+func (m *Mirror) Build(ctx context.Context, p *Pool, report ProgressFunc) error {
+	return (&Raid{Level: 1}).Build(ctx, p, report)
+}
+
+// PlanBuild returns the commands Build would run for the pool.
+func (m *Mirror) PlanBuild(p *Pool) ([]PlanStep, error) {
+	return (&Raid{Level: 1}).PlanBuild(p)
+}
+
+// Grow adds drives as additional mirror copies.
+func (m *Mirror) Grow(p *Pool, drives []*DriveInfo) error {
+	return (&Raid{Level: 1}).Grow(p, drives)
+}
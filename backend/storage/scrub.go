@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var PhaseScrub Phase = "scrub"
+
+// MinScrubInterval is the shortest accepted scrub schedule.
+var MinScrubInterval = time.Hour
+
+// ScrubOff disables scheduled scrubs for a pool.
+var ScrubOff = "off"
+
+type ScrubAction string
+
+var ScrubCheck ScrubAction = "check"
+var ScrubRepair ScrubAction = "repair"
+
+type ScrubResult string
+
+var ScrubRunning ScrubResult = "running"
+var ScrubClean ScrubResult = "clean"
+var ScrubMismatches ScrubResult = "mismatches"
+var ScrubCanceled ScrubResult = "canceled"
+var ScrubFailed ScrubResult = "failed"
+var ScrubInterrupted ScrubResult = "interrupted"
+
+type Scrub struct {
+	ID         string      `json:"id"`
+	PoolID     string      `json:"poolID"`
+	Action     ScrubAction `json:"action"`
+	StartedAt  string      `json:"startedAt"`
+	EndedAt    string      `json:"endedAt,omitempty"`
+	Mismatches uint64      `json:"mismatches"`
+	Result     ScrubResult `json:"result"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewScrub records the start of a scrub of the pool.
+func NewScrub(poolID string, action ScrubAction) *Scrub {
+	return &Scrub{
+		ID:        uuid.New().String(),
+		PoolID:    poolID,
+		Action:    action,
+		StartedAt: CreationTime(),
+		Result:    ScrubRunning,
+	}
+}
+
+// Finish records the end of the scrub, its mismatch count and outcome. A
+// context cancellation is recorded as canceled only when the user canceled it,
+// otherwise the server stopped while md kept going and it is interrupted.
+func (s *Scrub) Finish(mismatches uint64, err error, canceled bool) {
+	s.EndedAt = CreationTime()
+	s.Mismatches = mismatches
+	switch {
+	case err == nil && mismatches == 0:
+		s.Result = ScrubClean
+	case err == nil:
+		s.Result = ScrubMismatches
+	case canceled && errors.Is(err, context.Canceled):
+		s.Result = ScrubCanceled
+	case errors.Is(err, context.Canceled):
+		s.Result = ScrubInterrupted
+	default:
+		s.Result = ScrubFailed
+		s.Error = err.Error()
+	}
+}
+
+// ParseScrubAction parses a scrub action, defaulting to check.
+func ParseScrubAction(value string) (ScrubAction, error) {
+	switch ScrubAction(value) {
+	case "", ScrubCheck:
+		return ScrubCheck, nil
+	case ScrubRepair:
+		return ScrubRepair, nil
+	default:
+		return "", ErrInvalidScrubAction
+	}
+}
+
+// ValidateScrubSchedule returns an error unless schedule is off or a duration of at least MinScrubInterval.
+func ValidateScrubSchedule(schedule string) error {
+	if schedule == ScrubOff {
+		return nil
+	}
+	interval, err := time.ParseDuration(schedule)
+	if err != nil || interval < MinScrubInterval {
+		return ErrInvalidScrubSchedule
+	}
+	return nil
+}
+
+// ScrubDue reports whether the pool schedule calls for a scrub given the start
+// time of the last one, which is empty when the pool was never scrubbed.
+func (p *Pool) ScrubDue(lastStarted string, now time.Time) bool {
+	if p.ScrubSchedule == "" || p.ScrubSchedule == ScrubOff {
+		return false
+	}
+	interval, err := time.ParseDuration(p.ScrubSchedule)
+	if err != nil {
+		return false
+	}
+	if lastStarted == "" {
+		return true
+	}
+	last, err := time.Parse(time.RFC3339Nano, lastStarted)
+	if err != nil {
+		return true
+	}
+	return now.Sub(last) >= interval
+}
+
+// mdSysfsFile returns the path of a file in the pool's /sys/block/<name>/md directory.
+func (p *Pool) mdSysfsFile(file string) (string, error) {
+	name, err := p.KernelDevice()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(SysBlockPath, name, "md", file), nil
+}
+
+// SetSyncAction writes action to the array's sync_action file.
+func (p *Pool) SetSyncAction(action string) error {
+	path, err := p.mdSysfsFile("sync_action")
+	if err != nil {
+		return errors.Join(ErrPoolScrub, err)
+	}
+	if err = os.WriteFile(path, []byte(action), 0644); err != nil {
+		return errors.Join(ErrPoolScrub, err)
+	}
+	return nil
+}
+
+// MismatchCount reads the mismatch_cnt left by the last check or repair.
+func (p *Pool) MismatchCount() (uint64, error) {
+	path, err := p.mdSysfsFile("mismatch_cnt")
+	if err != nil {
+		return 0, err
+	}
+	if _, err = os.Stat(path); err != nil {
+		return 0, err
+	}
+	return readUint(path), nil
+}
+
+// StartScrub starts a check or repair pass over an idle pool array.
+func (p *Pool) StartScrub(action ScrubAction) error {
+	if !p.IsRedundant() {
+		return ErrScrubUnsupported
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return ErrPoolNotBuilt
+	}
+	path, err := p.mdSysfsFile("sync_action")
+	if err != nil {
+		return errors.Join(ErrPoolScrub, err)
+	}
+	if current := readString(path); current != "idle" {
+		return fmt.Errorf("%w: %s", ErrPoolSyncing, current)
+	}
+	return p.SetSyncAction(string(action))
+}
+
+// FinishScrub waits for a started scrub to end and returns the resulting mismatch count.
+func (p *Pool) FinishScrub(ctx context.Context, report ProgressFunc) (uint64, error) {
+	if err := p.WaitForSync(ctx, PhaseScrub, report); err != nil {
+		mismatches, _ := p.MismatchCount()
+		return mismatches, err
+	}
+	return p.MismatchCount()
+}
+
+// CancelScrub stops a running check or repair by writing idle to sync_action.
+func (p *Pool) CancelScrub() error {
+	return p.SetSyncAction("idle")
+}
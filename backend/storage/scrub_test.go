@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStartScrubWritesSyncAction(t *testing.T) {
+	root := t.TempDir()
+	previous := SysBlockPath
+	SysBlockPath = root
+	defer func() { SysBlockPath = previous }()
+
+	device := filepath.Join(t.TempDir(), "md127")
+	if err := os.WriteFile(device, nil, 0644); err != nil {
+		t.Fatalf("failed to create fake md device: %v", err)
+	}
+	writeSysfs(t, root, "md127", map[string]string{"sync_action": "resync", "mismatch_cnt": "128"})
+
+	pool := &Pool{Type: &Raid{Level: 1}, MdDevice: device, MountPoint: "/mnt/pool"}
+	if err := pool.StartScrub(ScrubCheck); !errors.Is(err, ErrPoolSyncing) {
+		t.Fatalf("expected ErrPoolSyncing, got %v", err)
+	}
+
+	writeSysfs(t, root, "md127", map[string]string{"sync_action": "idle"})
+	if err := pool.StartScrub(ScrubRepair); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	action, _ := os.ReadFile(filepath.Join(root, "md127", "md", "sync_action"))
+	if string(action) != "repair" {
+		t.Fatalf("expected repair written to sync_action, got %q", action)
+	}
+	if count, err := pool.MismatchCount(); err != nil || count != 128 {
+		t.Fatalf("expected 128 mismatches, got %d (%v)", count, err)
+	}
+
+	stripe := &Pool{Type: &Raid{Level: 0}, MdDevice: device, MountPoint: "/mnt/pool"}
+	if err := stripe.StartScrub(ScrubCheck); !errors.Is(err, ErrScrubUnsupported) {
+		t.Fatalf("expected ErrScrubUnsupported, got %v", err)
+	}
+}
+
+func TestScrubFinishAndSchedule(t *testing.T) {
+	scrub := NewScrub("pool", ScrubCheck)
+	scrub.Finish(0, nil, false)
+	if scrub.Result != ScrubClean || scrub.EndedAt == "" {
+		t.Fatalf("expected clean finished scrub, got %+v", scrub)
+	}
+	scrub.Finish(8, nil, false)
+	if scrub.Result != ScrubMismatches {
+		t.Fatalf("expected mismatches, got %q", scrub.Result)
+	}
+	scrub.Finish(0, context.Canceled, true)
+	if scrub.Result != ScrubCanceled {
+		t.Fatalf("expected canceled, got %q", scrub.Result)
+	}
+	scrub.Finish(0, context.Canceled, false)
+	if scrub.Result != ScrubInterrupted {
+		t.Fatalf("expected scrub stopped by shutdown to be interrupted, got %q", scrub.Result)
+	}
+
+	if err := ValidateScrubSchedule("10m"); !errors.Is(err, ErrInvalidScrubSchedule) {
+		t.Fatalf("expected ErrInvalidScrubSchedule, got %v", err)
+	}
+	now := time.Now().UTC()
+	pool := &Pool{ScrubSchedule: "168h"}
+	if !pool.ScrubDue("", now) {
+		t.Fatal("expected never-scrubbed pool to be due")
+	}
+	if pool.ScrubDue(now.Add(-24*time.Hour).Format(time.RFC3339Nano), now) {
+		t.Fatal("expected recently scrubbed pool not to be due")
+	}
+	if !pool.ScrubDue(now.Add(-200*time.Hour).Format(time.RFC3339Nano), now) {
+		t.Fatal("expected pool past its schedule to be due")
+	}
+	pool.ScrubSchedule = ScrubOff
+	if pool.ScrubDue("", now) {
+		t.Fatal("expected disabled schedule never to be due")
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/google/uuid"
+)
+
+type DriveHealth string
+
+var DriveHealthUnknown DriveHealth = "unknown"
+var DriveHealthOK DriveHealth = "healthy"
+var DriveHealthWarning DriveHealth = "warning"
+var DriveHealthFailing DriveHealth = "failing"
+
+// ATA attribute IDs counted as bad sectors.
+const (
+	ataReallocatedSectors   = 5
+	ataPendingSectors       = 197
+	ataOfflineUncorrectable = 198
+)
+
+// smartctlFatal masks the smartctl exit status bits for a bad command line or
+// a device that could not be opened. The other bits describe the drive.
+const smartctlFatal = 0x3
+
+// SmartReport is one SMART reading of an adopted drive.
+type SmartReport struct {
+	ID                 string      `json:"id"`
+	DriveID            string      `json:"driveID"`
+	CheckedAt          string      `json:"checkedAt"`
+	Protocol           string      `json:"protocol"`
+	Passed             bool        `json:"passed"`
+	Health             DriveHealth `json:"health"`
+	ReallocatedSectors uint64      `json:"reallocatedSectors"`
+	PendingSectors     uint64      `json:"pendingSectors"`
+	MediaErrors        uint64      `json:"mediaErrors"`
+	Temperature        int         `json:"temperature"`
+	PowerOnHours       uint64      `json:"powerOnHours"`
+	PercentageUsed     int         `json:"percentageUsed,omitempty"`
+	CriticalWarning    int         `json:"criticalWarning,omitempty"`
+}
+
+// smartctlOutput is the subset of `smartctl -j -a` output goNAS reads.
+type smartctlOutput struct {
+	Device struct {
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeLog *struct {
+		CriticalWarning int    `json:"critical_warning"`
+		PercentageUsed  int    `json:"percentage_used"`
+		MediaErrors     uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// ParseSmartctl parses the JSON output of `smartctl -j -a` for ATA and NVMe drives.
+func ParseSmartctl(data []byte) (SmartReport, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return SmartReport{}, fmt.Errorf("%w: %v", ErrSmartParse, err)
+	}
+	if out.SmartStatus == nil {
+		return SmartReport{}, ErrSmartUnavailable
+	}
+
+	report := SmartReport{
+		Protocol:     out.Device.Protocol,
+		Passed:       out.SmartStatus.Passed,
+		Temperature:  out.Temperature.Current,
+		PowerOnHours: out.PowerOnTime.Hours,
+	}
+	for _, attr := range out.AtaAttributes.Table {
+		switch attr.ID {
+		case ataReallocatedSectors:
+			report.ReallocatedSectors = attr.Raw.Value
+		case ataPendingSectors, ataOfflineUncorrectable:
+			report.PendingSectors = max(report.PendingSectors, attr.Raw.Value)
+		}
+	}
+	if out.NvmeLog != nil {
+		report.MediaErrors = out.NvmeLog.MediaErrors
+		report.PercentageUsed = out.NvmeLog.PercentageUsed
+		report.CriticalWarning = out.NvmeLog.CriticalWarning
+	}
+	report.Health = report.assess()
+	return report, nil
+}
+
+// assess derives the drive health from the reading. A failed overall assessment
+// or an NVMe critical warning means the drive is failing; bad sectors, media
+// errors or exhausted wear mean it should be watched.
+func (r SmartReport) assess() DriveHealth {
+	switch {
+	case !r.Passed || r.CriticalWarning != 0:
+		return DriveHealthFailing
+	case r.ReallocatedSectors > 0 || r.PendingSectors > 0 || r.MediaErrors > 0 || r.PercentageUsed >= 100:
+		return DriveHealthWarning
+	default:
+		return DriveHealthOK
+	}
+}
+
+// ReadSmart runs smartctl against the drive and parses its report.
+func ReadSmart(d *DriveInfo) (SmartReport, error) {
+	out, err := exec.Command("smartctl", "-j", "-a", d.Path).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// smartctl also exits non-zero to flag a failing drive, which still has a report
+		if !errors.As(err, &exitErr) || exitErr.ExitCode()&smartctlFatal != 0 {
+			return SmartReport{}, fmt.Errorf("%w: %s: %v", ErrSmartRead, d.Path, err)
+		}
+	}
+	report, err := ParseSmartctl(out)
+	if err != nil {
+		return SmartReport{}, err
+	}
+	report.ID = uuid.New().String()
+	report.DriveID = d.Uuid
+	report.CheckedAt = CreationTime()
+	return report, nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readSmartFixture returns captured smartctl JSON output from testdata.
+func readSmartFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "smart", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return data
+}
+
+func TestParseSmartctlFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    SmartReport
+	}{
+		{fixture: "ata.json", want: SmartReport{Protocol: "ATA", Passed: true, Health: DriveHealthOK, Temperature: 34, PowerOnHours: 15342}},
+		{fixture: "ata_pending.json", want: SmartReport{Protocol: "ATA", Passed: true, Health: DriveHealthWarning, ReallocatedSectors: 8, PendingSectors: 3, Temperature: 36, PowerOnHours: 51207}},
+		{fixture: "ata_failed.json", want: SmartReport{Protocol: "ATA", Health: DriveHealthFailing, ReallocatedSectors: 65528, PendingSectors: 120, Temperature: 41, PowerOnHours: 29811}},
+		{fixture: "nvme.json", want: SmartReport{Protocol: "NVMe", Passed: true, Health: DriveHealthOK, Temperature: 43, PowerOnHours: 6120, PercentageUsed: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			report, err := ParseSmartctl(readSmartFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, report)
+			}
+		})
+	}
+}
+
+func TestParseSmartctlWithoutHealth(t *testing.T) {
+	if _, err := ParseSmartctl(readSmartFixture(t, "usb_no_smart.json")); !errors.Is(err, ErrSmartUnavailable) {
+		t.Fatalf("expected ErrSmartUnavailable, got %v", err)
+	}
+	if _, err := ParseSmartctl([]byte("smartctl: command not found")); !errors.Is(err, ErrSmartParse) {
+		t.Fatalf("expected ErrSmartParse, got %v", err)
+	}
+}
+
+func TestSmartAssessNvme(t *testing.T) {
+	worn := SmartReport{Passed: true, PercentageUsed: 100}
+	if worn.assess() != DriveHealthWarning {
+		t.Fatalf("expected a worn out drive to warn")
+	}
+	spare := SmartReport{Passed: true, CriticalWarning: 1}
+	if spare.assess() != DriveHealthFailing {
+		t.Fatalf("expected a critical warning to fail the drive")
+	}
+	errs := SmartReport{Passed: true, MediaErrors: 4}
+	if errs.assess() != DriveHealthWarning {
+		t.Fatalf("expected media errors to warn")
+	}
+}
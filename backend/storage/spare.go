@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+)
+
+// AddSpares records drives as dedicated hot spares of the pool.
+func (p *Pool) AddSpares(drive ...*DriveInfo) {
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	if p.Spares == nil {
+		p.Spares = make(map[string]*AdoptedDrive)
+	}
+	for i := range drive {
+		spare := NewAdoptedDrive(drive[i])
+		spare.SetPoolID(p.Uuid)
+		spare.SetRole(RoleSpare)
+		p.Spares[spare.GetUuid()] = spare
+	}
+}
+
+// AttachSpares adds drives to the pool array as hot spares.
+// On a degraded array md starts rebuilding onto the first spare right away.
+func (p *Pool) AttachSpares(drives ...*DriveInfo) error {
+	if !p.IsRedundant() {
+		return ErrNoRedundancy
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return ErrPoolNotBuilt
+	}
+	if len(drives) == 0 {
+		return ErrInsufficientDrives
+	}
+	for _, d := range drives {
+		if !p.SpareFits(d) {
+			return ErrSpareTooSmall
+		}
+	}
+	parts, err := p.partitionDrives(drives)
+	if err != nil {
+		return errors.Join(ErrPoolSpare, err)
+	}
+	args := []string{"--manage", p.MdDevice, "--add"}
+	for _, d := range drives {
+		args = append(args, p.memberDevice(d.Path))
+	}
+	if err = helper.BuildMdadm(args); err != nil {
+		return errors.Join(ErrPoolSpare, err)
+	}
+	p.AddSpares(drives...)
+	p.recordPartitions(parts)
+	return nil
+}
+
+// DetachSpare removes an idle dedicated spare from the pool array and returns it.
+func (p *Pool) DetachSpare(uuid string) (*AdoptedDrive, error) {
+	p.lock().RLock()
+	spare, ok := p.Spares[uuid]
+	p.lock().RUnlock()
+	if !ok {
+		return nil, ErrDriveNotSpare
+	}
+	if p.IsAssembled() && !spare.Missing {
+		if err := helper.BuildMdadm([]string{"--manage", p.MdDevice, "--remove", p.memberDevice(spare.Drive.Path)}); err != nil {
+			return nil, errors.Join(ErrPoolSpare, err)
+		}
+	}
+	p.lock().Lock()
+	delete(p.Spares, uuid)
+	p.lock().Unlock()
+	return spare, nil
+}
+
+// HasIdleSpare reports whether a dedicated spare is waiting to take over a failed member.
+func (p *Pool) HasIdleSpare() bool {
+	for _, s := range p.DedicatedSpares() {
+		if s.GetState() == MemberSpare {
+			return true
+		}
+	}
+	return false
+}
+
+// PromoteSpares moves dedicated spares that md has pulled into the array over
+// to the active members and returns them.
+func (p *Pool) PromoteSpares() []*AdoptedDrive {
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	var promoted []*AdoptedDrive
+	for id, s := range p.Spares {
+		if s.GetState() != MemberRebuilding && s.GetState() != MemberInSync {
+			continue
+		}
+		delete(p.Spares, id)
+		s.SetRole(RoleActive)
+		p.AdoptedDrives[id] = s
+		promoted = append(promoted, s)
+	}
+	return promoted
+}
+
+// SpareFits reports whether the space md can use on drive is at least that of
+// the smallest active member. Missing members have no known size and are skipped.
+func (p *Pool) SpareFits(drive *DriveInfo) bool {
+	var smallest uint64
+	found := false
+	for _, d := range p.Members() {
+		if d.Missing {
+			continue
+		}
+		if size := p.memberSize(d.Drive); !found || size < smallest {
+			smallest = size
+			found = true
+		}
+	}
+	return !found || p.memberSize(drive) >= smallest
+}
+
+// NeedsSpare reports whether a degraded redundant pool has no spare of its own
+// to recover onto.
+func (p *Pool) NeedsSpare() bool {
+	if p.Status != Degraded || !p.IsRedundant() || p.HasIdleSpare() {
+		return false
+	}
+	for _, d := range p.Members() {
+		if d.GetState() == MemberRebuilding {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"goNAS/helper"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SnapshotDir is the directory below a pool mount point that holds snapshots.
+var SnapshotDir = ".snapshots"
+
+var TagManual = "manual"
+var TagHourly = "hourly"
+var TagDaily = "daily"
+var TagWeekly = "weekly"
+
+type SnapshotPolicy struct {
+	Hourly int `json:"hourly"`
+	Daily  int `json:"daily"`
+	Weekly int `json:"weekly"`
+}
+
+type Subvolume struct {
+	Uuid      string         `json:"uuid"`
+	PoolID    string         `json:"poolID"`
+	Name      string         `json:"name"`
+	Path      string         `json:"path"`
+	Policy    SnapshotPolicy `json:"policy"`
+	CreatedAt string         `json:"createdAt"`
+}
+
+type Snapshot struct {
+	Uuid        string `json:"uuid"`
+	PoolID      string `json:"poolID"`
+	SubvolumeID string `json:"subvolumeID"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Tag         string `json:"tag"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// policyTag pairs a retention tag with how often it is taken and how many are kept.
+type policyTag struct {
+	tag    string
+	period time.Duration
+	keep   int
+}
+
+// tags returns the scheduled tags of the policy, including those with nothing to keep.
+func (pol SnapshotPolicy) tags() []policyTag {
+	return []policyTag{
+		{tag: TagHourly, period: time.Hour, keep: pol.Hourly},
+		{tag: TagDaily, period: 24 * time.Hour, keep: pol.Daily},
+		{tag: TagWeekly, period: 7 * 24 * time.Hour, keep: pol.Weekly},
+	}
+}
+
+// Validate returns an error if a retention count is negative.
+func (pol SnapshotPolicy) Validate() error {
+	if pol.Hourly < 0 || pol.Daily < 0 || pol.Weekly < 0 {
+		return ErrInvalidSnapshotPolicy
+	}
+	return nil
+}
+
+// byTag groups snapshots by tag, newest first.
+func byTag(snapshots []Snapshot) map[string][]Snapshot {
+	grouped := make(map[string][]Snapshot)
+	for _, s := range snapshots {
+		grouped[s.Tag] = append(grouped[s.Tag], s)
+	}
+	for tag := range grouped {
+		sort.Slice(grouped[tag], func(i, j int) bool {
+			return snapshotTime(grouped[tag][i]).After(snapshotTime(grouped[tag][j]))
+		})
+	}
+	return grouped
+}
+
+// snapshotTime parses the creation time of a snapshot; unparsable times sort as oldest.
+func snapshotTime(s Snapshot) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s.CreatedAt)
+	return t
+}
+
+// Due returns the tags whose newest snapshot is at least one period old at now.
+func (pol SnapshotPolicy) Due(snapshots []Snapshot, now time.Time) []string {
+	grouped := byTag(snapshots)
+	var due []string
+	for _, t := range pol.tags() {
+		if t.keep == 0 {
+			continue
+		}
+		existing := grouped[t.tag]
+		if len(existing) == 0 {
+			due = append(due, t.tag)
+			continue
+		}
+		if now.Sub(snapshotTime(existing[0])) >= t.period {
+			due = append(due, t.tag)
+		}
+	}
+	return due
+}
+
+// Expired returns the scheduled snapshots beyond the keep count of their tag.
+// Manual snapshots never expire.
+func (pol SnapshotPolicy) Expired(snapshots []Snapshot) []Snapshot {
+	grouped := byTag(snapshots)
+	var expired []Snapshot
+	for _, t := range pol.tags() {
+		if existing := grouped[t.tag]; len(existing) > t.keep {
+			expired = append(expired, existing[t.keep:]...)
+		}
+	}
+	return expired
+}
+
+// SupportsSubvolumes reports whether the pool is a mounted-at-root btrfs filesystem.
+func (p *Pool) SupportsSubvolumes() bool {
+	return p.Format == "btrfs" && !p.IsLvm()
+}
+
+// checkSubvolumes ensures the pool filesystem is btrfs and mounted.
+func (p *Pool) checkSubvolumes() error {
+	if !p.SupportsSubvolumes() {
+		return ErrSubvolumeUnsupported
+	}
+	if !p.IsBuilt() || !helper.IsMounted(p.MountPoint) {
+		return ErrPoolNotBuilt
+	}
+	return nil
+}
+
+// AddSubvolumes records existing subvolumes on the pool.
+func (p *Pool) AddSubvolumes(subvolumes ...*Subvolume) {
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	if p.Subvolumes == nil {
+		p.Subvolumes = make(map[string]*Subvolume)
+	}
+	for _, s := range subvolumes {
+		p.Subvolumes[s.Uuid] = s
+	}
+}
+
+// GetSubvolume returns the pool subvolume with the given UUID.
+func (p *Pool) GetSubvolume(uuid string) (*Subvolume, error) {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := p.Subvolumes[uuid]
+	if !ok {
+		return nil, ErrSubvolumeNotFound
+	}
+	return s, nil
+}
+
+// CopySubvolumes returns a copy of the pool subvolumes that is safe to range
+// over while subvolumes are created, deleted or have their policy changed.
+func (p *Pool) CopySubvolumes() map[string]Subvolume {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	subvolumes := make(map[string]Subvolume, len(p.Subvolumes))
+	for id, s := range p.Subvolumes {
+		subvolumes[id] = *s
+	}
+	return subvolumes
+}
+
+// SetSubvolumePolicy replaces the retention policy of a subvolume.
+func (p *Pool) SetSubvolumePolicy(uuid string, policy SnapshotPolicy) (*Subvolume, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	s, ok := p.Subvolumes[uuid]
+	if !ok {
+		return nil, ErrSubvolumeNotFound
+	}
+	s.Policy = policy
+	return s, nil
+}
+
+// CreateSubvolume creates a btrfs subvolume directly below the pool mount point.
+func (p *Pool) CreateSubvolume(name string, policy SnapshotPolicy) (*Subvolume, error) {
+	if err := p.checkSubvolumes(); err != nil {
+		return nil, err
+	}
+	name, err := helper.SanitizeRaidName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = policy.Validate(); err != nil {
+		return nil, err
+	}
+	for _, s := range p.CopySubvolumes() {
+		if s.Name == name {
+			return nil, ErrSubvolumeExists
+		}
+	}
+
+	subvolume := &Subvolume{
+		Uuid:      uuid.New().String(),
+		PoolID:    p.Uuid,
+		Name:      name,
+		Path:      filepath.Join(p.MountPoint, name),
+		Policy:    policy,
+		CreatedAt: CreationTime(),
+	}
+	if err = helper.CreateSubvolume(subvolume.Path); err != nil {
+		return nil, err
+	}
+	p.AddSubvolumes(subvolume)
+	return subvolume, nil
+}
+
+// DeleteSubvolume deletes a subvolume. Its snapshots must be deleted first.
+func (p *Pool) DeleteSubvolume(uuid string) (*Subvolume, error) {
+	if err := p.checkSubvolumes(); err != nil {
+		return nil, err
+	}
+	subvolume, err := p.GetSubvolume(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if err = helper.DeleteSubvolume(subvolume.Path); err != nil {
+		return nil, err
+	}
+	p.lock().Lock()
+	delete(p.Subvolumes, uuid)
+	p.lock().Unlock()
+	return subvolume, nil
+}
+
+// CreateSnapshot takes a read-only snapshot of a subvolume below the pool snapshot directory.
+func (p *Pool) CreateSnapshot(subvolume *Subvolume, tag string, now time.Time) (*Snapshot, error) {
+	if err := p.checkSubvolumes(); err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s-%s", now.UTC().Format("20060102T150405Z"), tag)
+	dir := filepath.Join(p.MountPoint, SnapshotDir, subvolume.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Join(ErrSnapshotCreate, err)
+	}
+
+	snapshot := &Snapshot{
+		Uuid:        uuid.New().String(),
+		PoolID:      p.Uuid,
+		SubvolumeID: subvolume.Uuid,
+		Name:        name,
+		Path:        filepath.Join(dir, name),
+		Tag:         tag,
+		CreatedAt:   now.UTC().Format(time.RFC3339Nano),
+	}
+	if err := helper.SnapshotSubvolume(subvolume.Path, snapshot.Path); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// DeleteSnapshot deletes a snapshot from the pool.
+func (p *Pool) DeleteSnapshot(snapshot *Snapshot) error {
+	if err := p.checkSubvolumes(); err != nil {
+		return err
+	}
+	return helper.DeleteSubvolume(snapshot.Path)
+}
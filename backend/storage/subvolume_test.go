@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func snapshotAt(tag string, at time.Time) Snapshot {
+	return Snapshot{Uuid: tag + at.Format(time.RFC3339), Tag: tag, CreatedAt: at.Format(time.RFC3339Nano)}
+}
+
+func TestSnapshotPolicyDue(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	policy := SnapshotPolicy{Hourly: 24, Daily: 7}
+
+	due := policy.Due(nil, now)
+	if len(due) != 2 || due[0] != TagHourly || due[1] != TagDaily {
+		t.Fatalf("expected hourly and daily to be due with no snapshots, got %v", due)
+	}
+
+	snapshots := []Snapshot{
+		snapshotAt(TagHourly, now.Add(-30*time.Minute)),
+		snapshotAt(TagHourly, now.Add(-90*time.Minute)),
+		snapshotAt(TagDaily, now.Add(-25*time.Hour)),
+		snapshotAt(TagManual, now.Add(-time.Minute)),
+	}
+	due = policy.Due(snapshots, now)
+	if len(due) != 1 || due[0] != TagDaily {
+		t.Fatalf("expected only daily to be due, got %v", due)
+	}
+}
+
+func TestSnapshotPolicyExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	policy := SnapshotPolicy{Hourly: 2}
+
+	newest := snapshotAt(TagHourly, now)
+	snapshots := []Snapshot{
+		snapshotAt(TagHourly, now.Add(-2*time.Hour)),
+		newest,
+		snapshotAt(TagHourly, now.Add(-time.Hour)),
+		snapshotAt(TagDaily, now.Add(-time.Hour)),
+		snapshotAt(TagManual, now.Add(-30*24*time.Hour)),
+	}
+	expired := policy.Expired(snapshots)
+	if len(expired) != 2 {
+		t.Fatalf("expected the oldest hourly and the disabled daily to expire, got %+v", expired)
+	}
+	for _, s := range expired {
+		if s.Tag == TagManual || s.Uuid == newest.Uuid {
+			t.Errorf("unexpected expired snapshot %+v", s)
+		}
+	}
+
+	if err := (SnapshotPolicy{Daily: -1}).Validate(); !errors.Is(err, ErrInvalidSnapshotPolicy) {
+		t.Fatalf("expected ErrInvalidSnapshotPolicy, got %v", err)
+	}
+}
+
+func TestSubvolumesRequireBtrfs(t *testing.T) {
+	ext4, _ := NewPool("ext4", &Raid{Level: 1}, "ext4")
+	if _, err := ext4.CreateSubvolume("data", SnapshotPolicy{}); !errors.Is(err, ErrSubvolumeUnsupported) {
+		t.Fatalf("expected ErrSubvolumeUnsupported, got %v", err)
+	}
+
+	native, _ := NewPool("native", &Btrfs{Profile: "raid1"}, "btrfs")
+	if !native.SupportsSubvolumes() {
+		t.Fatal("expected native btrfs pool to support subvolumes")
+	}
+	if _, err := native.CreateSubvolume("data", SnapshotPolicy{}); !errors.Is(err, ErrPoolNotBuilt) {
+		t.Fatalf("expected ErrPoolNotBuilt for an unmounted pool, got %v", err)
+	}
+	if _, err := native.GetSubvolume("missing"); !errors.Is(err, ErrSubvolumeNotFound) {
+		t.Fatalf("expected ErrSubvolumeNotFound, got %v", err)
+	}
+}
+
+func TestCopySubvolumesDuringChanges(t *testing.T) {
+	pool, _ := NewPool("native", &Btrfs{Profile: "raid1"}, "btrfs")
+	pool.AddSubvolumes(&Subvolume{Uuid: "data", Name: "data"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			pool.AddSubvolumes(&Subvolume{Uuid: fmt.Sprint(i), Name: fmt.Sprint(i)})
+			if _, err := pool.SetSubvolumePolicy("data", SnapshotPolicy{Daily: i}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for _, subvolume := range pool.CopySubvolumes() {
+			_ = subvolume.Policy.Due(nil, time.Now())
+		}
+	}
+	<-done
+
+	if subvolumes := pool.CopySubvolumes(); len(subvolumes) != 101 || subvolumes["data"].Policy.Daily != 99 {
+		t.Fatalf("expected every subvolume and the last policy, got %d subvolumes and %+v", len(subvolumes), subvolumes["data"].Policy)
+	}
+	if _, err := pool.SetSubvolumePolicy("data", SnapshotPolicy{Daily: -1}); err == nil {
+		t.Fatal("expected an invalid policy to be rejected")
+	}
+	if _, err := pool.SetSubvolumePolicy("missing", SnapshotPolicy{}); !errors.Is(err, ErrSubvolumeNotFound) {
+		t.Fatalf("expected ErrSubvolumeNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type UeventAction string
+
+var UeventAdd UeventAction = "add"
+var UeventRemove UeventAction = "remove"
+var UeventChange UeventAction = "change"
+
+// Uevent is a kernel device event as broadcast on the uevent netlink socket.
+type Uevent struct {
+	Action    UeventAction      `json:"action"`
+	DevPath   string            `json:"devPath"`
+	Subsystem string            `json:"subsystem"`
+	DevName   string            `json:"devName"`
+	DevType   string            `json:"devType"`
+	Seqnum    uint64            `json:"seqnum"`
+	Env       map[string]string `json:"env"`
+}
+
+// UeventSource delivers device events. Receive blocks until an event arrives
+// or ctx is done.
+type UeventSource interface {
+	Receive(ctx context.Context) (Uevent, error)
+	Close() error
+}
+
+// IsDisk reports whether the event is for a whole block device rather than a partition.
+func (e Uevent) IsDisk() bool {
+	return e.Subsystem == "block" && e.DevType == "disk"
+}
+
+// ParseUevent parses a kernel uevent message: an action@devpath header followed
+// by NUL separated KEY=VALUE pairs. Messages rebroadcast by udev are rejected.
+func ParseUevent(msg []byte) (Uevent, error) {
+	fields := bytes.Split(bytes.TrimRight(msg, "\x00"), []byte{0})
+	header := string(fields[0])
+	if !strings.Contains(header, "@") {
+		return Uevent{}, ErrUeventParse
+	}
+
+	event := Uevent{Env: make(map[string]string, len(fields)-1)}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		event.Env[key] = value
+	}
+	event.Action = UeventAction(event.Env["ACTION"])
+	event.DevPath = event.Env["DEVPATH"]
+	event.Subsystem = event.Env["SUBSYSTEM"]
+	event.DevType = event.Env["DEVTYPE"]
+	if name := event.Env["DEVNAME"]; name != "" {
+		event.DevName = filepath.Base(name)
+	}
+	if seqnum, err := strconv.ParseUint(event.Env["SEQNUM"], 10, 64); err == nil {
+		event.Seqnum = seqnum
+	}
+	if event.Action == "" || event.DevPath == "" {
+		return Uevent{}, ErrUeventParse
+	}
+	return event, nil
+}
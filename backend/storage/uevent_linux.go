@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// ueventPoll bounds how long Receive blocks before checking its context.
+const ueventPoll = time.Second
+
+// ueventBufferSize fits the largest uevent the kernel sends.
+const ueventBufferSize = 8192
+
+// NetlinkUeventSource reads kernel uevents from a NETLINK_KOBJECT_UEVENT socket.
+type NetlinkUeventSource struct {
+	fd int
+}
+
+// NewNetlinkUeventSource opens a netlink socket subscribed to kernel uevents.
+func NewNetlinkUeventSource() (UeventSource, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUeventSocket, err)
+	}
+	// Multicast group 1 carries the kernel's own events; udev rebroadcasts on group 2
+	if err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("%w: %v", ErrUeventSocket, err)
+	}
+	timeout := syscall.NsecToTimeval(ueventPoll.Nanoseconds())
+	if err = syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("%w: %v", ErrUeventSocket, err)
+	}
+	return &NetlinkUeventSource{fd: fd}, nil
+}
+
+// Receive returns the next uevent sent by the kernel.
+func (s *NetlinkUeventSource) Receive(ctx context.Context) (Uevent, error) {
+	buf := make([]byte, ueventBufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return Uevent{}, err
+		}
+		n, from, err := syscall.Recvfrom(s.fd, buf, 0)
+		if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+			continue
+		}
+		if err != nil {
+			return Uevent{}, fmt.Errorf("%w: %v", ErrUeventRead, err)
+		}
+		// Only the kernel (port 0) is trusted to send uevents
+		if sender, ok := from.(*syscall.SockaddrNetlink); !ok || sender.Pid != 0 {
+			continue
+		}
+		return ParseUevent(buf[:n])
+	}
+}
+
+// Close closes the netlink socket.
+func (s *NetlinkUeventSource) Close() error {
+	return syscall.Close(s.fd)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package storage
+
+// NewNetlinkUeventSource reports that uevents are only available on Linux.
+func NewNetlinkUeventSource() (UeventSource, error) {
+	return nil, ErrUeventUnsupported
+}
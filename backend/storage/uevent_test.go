@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseUevent(t *testing.T) {
+	msg := strings.Join([]string{
+		"add@/devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0/block/sdc",
+		"ACTION=add",
+		"DEVPATH=/devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0/block/sdc",
+		"SUBSYSTEM=block",
+		"MAJOR=8",
+		"MINOR=32",
+		"DEVNAME=sdc",
+		"DEVTYPE=disk",
+		"SEQNUM=4711",
+	}, "\x00") + "\x00"
+	event, err := ParseUevent([]byte(msg))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Action != UeventAdd || event.DevName != "sdc" || event.Seqnum != 4711 || !event.IsDisk() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.Env["MAJOR"] != "8" {
+		t.Fatalf("expected the environment to be kept, got %v", event.Env)
+	}
+
+	partition, err := ParseUevent([]byte("remove@/block/sdc/sdc1\x00ACTION=remove\x00DEVPATH=/block/sdc/sdc1\x00SUBSYSTEM=block\x00DEVNAME=/dev/sdc1\x00DEVTYPE=partition\x00"))
+	if err != nil || partition.DevName != "sdc1" || partition.IsDisk() {
+		t.Fatalf("unexpected partition event %+v: %v", partition, err)
+	}
+
+	if _, err = ParseUevent([]byte("libudev\x00\xfe\xed\xca\xfe")); !errors.Is(err, ErrUeventParse) {
+		t.Fatalf("expected ErrUeventParse for a udev message, got %v", err)
+	}
+	if _, err = ParseUevent([]byte("add@/devices/virtual\x00SUBSYSTEM=block\x00")); !errors.Is(err, ErrUeventParse) {
+		t.Fatalf("expected ErrUeventParse without an action, got %v", err)
+	}
+}
+
+func TestGetSystemDrive(t *testing.T) {
+	root := t.TempDir()
+	previous := SysBlockPath
+	SysBlockPath = root
+	defer func() { SysBlockPath = previous }()
+
+	write := func(name, file, value string) {
+		path := filepath.Join(root, name, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("sdx", "size", "4194304")
+	write("sdx", "device/serial", "HOTPLUG1")
+	write("loop0", "size", "0")
+
+	drive, ok := GetSystemDrive("sdx")
+	if !ok || drive.SizeBytes != 2*1024*1024*1024 || drive.DriveKey.String() != "serial:HOTPLUG1" {
+		t.Fatalf("unexpected drive %+v", drive)
+	}
+	if _, ok = GetSystemDrive("loop0"); ok {
+		t.Fatalf("expected an empty loop device to be ignored")
+	}
+	if _, err := ReadDrive("sdy"); !errors.Is(err, ErrDriveNotFound) {
+		t.Fatalf("expected ErrDriveNotFound, got %v", err)
+	}
+}
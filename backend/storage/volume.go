@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"goNAS/helper"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+var PhaseVolumeGroup Phase = "volume-group"
+
+type Volume struct {
+	Uuid       string `json:"uuid"`
+	PoolID     string `json:"poolID"`
+	Name       string `json:"name"`
+	SizeBytes  uint64 `json:"sizeBytes"`
+	Format     string `json:"format"`
+	MountPoint string `json:"mountPoint"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+// IsLvm reports whether the pool md device is an LVM volume group instead of a filesystem.
+func (p *Pool) IsLvm() bool {
+	return p.VolumeGroup != ""
+}
+
+// EnableLvm makes an unbuilt md pool an LVM volume group; its filesystems then live on volumes.
+func (p *Pool) EnableLvm() error {
+	if p.MdDevice == "" {
+		return ErrLvmUnsupported
+	}
+	if p.IsBuilt() {
+		return ErrPoolAlreadyBuilt
+	}
+	shortID, err := ShortUuid(SHORTUUIDLEN, p.Uuid)
+	if err != nil {
+		return err
+	}
+	p.VolumeGroup = "gonas-" + shortID
+	return nil
+}
+
+// VolumeDevice returns the device path of a logical volume in the pool.
+func (p *Pool) VolumeDevice(v *Volume) string {
+	return filepath.Join(DevFolder, p.VolumeGroup, v.Name)
+}
+
+// AddVolumes records existing volumes on the pool.
+func (p *Pool) AddVolumes(volumes ...*Volume) {
+	mu := p.lock()
+	mu.Lock()
+	defer mu.Unlock()
+	if p.Volumes == nil {
+		p.Volumes = make(map[string]*Volume)
+	}
+	for _, v := range volumes {
+		p.Volumes[v.Uuid] = v
+	}
+}
+
+// GetVolume returns the pool volume with the given UUID.
+func (p *Pool) GetVolume(uuid string) (*Volume, error) {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	v, ok := p.Volumes[uuid]
+	if !ok {
+		return nil, ErrVolumeNotFound
+	}
+	return v, nil
+}
+
+// ListVolumes returns a snapshot of the pool volumes.
+func (p *Pool) ListVolumes() []*Volume {
+	mu := p.lock()
+	mu.RLock()
+	defer mu.RUnlock()
+	volumes := make([]*Volume, 0, len(p.Volumes))
+	for _, v := range p.Volumes {
+		volumes = append(volumes, v)
+	}
+	return volumes
+}
+
+// CheckNoVolumes returns ErrPoolHasVolumes if the pool is a volume group
+// that still holds volumes, which deleting the pool would orphan.
+func (p *Pool) CheckNoVolumes() error {
+	if p.IsLvm() && len(p.ListVolumes()) > 0 {
+		return ErrPoolHasVolumes
+	}
+	return nil
+}
+
+// checkVolumeGroup ensures the pool is a built, assembled volume group.
+func (p *Pool) checkVolumeGroup() error {
+	if !p.IsLvm() {
+		return ErrLvmUnsupported
+	}
+	if !p.IsBuilt() || !p.IsAssembled() {
+		return ErrPoolNotBuilt
+	}
+	return nil
+}
+
+// CreateVolume creates a logical volume, formats it and mounts it below the pool mount point.
+// An empty format uses the pool format.
+func (p *Pool) CreateVolume(name string, sizeBytes uint64, format string) (*Volume, error) {
+	if err := p.checkVolumeGroup(); err != nil {
+		return nil, err
+	}
+	name, err := helper.SanitizeRaidName(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range p.ListVolumes() {
+		if v.Name == name {
+			return nil, ErrVolumeExists
+		}
+	}
+	if sizeBytes == 0 {
+		return nil, ErrVolumeSize
+	}
+	if format == "" {
+		format = p.Format
+	}
+	if err = ValidatePoolFormat(format); err != nil {
+		return nil, err
+	}
+
+	volume := &Volume{
+		Uuid:       uuid.New().String(),
+		PoolID:     p.Uuid,
+		Name:       name,
+		SizeBytes:  sizeBytes,
+		Format:     format,
+		MountPoint: filepath.Join(p.MountPoint, name),
+		CreatedAt:  CreationTime(),
+	}
+	if err = helper.CreateLogicalVolume(p.VolumeGroup, name, sizeBytes); err != nil {
+		return nil, errors.Join(ErrPoolVolume, err)
+	}
+	device := p.VolumeDevice(volume)
+	if err = helper.FormatPool(format, device); err != nil {
+		return nil, p.discardVolume(volume, err)
+	}
+	if err = helper.Mount(device, volume.MountPoint); err != nil {
+		return nil, p.discardVolume(volume, err)
+	}
+
+	p.AddVolumes(volume)
+	p.CalculateAvailableCapacity()
+	return volume, nil
+}
+
+// discardVolume removes the logical volume and mount point of a volume whose
+// creation failed after lvcreate, so the name and space can be used again.
+func (p *Pool) discardVolume(volume *Volume, err error) error {
+	if rmErr := os.Remove(volume.MountPoint); rmErr != nil && !os.IsNotExist(rmErr) {
+		err = errors.Join(err, rmErr)
+	}
+	if lvErr := helper.RemoveLogicalVolume(p.VolumeGroup, volume.Name); lvErr != nil {
+		err = errors.Join(err, lvErr)
+	}
+	return errors.Join(ErrPoolVolume, err)
+}
+
+// ResizeVolume grows a logical volume and its filesystem to sizeBytes.
+// Volumes cannot shrink since not every supported filesystem can.
+func (p *Pool) ResizeVolume(uuid string, sizeBytes uint64) (*Volume, error) {
+	if err := p.checkVolumeGroup(); err != nil {
+		return nil, err
+	}
+	volume, err := p.GetVolume(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if sizeBytes < volume.SizeBytes {
+		return nil, ErrVolumeShrink
+	}
+	if sizeBytes == volume.SizeBytes {
+		return volume, nil
+	}
+
+	if err = helper.ExtendLogicalVolume(p.VolumeGroup, volume.Name, sizeBytes); err != nil {
+		return nil, errors.Join(ErrPoolVolume, err)
+	}
+	if err = helper.ResizeFilesystem(volume.Format, p.VolumeDevice(volume), volume.MountPoint); err != nil {
+		return nil, err
+	}
+	volume.SizeBytes = sizeBytes
+	p.CalculateAvailableCapacity()
+	return volume, nil
+}
+
+// DeleteVolume unmounts and removes a logical volume.
+// It refuses when a process still holds files below the volume mount point.
+func (p *Pool) DeleteVolume(uuid string) (*Volume, error) {
+	if err := p.checkVolumeGroup(); err != nil {
+		return nil, err
+	}
+	volume, err := p.GetVolume(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if err = unmountVolume(volume); err != nil {
+		return nil, err
+	}
+	if err = helper.RemoveLogicalVolume(p.VolumeGroup, volume.Name); err != nil {
+		return nil, errors.Join(ErrPoolVolume, err)
+	}
+	if err = os.Remove(volume.MountPoint); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Join(ErrPoolDeleteRmdir, err)
+	}
+
+	p.lock().Lock()
+	delete(p.Volumes, uuid)
+	p.lock().Unlock()
+	p.CalculateAvailableCapacity()
+	return volume, nil
+}
+
+// unmountVolume unmounts a volume unless a process holds files below it.
+func unmountVolume(v *Volume) error {
+	if !helper.IsMounted(v.MountPoint) {
+		return nil
+	}
+	holders, err := helper.MountHolders(v.MountPoint)
+	if err != nil {
+		return err
+	}
+	if len(holders) > 0 {
+		return fmt.Errorf("%w: volume %s held open by pids %v", ErrPoolInUse, v.Name, holders)
+	}
+	return helper.Unmount(v.MountPoint)
+}
+
+// activateVolumes activates the volume group and mounts every volume.
+func (p *Pool) activateVolumes() error {
+	if err := helper.ActivateVolumeGroup(p.VolumeGroup, true); err != nil {
+		return errors.Join(ErrPoolAssemble, err)
+	}
+	for _, v := range p.ListVolumes() {
+		if helper.IsMounted(v.MountPoint) {
+			continue
+		}
+		if err := helper.Mount(p.VolumeDevice(v), v.MountPoint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deactivateVolumes unmounts every volume and deactivates the volume group.
+func (p *Pool) deactivateVolumes() error {
+	for _, v := range p.ListVolumes() {
+		if err := unmountVolume(v); err != nil {
+			return err
+		}
+	}
+	if !p.IsAssembled() {
+		return nil
+	}
+	if err := helper.ActivateVolumeGroup(p.VolumeGroup, false); err != nil {
+		return errors.Join(ErrPoolDeleteStop, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnableLvm(t *testing.T) {
+	pool, err := NewPool("volumes", &Raid{Level: 1}, "ext4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = pool.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !pool.IsLvm() || pool.VolumeGroup == "" {
+		t.Fatalf("expected pool to be an lvm volume group, got %q", pool.VolumeGroup)
+	}
+
+	native, _ := NewPool("native", &Btrfs{Profile: "raid1"}, "btrfs")
+	if err = native.EnableLvm(); !errors.Is(err, ErrLvmUnsupported) {
+		t.Fatalf("expected ErrLvmUnsupported, got %v", err)
+	}
+
+	built, _ := NewPool("built", &Raid{Level: 1}, "ext4")
+	built.MountPoint = "/mnt/pools/built"
+	if err = built.EnableLvm(); !errors.Is(err, ErrPoolAlreadyBuilt) {
+		t.Fatalf("expected ErrPoolAlreadyBuilt, got %v", err)
+	}
+}
+
+func TestVolumeValidation(t *testing.T) {
+	plain, _ := NewPool("plain", &Raid{Level: 1}, "ext4")
+	if _, err := plain.CreateVolume("data", 1024, ""); !errors.Is(err, ErrLvmUnsupported) {
+		t.Fatalf("expected ErrLvmUnsupported, got %v", err)
+	}
+
+	device := filepath.Join(t.TempDir(), "md127")
+	if err := os.WriteFile(device, nil, 0644); err != nil {
+		t.Fatalf("failed to create fake md device: %v", err)
+	}
+	pool, _ := NewPool("volumes", &Raid{Level: 1}, "ext4")
+	if err := pool.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool.MdDevice = device
+	pool.MountPoint = "/mnt/pools/" + pool.Uuid
+	pool.AddVolumes(&Volume{Uuid: "v1", Name: "data", SizeBytes: 2048, Format: "ext4"})
+
+	if _, err := pool.CreateVolume("data", 1024, ""); !errors.Is(err, ErrVolumeExists) {
+		t.Fatalf("expected ErrVolumeExists, got %v", err)
+	}
+	if _, err := pool.CreateVolume("media", 0, ""); !errors.Is(err, ErrVolumeSize) {
+		t.Fatalf("expected ErrVolumeSize, got %v", err)
+	}
+	if _, err := pool.CreateVolume("media", 1024, "ntfs"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+	if _, err := pool.ResizeVolume("v1", 1024); !errors.Is(err, ErrVolumeShrink) {
+		t.Fatalf("expected ErrVolumeShrink, got %v", err)
+	}
+	if _, err := pool.DeleteVolume("missing"); !errors.Is(err, ErrVolumeNotFound) {
+		t.Fatalf("expected ErrVolumeNotFound, got %v", err)
+	}
+	if got := pool.VolumeDevice(pool.Volumes["v1"]); got != "/dev/"+pool.VolumeGroup+"/data" {
+		t.Fatalf("unexpected volume device %q", got)
+	}
+}
+
+func TestDeleteRefusesPoolWithVolumes(t *testing.T) {
+	pool, _ := NewPool("volumes", &Raid{Level: 1}, "ext4")
+	if err := pool.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool.MountPoint = t.TempDir()
+	pool.Status = Offline
+	pool.AddVolumes(&Volume{Uuid: "v1", Name: "data", MountPoint: filepath.Join(pool.MountPoint, "data")})
+
+	if err := pool.Delete(); !errors.Is(err, ErrPoolHasVolumes) {
+		t.Fatalf("expected ErrPoolHasVolumes, got %v", err)
+	}
+	if _, err := os.Stat(pool.MountPoint); err != nil {
+		t.Fatalf("expected the mount directory to be left alone, got %v", err)
+	}
+}
+
+func TestDiscardVolumeAfterFailedFormat(t *testing.T) {
+	pool, _ := NewPool("volumes", &Raid{Level: 1}, "ext4")
+	if err := pool.EnableLvm(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pool.MountPoint = t.TempDir()
+	volume := &Volume{Uuid: "v1", Name: "data", MountPoint: filepath.Join(pool.MountPoint, "data")}
+	if err := os.Mkdir(volume.MountPoint, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// lvremove is not available here, so its failure is reported alongside the cause
+	cause := errors.New("mkfs failed")
+	err := pool.discardVolume(volume, cause)
+	if !errors.Is(err, ErrPoolVolume) || !errors.Is(err, cause) || !errors.Is(err, helper.ErrLvmCommand) {
+		t.Fatalf("expected ErrPoolVolume with the cause and the lvremove attempt, got %v", err)
+	}
+	if _, statErr := os.Stat(volume.MountPoint); !os.IsNotExist(statErr) {
+		t.Fatalf("expected the mount point to be removed, got %v", statErr)
+	}
+	if len(pool.Volumes) != 0 {
+		t.Fatalf("expected the volume to stay unrecorded, got %v", pool.Volumes)
+	}
+}
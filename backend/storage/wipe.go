@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"goNAS/helper"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var PhaseWipe Phase = "wipe"
+
+type WipeMode string
+
+var WipeSignatures WipeMode = "signatures"
+var WipeZero WipeMode = "zero"
+var WipeDiscard WipeMode = "discard"
+var WipeSecureErase WipeMode = "secure-erase"
+
+// WipeTokenTTL is how long a prepared wipe stays valid.
+const WipeTokenTTL = 5 * time.Minute
+
+// WipeConfirmation is the token a prepare call hands out, which a wipe of the
+// same drive in the same mode must present before it expires.
+type WipeConfirmation struct {
+	Token     string    `json:"token"`
+	DriveUuid string    `json:"driveUuid"`
+	Mode      WipeMode  `json:"mode"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ParseWipeMode parses a wipe mode string.
+func ParseWipeMode(value string) (WipeMode, error) {
+	for _, mode := range []WipeMode{WipeSignatures, WipeZero, WipeDiscard, WipeSecureErase} {
+		if WipeMode(value) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidWipeMode, value)
+}
+
+// NewWipeConfirmation creates a random confirmation token for wiping a drive in mode.
+func NewWipeConfirmation(driveUuid string, mode WipeMode) (WipeConfirmation, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return WipeConfirmation{}, err
+	}
+	return WipeConfirmation{
+		Token:     hex.EncodeToString(buf),
+		DriveUuid: driveUuid,
+		Mode:      mode,
+		ExpiresAt: time.Now().Add(WipeTokenTTL),
+	}, nil
+}
+
+// Confirm checks that token was issued for wiping the drive in mode and has not expired.
+func (w WipeConfirmation) Confirm(driveUuid string, mode WipeMode, token string, now time.Time) error {
+	if w.Token == "" || w.DriveUuid != driveUuid || w.Mode != mode ||
+		subtle.ConstantTimeCompare([]byte(w.Token), []byte(token)) != 1 {
+		return ErrWipeTokenInvalid
+	}
+	if now.After(w.ExpiresAt) {
+		return ErrWipeTokenExpired
+	}
+	return nil
+}
+
+// IsNvme reports whether the drive is an NVMe namespace.
+func (d *DriveInfo) IsNvme() bool {
+	return strings.HasPrefix(d.Name, "nvme")
+}
+
+// Holders returns the kernel devices, such as md arrays or device-mapper
+// targets, built on top of the drive or its partitions.
+func (d *DriveInfo) Holders() []string {
+	patterns := []string{
+		filepath.Join(SysBlockPath, d.Name, "holders", "*"),
+		filepath.Join(SysBlockPath, d.Name, d.Name+"*", "holders", "*"),
+	}
+	var holders []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			holders = append(holders, filepath.Base(m))
+		}
+	}
+	return holders
+}
+
+// PartitionDevices returns the device paths of the drive's partitions.
+func (d *DriveInfo) PartitionDevices() []string {
+	matches, _ := filepath.Glob(filepath.Join(SysBlockPath, d.Name, d.Name+"*"))
+	devices := make([]string, 0, len(matches))
+	for _, m := range matches {
+		devices = append(devices, DevFolder+filepath.Base(m))
+	}
+	return devices
+}
+
+// CheckWipe verifies that nothing uses the drive and that it supports mode.
+func CheckWipe(d *DriveInfo, mode WipeMode) error {
+	if mounts := helper.DeviceMounts(d.Path); len(mounts) > 0 {
+		return fmt.Errorf("%w: %s", ErrDriveMounted, strings.Join(mounts, ", "))
+	}
+	if holders := d.Holders(); len(holders) > 0 {
+		return fmt.Errorf("%w: %s", ErrDriveHeld, strings.Join(holders, ", "))
+	}
+
+	switch mode {
+	case WipeDiscard:
+		if d.IsRotational {
+			return fmt.Errorf("%w: discard requires a solid state drive", ErrWipeUnsupported)
+		}
+	case WipeSecureErase:
+		if d.IsNvme() {
+			supported, err := helper.NvmeFormatSupported(d.Path)
+			if err != nil {
+				return err
+			}
+			if !supported {
+				return fmt.Errorf("%w: controller does not support format", ErrWipeUnsupported)
+			}
+			return nil
+		}
+		sec, err := helper.ReadAtaSecurity(d.Path)
+		if err != nil {
+			return err
+		}
+		return checkAtaSecurity(sec)
+	}
+	return nil
+}
+
+// checkAtaSecurity verifies an ATA drive is able to accept a security erase.
+func checkAtaSecurity(sec helper.AtaSecurity) error {
+	switch {
+	case !sec.Supported:
+		return fmt.Errorf("%w: drive does not support ata security erase", ErrWipeUnsupported)
+	case sec.Frozen:
+		// Most firmware freezes security at boot; a suspend and resume usually clears it
+		return fmt.Errorf("%w: drive security is frozen", ErrWipeUnsupported)
+	case sec.Enabled:
+		return fmt.Errorf("%w: drive already has a security password set", ErrWipeUnsupported)
+	}
+	return nil
+}
+
+// WipeDrive erases the drive in mode. Callers must run CheckWipe first.
+func WipeDrive(ctx context.Context, d *DriveInfo, mode WipeMode, report ProgressFunc) error {
+	report.report(PhaseWipe, 0)
+	var err error
+	switch mode {
+	case WipeSignatures:
+		// Partitions go first; md superblocks and filesystems on them are lost with the table otherwise
+		err = helper.WipeSignatures(append(d.PartitionDevices(), d.Path)...)
+	case WipeZero:
+		err = helper.ZeroFill(ctx, d.Path, d.SizeBytes, func(written uint64) {
+			report.report(PhaseWipe, float64(written)/float64(d.SizeBytes)*100)
+		})
+	case WipeDiscard:
+		err = helper.Blkdiscard(d.Path)
+	case WipeSecureErase:
+		if d.IsNvme() {
+			err = helper.NvmeSecureErase(d.Path)
+			break
+		}
+		var sec helper.AtaSecurity
+		if sec, err = helper.ReadAtaSecurity(d.Path); err == nil {
+			err = helper.AtaSecureErase(d.Path, sec.Enhanced)
+		}
+	default:
+		err = fmt.Errorf("%w: %q", ErrInvalidWipeMode, mode)
+	}
+	if err != nil {
+		return err
+	}
+	report.report(PhaseWipe, 100)
+	return nil
+}
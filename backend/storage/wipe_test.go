@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"goNAS/helper"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseWipeMode(t *testing.T) {
+	for _, value := range []string{"signatures", "zero", "discard", "secure-erase"} {
+		if mode, err := ParseWipeMode(value); err != nil || string(mode) != value {
+			t.Fatalf("expected %s, got %q %v", value, mode, err)
+		}
+	}
+	if _, err := ParseWipeMode("shred"); !errors.Is(err, ErrInvalidWipeMode) {
+		t.Fatalf("expected ErrInvalidWipeMode, got %v", err)
+	}
+}
+
+func TestWipeConfirmation(t *testing.T) {
+	confirmation, err := NewWipeConfirmation("drive", WipeZero)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	if err = confirmation.Confirm("drive", WipeZero, confirmation.Token, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = confirmation.Confirm("drive", WipeSignatures, confirmation.Token, now); !errors.Is(err, ErrWipeTokenInvalid) {
+		t.Fatalf("expected a different mode to be rejected, got %v", err)
+	}
+	if err = confirmation.Confirm("other", WipeZero, confirmation.Token, now); !errors.Is(err, ErrWipeTokenInvalid) {
+		t.Fatalf("expected a different drive to be rejected, got %v", err)
+	}
+	if err = confirmation.Confirm("drive", WipeZero, "guess", now); !errors.Is(err, ErrWipeTokenInvalid) {
+		t.Fatalf("expected a wrong token to be rejected, got %v", err)
+	}
+	if err = confirmation.Confirm("drive", WipeZero, confirmation.Token, now.Add(WipeTokenTTL+time.Second)); !errors.Is(err, ErrWipeTokenExpired) {
+		t.Fatalf("expected ErrWipeTokenExpired, got %v", err)
+	}
+	if err = (WipeConfirmation{}).Confirm("drive", WipeZero, "", now); !errors.Is(err, ErrWipeTokenInvalid) {
+		t.Fatalf("expected an unprepared wipe to be rejected, got %v", err)
+	}
+}
+
+func TestCheckWipe(t *testing.T) {
+	root := t.TempDir()
+	mounts := filepath.Join(root, "mounts")
+	if err := os.WriteFile(mounts, []byte("/dev/sdb1 /media ext4 rw 0 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "sys", "sdc", "holders", "md127"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	previousMounts, previousSys := helper.MountsPath, SysBlockPath
+	helper.MountsPath, SysBlockPath = mounts, filepath.Join(root, "sys")
+	defer func() { helper.MountsPath, SysBlockPath = previousMounts, previousSys }()
+
+	free := &DriveInfo{Name: "sda", Path: "/dev/sda", IsRotational: true}
+	if err := CheckWipe(free, WipeZero); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckWipe(free, WipeDiscard); !errors.Is(err, ErrWipeUnsupported) {
+		t.Fatalf("expected discard on a rotational drive to be refused, got %v", err)
+	}
+	if err := CheckWipe(&DriveInfo{Name: "sdb", Path: "/dev/sdb"}, WipeSignatures); !errors.Is(err, ErrDriveMounted) {
+		t.Fatalf("expected ErrDriveMounted, got %v", err)
+	}
+	if err := CheckWipe(&DriveInfo{Name: "sdc", Path: "/dev/sdc"}, WipeSignatures); !errors.Is(err, ErrDriveHeld) {
+		t.Fatalf("expected ErrDriveHeld, got %v", err)
+	}
+}
+
+func TestPartitionDevices(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"nvme0n1/nvme0n1p1", "nvme0n1/nvme0n1p2", "nvme0n1/queue"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := SysBlockPath
+	SysBlockPath = root
+	defer func() { SysBlockPath = previous }()
+
+	got := (&DriveInfo{Name: "nvme0n1"}).PartitionDevices()
+	if len(got) != 2 || got[0] != "/dev/nvme0n1p1" || got[1] != "/dev/nvme0n1p2" {
+		t.Fatalf("unexpected partitions %v", got)
+	}
+	if got = (&DriveInfo{Name: "sda"}).PartitionDevices(); len(got) != 0 {
+		t.Fatalf("expected no partitions, got %v", got)
+	}
+}
+
+func TestCheckAtaSecurity(t *testing.T) {
+	if err := checkAtaSecurity(helper.AtaSecurity{Supported: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, sec := range []helper.AtaSecurity{{}, {Supported: true, Frozen: true}, {Supported: true, Enabled: true}} {
+		if err := checkAtaSecurity(sec); !errors.Is(err, ErrWipeUnsupported) {
+			t.Fatalf("expected %+v to be refused, got %v", sec, err)
+		}
+	}
+}
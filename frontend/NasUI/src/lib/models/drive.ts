@@ -41,6 +41,7 @@ export type AdoptedDrive = {
     uuid: string;
     created_at: string;
     poolId : string;
+    role: "active" | "spare";
 }
 
 
@@ -70,5 +71,5 @@ export async function fetchAdoptedDrives(timeoutMs: number = 5000): Promise<Reco
     }
     const data = await res.json();
 
-    return data.data as Record<string, AdoptedDrive>;
+    return data.data.drives as Record<string, AdoptedDrive>;
 }